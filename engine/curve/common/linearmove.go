@@ -104,6 +104,34 @@ func (c *Curve) Merge(o curve.Curve) error {
 	return c.data.Merge(o.Data())
 }
 
+// Truncate discards all data after the input tick. If the input tick falls
+// between two data points, the interpolated position at the input tick is
+// added to the curve.
+func (c *Curve) Truncate(t id.Tick) {
+	var v interface{}
+	if c.Data().Len() > 0 {
+		v = c.Get(t)
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	i := c.data.Search(t)
+	if i < c.data.Len() && c.data.Tick(i) == t {
+		i++
+	}
+	if i < c.data.Len() {
+		c.data.Truncate(c.data.Tick(i))
+		if i > 0 {
+			c.data.Set(t, v)
+		}
+	}
+
+	if c.tick > t {
+		c.tick = t
+	}
+}
+
 // Get queries the Curve at a specific point for an interpolated value.
 func (c *Curve) Get(t id.Tick) interface{} {
 	c.mux.RLock()
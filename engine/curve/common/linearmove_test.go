@@ -264,3 +264,81 @@ func TestGet(t *testing.T) {
 		})
 	}
 }
+
+func TestTruncate(t *testing.T) {
+	const eid = "eid"
+
+	newCurve := func() *Curve {
+		c := New(eid, 2)
+		c.Add(0, &gdpb.Position{X: 0, Y: 0})
+		c.Add(1, &gdpb.Position{X: 1, Y: 1})
+		c.Add(2, &gdpb.Position{X: 2, Y: 2})
+		return c
+	}
+
+	testConfigs := []struct {
+		name     string
+		c        *Curve
+		t        id.Tick
+		want     map[id.Tick]*gdpb.Position
+		wantTick id.Tick
+	}{
+		{
+			name:     "TruncateEmpty",
+			c:        New(eid, 2),
+			t:        1,
+			want:     map[id.Tick]*gdpb.Position{},
+			wantTick: 1,
+		},
+		{
+			name: "TruncateAtDatum",
+			c:    newCurve(),
+			t:    1,
+			want: map[id.Tick]*gdpb.Position{
+				0: &gdpb.Position{X: 0, Y: 0},
+				1: &gdpb.Position{X: 1, Y: 1},
+			},
+			wantTick: 1,
+		},
+		{
+			name: "TruncateInterpolated",
+			c:    newCurve(),
+			t:    1.5,
+			want: map[id.Tick]*gdpb.Position{
+				0:   &gdpb.Position{X: 0, Y: 0},
+				1:   &gdpb.Position{X: 1, Y: 1},
+				1.5: &gdpb.Position{X: 1.5, Y: 1.5},
+			},
+			wantTick: 1.5,
+		},
+		{
+			name: "TruncateAfterLastDatum",
+			c:    newCurve(),
+			t:    3,
+			want: map[id.Tick]*gdpb.Position{
+				0: &gdpb.Position{X: 0, Y: 0},
+				1: &gdpb.Position{X: 1, Y: 1},
+				2: &gdpb.Position{X: 2, Y: 2},
+			},
+			wantTick: 2,
+		},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			c.c.Truncate(c.t)
+
+			got := map[id.Tick]*gdpb.Position{}
+			for i := 0; i < c.c.Data().Len(); i++ {
+				tick := c.c.Data().Tick(i)
+				got[tick] = c.c.Data().Get(tick).(*gdpb.Position)
+			}
+			if diff := cmp.Diff(c.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("Data() mismatch (-want +got):\n%v", diff)
+			}
+			if got := c.c.Tick(); got != c.wantTick {
+				t.Errorf("Tick() = %v, want = %v", got, c.wantTick)
+			}
+		})
+	}
+}
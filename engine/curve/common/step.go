@@ -78,6 +78,24 @@ func (c *Curve) Add(tick id.Tick, value interface{}) error {
 	return nil
 }
 
+// Truncate discards all data after the input tick.
+func (c *Curve) Truncate(t id.Tick) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	i := c.data.Search(t)
+	if i < c.data.Len() && c.data.Tick(i) == t {
+		i++
+	}
+	if i < c.data.Len() {
+		c.data.Truncate(c.data.Tick(i))
+	}
+
+	if c.tick > t {
+		c.tick = t
+	}
+}
+
 // Merge takes as input another Curve of the same type and replaces any
 // data in the original Curve which occurs after the earliest element of the
 // replacement Curve. In the game, this will occur when the original Curve
@@ -133,6 +151,24 @@ func (c *Curve) Export(tick id.Tick) *gdpb.Curve {
 				Datum: &gdpb.CurveDatum_BoolDatum{c.data.Get(c.data.Tick(j)).(bool)},
 			})
 		}
+	case reflect.TypeOf(&gdpb.Position{}):
+		for j := i; j < c.data.Len(); j++ {
+			pb.Data = append(pb.GetData(), &gdpb.CurveDatum{
+				Tick:  c.data.Tick(j).Value(),
+				Datum: &gdpb.CurveDatum_PositionDatum{c.data.Get(c.data.Tick(j)).(*gdpb.Position)},
+			})
+		}
+	default:
+		// String-like types, e.g. id.ClientID, are exported as raw
+		// strings.
+		if c.DatumType().Kind() == reflect.String {
+			for j := i; j < c.data.Len(); j++ {
+				pb.Data = append(pb.GetData(), &gdpb.CurveDatum{
+					Tick:  c.data.Tick(j).Value(),
+					Datum: &gdpb.CurveDatum_StringDatum{reflect.ValueOf(c.data.Get(c.data.Tick(j))).String()},
+				})
+			}
+		}
 	}
 
 	return pb
@@ -6,8 +6,11 @@ import (
 
 	"github.com/downflux/game/engine/curve/curve"
 	"github.com/downflux/game/engine/id/id"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
 
 	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
 )
 
 var (
@@ -80,3 +83,78 @@ func TestGet(t *testing.T) {
 		})
 	}
 }
+
+func TestExportString(t *testing.T) {
+	const t0 = 100
+	const v0 = id.ClientID("client-id")
+
+	c := New(
+		"entity-id",
+		0,
+		gcpb.EntityProperty_ENTITY_PROPERTY_CLIENT_ID,
+		reflect.TypeOf(id.ClientID("")),
+	)
+	c.Add(t0, v0)
+
+	want := &gdpb.Curve{
+		EntityId: "entity-id",
+		Type:     gcpb.CurveType_CURVE_TYPE_STEP,
+		Property: gcpb.EntityProperty_ENTITY_PROPERTY_CLIENT_ID,
+		Data: []*gdpb.CurveDatum{
+			{Tick: t0, Datum: &gdpb.CurveDatum_StringDatum{v0.Value()}},
+		},
+	}
+	if diff := cmp.Diff(want, c.Export(0), protocmp.Transform()); diff != "" {
+		t.Errorf("Export() mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestExportPosition(t *testing.T) {
+	const t0 = 100
+	v0 := &gdpb.Position{X: 1, Y: 2}
+
+	c := New(
+		"entity-id",
+		0,
+		gcpb.EntityProperty_ENTITY_PROPERTY_RALLY_POINT,
+		reflect.TypeOf(&gdpb.Position{}),
+	)
+	c.Add(t0, v0)
+
+	want := &gdpb.Curve{
+		EntityId: "entity-id",
+		Type:     gcpb.CurveType_CURVE_TYPE_STEP,
+		Property: gcpb.EntityProperty_ENTITY_PROPERTY_RALLY_POINT,
+		Data: []*gdpb.CurveDatum{
+			{Tick: t0, Datum: &gdpb.CurveDatum_PositionDatum{v0}},
+		},
+	}
+	if diff := cmp.Diff(want, c.Export(0), protocmp.Transform()); diff != "" {
+		t.Errorf("Export() mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	const t0 = 100
+	const t1 = 200
+	const v0 = float64(101)
+	const v1 = float64(201)
+
+	c := New(
+		"entity-id",
+		0,
+		gcpb.EntityProperty_ENTITY_PROPERTY_UNKNOWN,
+		reflect.TypeOf(v0),
+	)
+	c.Add(t0, v0)
+	c.Add(t1, v1)
+
+	c.Truncate(t1 - 1)
+
+	if got := c.Data().Len(); got != 1 {
+		t.Errorf("Len() = %v, want = %v", got, 1)
+	}
+	if got := c.Get(t1).(float64); got != v0 {
+		t.Errorf("Get() = %v, want = %v", got, v0)
+	}
+}
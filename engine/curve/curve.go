@@ -61,6 +61,12 @@ type Curve interface {
 	// was updated after the source curve.
 	Merge(c Curve) error
 
+	// Truncate discards all values of the curve after the input tick,
+	// and rewinds the last updated tick of the curve to the input tick.
+	// The value of the curve at the input tick is preserved. This is
+	// used to roll back the game state.
+	Truncate(t id.Tick)
+
 	// Export returns the last N values of the curve as a protobuf,
 	// ready to be sent on wire. Setting tick = 0 will export the entire
 	// curve.
@@ -13,6 +13,14 @@ type Component struct {
 	end   id.Tick
 }
 
+// New constructs a new Component instance for an Entity spawned at the input
+// tick.
+func New(start id.Tick) *Component {
+	return &Component{
+		start: start,
+	}
+}
+
 // Start returns the tick at which the Entity is spawned. The tick is set in the
 // constructor (delegated to each concrete impementation).
 func (e Component) Start() id.Tick { return e.start }
@@ -22,5 +30,7 @@ func (e Component) Start() id.Tick { return e.start }
 // hence the need for this marker.
 func (e Component) End() id.Tick { return e.end }
 
-// Delete marks the target Entity as having been destroyed.
-func (e Component) Delete(tick id.Tick) { e.end = tick }
+// Delete marks the target Entity as having been destroyed. Deleting the Entity
+// at tick zero marks the Entity as not destroyed, which is used when rolling
+// back the game to before the Entity was destroyed.
+func (e *Component) Delete(tick id.Tick) { e.end = tick }
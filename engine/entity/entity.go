@@ -34,6 +34,11 @@ type Entity interface {
 	Curves() *list.List
 	Export() *gdpb.Entity
 
+	// ClientID returns the UUID of the client which owns the Entity at
+	// the input tick. Entities which are not owned by any client return
+	// an empty UUID.
+	ClientID(tick id.Tick) id.ClientID
+
 	// Start returns the game tick at which the Entity was created.
 	Start() id.Tick
 
@@ -66,6 +71,13 @@ func New(t gcpb.EntityType, eid id.EntityID, cidc *step.Curve) *Base {
 func (e Base) Type() gcpb.EntityType { return e.entityType }
 func (e Base) ID() id.EntityID       { return e.id }
 
+func (e Base) ClientID(tick id.Tick) id.ClientID {
+	if e.cidc == nil {
+		return ""
+	}
+	return e.cidc.Get(tick).(id.ClientID)
+}
+
 // Export converts the static properties of the entity into a gdpb.Entity
 // object. Note that dynamic properties (e.g. position) are not considered here.
 // These properties must be manually converted via Curve.Export instead.
@@ -51,3 +51,15 @@ func (l *List) Append(e entity.Entity) error {
 	l.entities[e.ID()] = e
 	return nil
 }
+
+// Remove stops tracking the Entity with the given UUID. Entities are not
+// removed during normal play; this is only used to undo the creation of an
+// Entity when rolling back the game state.
+func (l *List) Remove(eid id.EntityID) error {
+	if _, found := l.entities[eid]; !found {
+		return status.Error(codes.NotFound, "no entity exists with the given ID")
+	}
+
+	delete(l.entities, eid)
+	return nil
+}
@@ -2,6 +2,11 @@
 package list
 
 import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
 	"github.com/downflux/game/engine/fsm/action"
 	"github.com/downflux/game/engine/fsm/fsm"
 	"github.com/downflux/game/engine/id/id"
@@ -34,6 +39,16 @@ func New(fsmType fcpb.FSMType) *List {
 func (l *List) Get(iid id.ActionID) action.Action { return l.actions[iid] }
 func (l *List) Type() fcpb.FSMType                { return l.fsmType }
 
+// Iter returns the list of actions currently tracked by the List. This is
+// used for loop ranges.
+func (l *List) Iter() []action.Action {
+	var actions []action.Action
+	for _, i := range l.actions {
+		actions = append(actions, i)
+	}
+	return actions
+}
+
 func (l *List) Clear() error {
 	for iid, i := range l.actions {
 		s, err := i.State()
@@ -48,14 +63,68 @@ func (l *List) Clear() error {
 	return nil
 }
 
+// Accept runs the Visitor over all actions in the List, and returns the
+// sorted IDs of the actions which were deferred to the next tick.
+//
+// Actions are deferred if they have not started by the time the input
+// context is done, or if the Visitor abandons the action by returning the
+// context error, e.g. when interrupted during a path search. Which actions are
+// deferred this way is not deterministic -- in order to reproduce the game
+// (e.g. on rollback or replay), the caller must record the returned IDs and
+// pass them back as the input skip list, which defers the listed actions
+// without visiting them.
+//
+// If the Visitor implements visitor.Planner, the Visitor is first given the
+// full list of actions.
+//
 // TODO(minkezhang): Rename to make clear List is not an FSM agent.
-func (l *List) Accept(v visitor.Visitor) error {
+func (l *List) Accept(ctx context.Context, v visitor.Visitor, skip []id.ActionID) ([]id.ActionID, error) {
+	if p, ok := v.(visitor.Planner); ok {
+		var agents []visitor.Agent
+		for _, i := range l.actions {
+			agents = append(agents, i)
+		}
+		if err := p.Plan(ctx, agents); err != nil {
+			return nil, err
+		}
+	}
+
+	skipped := map[id.ActionID]bool{}
+	for _, aid := range skip {
+		skipped[aid] = true
+	}
+
+	var mux sync.Mutex
+	var deferred []id.ActionID
+
 	var eg errgroup.Group
-	for _, i := range l.actions {
-		i := i
-		eg.Go(func() error { return i.Accept(v) })
+	for aid, i := range l.actions {
+		aid, i := aid, i
+		if skipped[aid] {
+			deferred = append(deferred, aid)
+			continue
+		}
+		eg.Go(func() error {
+			err := ctx.Err()
+			if err == nil {
+				err = i.Accept(ctx, v)
+			}
+			if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+				mux.Lock()
+				defer mux.Unlock()
+
+				deferred = append(deferred, aid)
+				return nil
+			}
+			return err
+		})
 	}
-	return eg.Wait()
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(deferred, func(i, j int) bool { return deferred[i] < deferred[j] })
+	return deferred, nil
 }
 
 // Merge replaces internal FSMs with FSMs of higher priority.
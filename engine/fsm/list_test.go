@@ -1,6 +1,7 @@
 package list
 
 import (
+	"context"
 	"testing"
 
 	"github.com/downflux/game/engine/fsm/action"
@@ -8,10 +9,12 @@ import (
 	"github.com/downflux/game/engine/fsm/mock/dependent"
 	"github.com/downflux/game/engine/fsm/mock/simple"
 	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/visitor/visitor"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 
 	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+	simplevisitor "github.com/downflux/game/engine/visitor/mock/simple"
 )
 
 const (
@@ -105,3 +108,110 @@ func TestAddCancel(t *testing.T) {
 		t.Errorf("Get() mismatch (-want +got):\n%v", diff)
 	}
 }
+
+func TestAccept(t *testing.T) {
+	l := New(fsmType)
+	if err := l.Add(simple.New(id.ActionID("action-id"), 0)); err != nil {
+		t.Fatalf("Add() = %v, want = nil", err)
+	}
+
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testConfigs := []struct {
+		name         string
+		ctx          context.Context
+		skip         []id.ActionID
+		want         int
+		wantDeferred int
+	}{
+		{name: "TestAccept", ctx: context.Background(), want: 1, wantDeferred: 0},
+		{name: "TestAcceptDeadlineExceeded", ctx: expired, want: 0, wantDeferred: 1},
+		{name: "TestAcceptSkip", ctx: context.Background(), skip: []id.ActionID{"action-id"}, want: 0, wantDeferred: 1},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			v := simplevisitor.New()
+			deferred, err := l.Accept(c.ctx, v, c.skip)
+			if err != nil {
+				t.Fatalf("Accept() = _, %v, want = nil", err)
+			}
+			if got := v.Count(); got != c.want {
+				t.Errorf("Count() = %v, want = %v", got, c.want)
+			}
+			if got := len(deferred); got != c.wantDeferred {
+				t.Errorf("len() = %v, want = %v", got, c.wantDeferred)
+			}
+		})
+	}
+}
+
+// interrupted is a mock Visitor which abandons every action once the context
+// is done.
+type interrupted struct {
+	*simplevisitor.Visitor
+
+	cancel context.CancelFunc
+}
+
+func (v *interrupted) Visit(ctx context.Context, a visitor.Agent) error {
+	v.cancel()
+	return ctx.Err()
+}
+
+func TestAcceptInterrupted(t *testing.T) {
+	l := New(fsmType)
+	if err := l.Add(simple.New(id.ActionID("action-id"), 0)); err != nil {
+		t.Fatalf("Add() = %v, want = nil", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deferred, err := l.Accept(ctx, &interrupted{Visitor: simplevisitor.New(), cancel: cancel}, nil)
+	if err != nil {
+		t.Fatalf("Accept() = _, %v, want = nil", err)
+	}
+	if diff := cmp.Diff([]id.ActionID{"action-id"}, deferred); diff != "" {
+		t.Errorf("Accept() mismatch (-want +got):\n%v", diff)
+	}
+}
+
+// planner is a mock visitor.Planner which records the number of planned
+// agents, and how many agents were visited before planning.
+type planner struct {
+	*simplevisitor.Visitor
+
+	planned int
+	visited int
+}
+
+func (p *planner) Plan(ctx context.Context, agents []visitor.Agent) error {
+	p.planned = len(agents)
+	p.visited = p.Count()
+	return nil
+}
+
+func TestAcceptPlanner(t *testing.T) {
+	l := New(fsmType)
+	for _, aid := range []id.ActionID{"action-a", "action-b"} {
+		if err := l.Add(simple.New(aid, 0)); err != nil {
+			t.Fatalf("Add() = %v, want = nil", err)
+		}
+	}
+
+	p := &planner{Visitor: simplevisitor.New()}
+	if _, err := l.Accept(context.Background(), p, nil); err != nil {
+		t.Fatalf("Accept() = _, %v, want = nil", err)
+	}
+	if p.planned != 2 {
+		t.Errorf("Plan() received %v agents, want = %v", p.planned, 2)
+	}
+	if p.visited != 0 {
+		t.Errorf("Count() = %v before Plan(), want = %v", p.visited, 0)
+	}
+	if got := p.Count(); got != 2 {
+		t.Errorf("Count() = %v, want = %v", got, 2)
+	}
+}
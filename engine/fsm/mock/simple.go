@@ -1,6 +1,8 @@
 package simple
 
 import (
+	"context"
+
 	"github.com/downflux/game/engine/fsm/action"
 	"github.com/downflux/game/engine/fsm/fsm"
 	"github.com/downflux/game/engine/id/id"
@@ -39,8 +41,8 @@ func New(aid id.ActionID, priority int) *Action {
 	}
 }
 
-func (n *Action) Accept(v visitor.Visitor) error { return v.Visit(n) }
-func (n *Action) ID() id.ActionID                { return n.id }
+func (n *Action) Accept(ctx context.Context, v visitor.Visitor) error { return v.Visit(ctx, n) }
+func (n *Action) ID() id.ActionID                                     { return n.id }
 
 func (n *Action) Precedence(i action.Action) bool {
 	if i.Type() != fsmType || n.ID() != i.ID() {
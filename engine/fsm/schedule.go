@@ -110,6 +110,18 @@ func (s *Schedule) Get(fsmType fcpb.FSMType) *list.List {
 	return s.actions[fsmType]
 }
 
+// Iter returns a flattened list of all actions tracked by the Schedule.
+func (s *Schedule) Iter() []action.Action {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	var actions []action.Action
+	for _, l := range s.actions {
+		actions = append(actions, l.Iter()...)
+	}
+	return actions
+}
+
 func (s *Schedule) Clear() error {
 	s.mux.Lock()
 	defer s.mux.Unlock()
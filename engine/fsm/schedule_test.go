@@ -151,3 +151,24 @@ func TestPop(t *testing.T) {
 		t.Errorf("Get() = %v, want = nil", got)
 	}
 }
+
+func TestIter(t *testing.T) {
+	aid := id.ActionID("action-id")
+
+	s := New([]fcpb.FSMType{fcpb.FSMType_FSM_TYPE_MOVE})
+	if got := s.Iter(); got != nil {
+		t.Fatalf("Iter() = %v, want = nil", got)
+	}
+
+	if err := s.Extend([]action.Action{simple.New(aid, 0)}); err != nil {
+		t.Fatalf("Extend() = %v, want = nil", err)
+	}
+
+	got := s.Iter()
+	if len(got) != 1 {
+		t.Fatalf("len() = %v, want = %v", len(got), 1)
+	}
+	if got[0].ID() != aid {
+		t.Errorf("ID() = %v, want = %v", got[0].ID(), aid)
+	}
+}
@@ -49,10 +49,11 @@ func (s *GameState) Export(tick id.Tick, filter *dirty.List) *gdpb.GameState {
 	state := &gdpb.GameState{}
 
 	for _, e := range filter.Entities() {
-		state.Entities = append(
-			state.GetEntities(),
-			s.entities.Get(e.ID).Export(),
-		)
+		en := s.entities.Get(e.ID)
+
+		pb := en.Export()
+		pb.End = en.End().Value()
+		state.Entities = append(state.GetEntities(), pb)
 	}
 
 	for _, c := range filter.Curves() {
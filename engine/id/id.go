@@ -33,6 +33,14 @@ type ClientID ID
 
 func (id ClientID) Value() string { return string(id) }
 
+type GameID ID
+
+func (id GameID) Value() string { return string(id) }
+
+type LobbyID ID
+
+func (id LobbyID) Value() string { return string(id) }
+
 const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
 
 // RandomString returns a random string of the specified length.
@@ -44,3 +52,24 @@ func RandomString(n int) string {
 	}
 	return string(b)
 }
+
+// Generator produces a deterministic sequence of random strings. Two Generator
+// instances constructed with the same seed will return the same sequence of
+// strings. This is not thread-safe.
+type Generator struct {
+	r *rand.Rand
+}
+
+// NewGenerator constructs a new Generator instance with the given seed.
+func NewGenerator(seed int64) *Generator {
+	return &Generator{r: rand.New(rand.NewSource(seed))}
+}
+
+// RandomString returns the next random string of the specified length.
+func (g *Generator) RandomString(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = charset[g.r.Intn(len(charset))]
+	}
+	return string(b)
+}
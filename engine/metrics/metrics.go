@@ -0,0 +1,355 @@
+// Package metrics implements a minimal set of Prometheus-style metrics which
+// are exported in the Prometheus text exposition format.
+//
+// See https://prometheus.io/docs/instrumenting/exposition_formats/ for more
+// information.
+//
+// Example
+//
+//  r := metrics.New()
+//  c := r.NewCounter("requests_total", "Number of requests.", "method")
+//  c.Add(1, "GET")
+//  http.Handle("/metrics", r)
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	metricTypeCounter   = "counter"
+	metricTypeGauge     = "gauge"
+	metricTypeHistogram = "histogram"
+)
+
+var (
+	// DefaultBuckets are the default histogram buckets, tuned for
+	// measuring latencies in seconds.
+	DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+
+	// labelEscaper escapes label values as required by the text
+	// exposition format. Unlike Go string literals, all other characters
+	// (including non-ASCII characters) are written as-is.
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// metric is a single named metric which may be exported.
+type metric interface {
+	write(w io.Writer) error
+}
+
+// Registry tracks a list of metrics and exports them on demand. This struct
+// implements the http.Handler interface.
+type Registry struct {
+	// mux guards the metrics list.
+	mux     sync.Mutex
+	metrics []metric
+}
+
+// New constructs a new Registry instance.
+func New() *Registry { return &Registry{} }
+
+func (r *Registry) add(m metric) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.metrics = append(r.metrics, m)
+}
+
+// Write exports all registered metrics in the text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for _, m := range r.metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP exports all registered metrics to the HTTP client.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := r.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// base implements the label handling logic shared by all metric types.
+type base struct {
+	name       string   // Read-only.
+	help       string   // Read-only.
+	metricType string   // Read-only.
+	labels     []string // Read-only.
+}
+
+// key transforms the list of label values into a lookup key.
+func (m base) key(values []string) string {
+	if len(values) != len(m.labels) {
+		panic(fmt.Sprintf("metric %v expects %v label values, got %v", m.name, len(m.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// series formats the metric name along with the set of label values, e.g.
+//
+//  requests_total{method="GET"}
+//
+// Additional label pairs (e.g. the histogram bucket) are appended to the end
+// of the list.
+func (m base) series(suffix string, values []string, extra ...string) string {
+	var pairs []string
+	for i, l := range m.labels {
+		pairs = append(pairs, fmt.Sprintf("%v=\"%v\"", l, labelEscaper.Replace(values[i])))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%v=\"%v\"", extra[i], labelEscaper.Replace(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return m.name + suffix
+	}
+	return fmt.Sprintf("%v%v{%v}", m.name, suffix, strings.Join(pairs, ","))
+}
+
+func (m base) header(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", m.name, m.help, m.name, m.metricType)
+	return err
+}
+
+func format(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// scalar implements a single float64 value per label set, and is used by
+// both counters and gauges.
+type scalar struct {
+	base
+
+	// mux guards the values and labelValues properties.
+	mux         sync.Mutex
+	values      map[string]float64
+	labelValues map[string][]string
+}
+
+func newScalar(name string, help string, metricType string, labels []string) *scalar {
+	return &scalar{
+		base: base{
+			name:       name,
+			help:       help,
+			metricType: metricType,
+			labels:     labels,
+		},
+		values:      map[string]float64{},
+		labelValues: map[string][]string{},
+	}
+}
+
+func (m *scalar) update(f func(v float64) float64, values []string) {
+	k := m.key(values)
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.values[k] = f(m.values[k])
+	m.labelValues[k] = values
+}
+
+func (m *scalar) get(values []string) float64 {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return m.values[m.key(values)]
+}
+
+func (m *scalar) write(w io.Writer) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if err := m.header(w); err != nil {
+		return err
+	}
+
+	var keys []string
+	for k := range m.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(w, "%v %v\n", m.series("", m.labelValues[k]), format(m.values[k])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	*scalar
+}
+
+// NewCounter registers a new Counter with the input set of label names.
+func (r *Registry) NewCounter(name string, help string, labels ...string) *Counter {
+	c := &Counter{scalar: newScalar(name, help, metricTypeCounter, labels)}
+	r.add(c)
+	return c
+}
+
+// Add increments the counter with the specified label values. The increment
+// must be non-negative.
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("cannot decrement counter %v", c.name))
+	}
+	c.update(func(u float64) float64 { return u + v }, values)
+}
+
+// Get returns the current value of the counter with the specified label
+// values.
+func (c *Counter) Get(values ...string) float64 { return c.get(values) }
+
+// Gauge is a value which may arbitrarily go up or down.
+type Gauge struct {
+	*scalar
+}
+
+// NewGauge registers a new Gauge with the input set of label names.
+func (r *Registry) NewGauge(name string, help string, labels ...string) *Gauge {
+	g := &Gauge{scalar: newScalar(name, help, metricTypeGauge, labels)}
+	r.add(g)
+	return g
+}
+
+// Set overwrites the gauge value with the specified label values.
+func (g *Gauge) Set(v float64, values ...string) {
+	g.update(func(float64) float64 { return v }, values)
+}
+
+// Get returns the current value of the gauge with the specified label values.
+func (g *Gauge) Get(values ...string) float64 { return g.get(values) }
+
+// distribution tracks the observations of a single histogram label set.
+type distribution struct {
+	values []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Histogram counts observations into a list of configurable buckets.
+type Histogram struct {
+	base
+
+	buckets []float64 // Read-only.
+
+	// mux guards the distributions property.
+	mux           sync.Mutex
+	distributions map[string]*distribution
+}
+
+// NewHistogram registers a new Histogram with the input set of label names.
+// The input buckets are the inclusive upper bounds of each bucket; an
+// additional +Inf bucket is implicitly added.
+func (r *Registry) NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	b := append([]float64{}, buckets...)
+	sort.Float64s(b)
+
+	h := &Histogram{
+		base: base{
+			name:       name,
+			help:       help,
+			metricType: metricTypeHistogram,
+			labels:     labels,
+		},
+		buckets:       b,
+		distributions: map[string]*distribution{},
+	}
+	r.add(h)
+	return h
+}
+
+// Observe adds a single observation to the histogram with the specified label
+// values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	k := h.key(values)
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	d, found := h.distributions[k]
+	if !found {
+		d = &distribution{
+			values: values,
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.distributions[k] = d
+	}
+
+	for i, b := range h.buckets {
+		if v <= b {
+			d.counts[i]++
+		}
+	}
+	d.count++
+	d.sum += v
+}
+
+// Count returns the total number of observations with the specified label
+// values.
+func (h *Histogram) Count(values ...string) uint64 {
+	k := h.key(values)
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if d, found := h.distributions[k]; found {
+		return d.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if err := h.header(w); err != nil {
+		return err
+	}
+
+	var keys []string
+	for k := range h.distributions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		d := h.distributions[k]
+		for i, b := range h.buckets {
+			if _, err := fmt.Fprintf(w, "%v %v\n", h.series("_bucket", d.values, "le", format(b)), d.counts[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(
+			w,
+			"%v %v\n%v %v\n%v %v\n",
+			h.series("_bucket", d.values, "le", format(math.Inf(1))), d.count,
+			h.series("_sum", d.values), format(d.sum),
+			h.series("_count", d.values), d.count,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
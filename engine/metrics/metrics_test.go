@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWrite(t *testing.T) {
+	r := New()
+
+	c := r.NewCounter("requests_total", "Number of requests.", "method")
+	c.Add(1, "GET")
+	c.Add(2, "GET")
+	c.Add(1, "POST")
+
+	g := r.NewGauge("clients", "Number of clients.")
+	g.Set(3)
+
+	h := r.NewHistogram("latency_seconds", "Request latency.", []float64{1, 0.5})
+	h.Observe(0.25)
+	h.Observe(0.75)
+	h.Observe(2)
+
+	want := `# HELP requests_total Number of requests.
+# TYPE requests_total counter
+requests_total{method="GET"} 3
+requests_total{method="POST"} 1
+# HELP clients Number of clients.
+# TYPE clients gauge
+clients 3
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.5"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 3
+latency_seconds_count 3
+`
+
+	var got bytes.Buffer
+	if err := r.Write(&got); err != nil {
+		t.Fatalf("Write() = %v, want = nil", err)
+	}
+	if diff := cmp.Diff(want, got.String()); diff != "" {
+		t.Errorf("Write() mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestWriteEscape(t *testing.T) {
+	r := New()
+
+	c := r.NewCounter("requests_total", "Number of requests.", "path")
+	c.Add(1, "C:\\dir\n\"é\"\t")
+
+	// Only backslashes, double quotes, and newlines are escaped.
+	want := "# HELP requests_total Number of requests.\n" +
+		"# TYPE requests_total counter\n" +
+		"requests_total{path=\"C:\\\\dir\\n\\\"é\\\"\t\"} 1\n"
+
+	var got bytes.Buffer
+	if err := r.Write(&got); err != nil {
+		t.Fatalf("Write() = %v, want = nil", err)
+	}
+	if diff := cmp.Diff(want, got.String()); diff != "" {
+		t.Errorf("Write() mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestGet(t *testing.T) {
+	r := New()
+
+	c := r.NewCounter("requests_total", "Number of requests.", "method")
+	c.Add(1, "GET")
+	if got := c.Get("GET"); got != 1 {
+		t.Errorf("Get() = %v, want = %v", got, 1)
+	}
+	if got := c.Get("POST"); got != 0 {
+		t.Errorf("Get() = %v, want = %v", got, 0)
+	}
+
+	h := r.NewHistogram("latency_seconds", "Request latency.", DefaultBuckets, "method")
+	h.Observe(1, "GET")
+	if got := h.Count("GET"); got != 1 {
+		t.Errorf("Count() = %v, want = %v", got, 1)
+	}
+}
@@ -159,7 +159,7 @@ func (c *Client) Send(m *apipb.StreamDataResponse) error {
 	}
 
 	// Only send data if there is interesting data to send.
-	if m.GetState().GetEntities() != nil || m.GetState().GetCurves() != nil {
+	if m.GetState().GetEntities() != nil || m.GetState().GetCurves() != nil || m.GetState().GetTeams() != nil || m.GetGameOver() != nil {
 		c.ch <- m
 		return c.setStateUnsafe(ccpb.ClientState_CLIENT_STATE_OK)
 	}
@@ -2,6 +2,7 @@
 package list
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/downflux/game/engine/fsm/fsm"
@@ -12,6 +13,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	apipb "github.com/downflux/game/api/api_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
 	ccpb "github.com/downflux/game/engine/server/client/api/constants_go_proto"
 )
 
@@ -25,12 +27,20 @@ type List struct {
 	// UUIDs.
 	idLen int
 
-	// mux guards the clients iterable.
+	// mux guards the clients, teams, and teamsDirty properties.
 	mux sync.RWMutex
 
 	// clients is an internal iterable of Client instances, hashed by the
 	// Client UUID.
 	clients map[id.ClientID]*client.Client
+
+	// teams is the team of each Client, hashed by the Client UUID.
+	// Clients which are not in this map are on team 0.
+	teams map[id.ClientID]int32
+
+	// teamsDirty indicates team membership has changed since the last
+	// call to PopTeams.
+	teamsDirty bool
 }
 
 // New returns a new List instance.
@@ -38,6 +48,7 @@ func New(idLen int) *List {
 	return &List{
 		idLen:   idLen,
 		clients: map[id.ClientID]*client.Client{},
+		teams:   map[id.ClientID]int32{},
 	}
 }
 
@@ -67,7 +78,7 @@ func (l *List) Broadcast(partialGenerator, fullGenerator func() *apipb.StreamDat
 	var full *apipb.StreamDataResponse
 
 	desyncedClients := l.filterUnsafe(ccpb.ClientState_CLIENT_STATE_DESYNCED)
-	if len(desyncedClients) == 0 && partial.GetState().GetCurves() == nil && partial.GetState().GetEntities() == nil {
+	if len(desyncedClients) == 0 && partial.GetState().GetCurves() == nil && partial.GetState().GetEntities() == nil && partial.GetState().GetTeams() == nil {
 		return nil
 	}
 	if len(desyncedClients) > 0 {
@@ -92,6 +103,45 @@ func (l *List) Broadcast(partialGenerator, fullGenerator func() *apipb.StreamDat
 	return eg.Wait()
 }
 
+// SendAll atomically sends the input message to all Client instances which
+// have an open channel, regardless of if the Client is synced. This is used to
+// broadcast messages which are not game state updates, e.g. the game results.
+func (l *List) SendAll(m *apipb.StreamDataResponse) error {
+	l.mux.RLock()
+	defer l.mux.RUnlock()
+
+	var eg errgroup.Group
+	for _, c := range l.clients {
+		c := c
+		s, err := c.State()
+		if err != nil {
+			return err
+		}
+
+		switch s {
+		case fsm.State(ccpb.ClientState_CLIENT_STATE_OK.String()), fsm.State(ccpb.ClientState_CLIENT_STATE_DESYNCED.String()):
+			eg.Go(func() error { return c.Send(m) })
+		}
+	}
+	return eg.Wait()
+}
+
+// Count returns the number of Client instances in each ClientState.
+func (l *List) Count() map[ccpb.ClientState]int {
+	l.mux.RLock()
+	defer l.mux.RUnlock()
+
+	counts := map[ccpb.ClientState]int{}
+	for _, c := range l.clients {
+		s, err := c.State()
+		if err != nil {
+			continue
+		}
+		counts[ccpb.ClientState(ccpb.ClientState_value[string(s)])]++
+	}
+	return counts
+}
+
 // Channel returns a read-only channel of game states. This is generally passed
 // to the gRPC server to be forwarded to the client.
 func (l *List) Channel(cid id.ClientID) (<-chan *apipb.StreamDataResponse, error) {
@@ -115,11 +165,106 @@ func (l *List) Add() (id.ClientID, error) {
 	for _, found := l.clients[cid]; found; cid = id.ClientID(id.RandomString(l.idLen)) {
 	}
 	l.clients[cid] = client.New(cid)
+	l.teamsDirty = true
 
 	return cid, nil
 
 }
 
+// AddWithID inserts a new Client instance with a fixed UUID into the List.
+// This is used to register clients which were assigned a UUID before the game
+// was created, e.g. in a pre-game lobby.
+func (l *List) AddWithID(cid id.ClientID) error {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.inUnsafe(cid) {
+		return status.Errorf(codes.AlreadyExists, "client %v already exists in client list", cid)
+	}
+	l.clients[cid] = client.New(cid)
+	l.teamsDirty = true
+
+	return nil
+}
+
+// SetTeam moves the specified Client to the input team.
+func (l *List) SetTeam(cid id.ClientID, team int32) error {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if !l.inUnsafe(cid) {
+		return notFound
+	}
+
+	if team == 0 {
+		delete(l.teams, cid)
+	} else {
+		l.teams[cid] = team
+	}
+	l.teamsDirty = true
+
+	return nil
+}
+
+// Team returns the team of the specified Client.
+func (l *List) Team(cid id.ClientID) (int32, error) {
+	l.mux.RLock()
+	defer l.mux.RUnlock()
+
+	if !l.inUnsafe(cid) {
+		return 0, notFound
+	}
+	return l.teams[cid], nil
+}
+
+// Allied checks if the two input Client instances are allies, i.e. are the
+// same Client or are on the same non-zero team. Unknown clients are not
+// allied with any other client.
+func (l *List) Allied(a, b id.ClientID) bool {
+	l.mux.RLock()
+	defer l.mux.RUnlock()
+
+	if !l.inUnsafe(a) || !l.inUnsafe(b) {
+		return false
+	}
+	return a == b || (l.teams[a] != 0 && l.teams[a] == l.teams[b])
+}
+
+// Teams returns the team membership of all Client instances, ordered by the
+// Client UUID.
+func (l *List) Teams() []*gdpb.TeamMembership {
+	l.mux.RLock()
+	defer l.mux.RUnlock()
+
+	return l.teamsUnsafe()
+}
+
+// PopTeams returns the team membership of all Client instances if the
+// membership has changed since the last call to PopTeams, and nil otherwise.
+func (l *List) PopTeams() []*gdpb.TeamMembership {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if !l.teamsDirty {
+		return nil
+	}
+	l.teamsDirty = false
+	return l.teamsUnsafe()
+}
+
+// teamsUnsafe implements the team membership export logic.
+func (l *List) teamsUnsafe() []*gdpb.TeamMembership {
+	var teams []*gdpb.TeamMembership
+	for cid := range l.clients {
+		teams = append(teams, &gdpb.TeamMembership{
+			ClientId: cid.Value(),
+			Team:     l.teams[cid],
+		})
+	}
+	sort.Slice(teams, func(i, j int) bool { return teams[i].GetClientId() < teams[j].GetClientId() })
+	return teams
+}
+
 // Start will indicate to the associated Client instance that a channel
 // instance should be created, and allows Client.Send() calls to occur.
 func (l *List) Start(cid id.ClientID) error {
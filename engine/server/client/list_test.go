@@ -0,0 +1,85 @@
+package list
+
+import (
+	"testing"
+
+	"github.com/downflux/game/engine/id/id"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	gdpb "github.com/downflux/game/api/data_go_proto"
+)
+
+const (
+	idLen = 8
+)
+
+func TestAllied(t *testing.T) {
+	l := New(idLen)
+
+	cids := []id.ClientID{"client-a", "client-b", "client-c"}
+	for _, cid := range cids {
+		if err := l.AddWithID(cid); err != nil {
+			t.Fatalf("AddWithID() = %v, want = nil", err)
+		}
+	}
+	if err := l.SetTeam(cids[0], 1); err != nil {
+		t.Fatalf("SetTeam() = %v, want = nil", err)
+	}
+	if err := l.SetTeam(cids[1], 1); err != nil {
+		t.Fatalf("SetTeam() = %v, want = nil", err)
+	}
+	if err := l.SetTeam("missing", 1); status.Code(err) != codes.NotFound {
+		t.Errorf("SetTeam() = %v, want = %v", err, codes.NotFound)
+	}
+
+	testConfigs := []struct {
+		name string
+		a    id.ClientID
+		b    id.ClientID
+		want bool
+	}{
+		{name: "SameTeam", a: cids[0], b: cids[1], want: true},
+		{name: "DifferentTeam", a: cids[0], b: cids[2], want: false},
+		{name: "Self", a: cids[2], b: cids[2], want: true},
+		{name: "Unknown", a: cids[0], b: "missing", want: false},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if got := l.Allied(c.a, c.b); got != c.want {
+				t.Errorf("Allied() = %v, want = %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestPopTeams(t *testing.T) {
+	l := New(idLen)
+
+	cid := id.ClientID("client-id")
+	if err := l.AddWithID(cid); err != nil {
+		t.Fatalf("AddWithID() = %v, want = nil", err)
+	}
+
+	want := []*gdpb.TeamMembership{{ClientId: cid.Value(), Team: 0}}
+	if diff := cmp.Diff(want, l.PopTeams(), protocmp.Transform()); diff != "" {
+		t.Errorf("PopTeams() mismatch (-want +got):\n%v", diff)
+	}
+	if got := l.PopTeams(); got != nil {
+		t.Errorf("PopTeams() = %v, want = nil", got)
+	}
+
+	if err := l.SetTeam(cid, 2); err != nil {
+		t.Fatalf("SetTeam() = %v, want = nil", err)
+	}
+	want = []*gdpb.TeamMembership{{ClientId: cid.Value(), Team: 2}}
+	if diff := cmp.Diff(want, l.PopTeams(), protocmp.Transform()); diff != "" {
+		t.Errorf("PopTeams() mismatch (-want +got):\n%v", diff)
+	}
+	if diff := cmp.Diff(want, l.Teams(), protocmp.Transform()); diff != "" {
+		t.Errorf("Teams() mismatch (-want +got):\n%v", diff)
+	}
+}
@@ -2,7 +2,11 @@
 package executor
 
 import (
+	"context"
 	"log"
+	"math"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/downflux/game/engine/fsm/action"
@@ -10,11 +14,15 @@ import (
 	"github.com/downflux/game/engine/gamestate/dirty"
 	"github.com/downflux/game/engine/gamestate/gamestate"
 	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/metrics/metrics"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	apipb "github.com/downflux/game/api/api_go_proto"
 	gdpb "github.com/downflux/game/api/data_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+	ccpb "github.com/downflux/game/engine/server/client/api/constants_go_proto"
 	clientlist "github.com/downflux/game/engine/server/client/list"
 	visitorlist "github.com/downflux/game/engine/visitor/list"
 )
@@ -30,6 +38,109 @@ var (
 		codes.Unimplemented, "function not implemented")
 )
 
+// Recorder consumes the list of externally scheduled actions which are applied
+// at a specific game tick. This is used to record games for deterministic
+// playback.
+type Recorder interface {
+	Record(tick id.Tick, actions []action.Action) error
+
+	// Defer consumes the list of actions of the input FSM type which
+	// were deferred to the next tick at the input tick, as the Visitor
+	// pass ran out of time. Playback must skip the same actions.
+	Defer(tick id.Tick, fsmType fcpb.FSMType, aids []id.ActionID) error
+}
+
+var (
+	// broadcastBuckets are the histogram buckets used to track the size
+	// of broadcast game state updates, in bytes.
+	broadcastBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576}
+)
+
+// tickWindow represents a range of ticks relative to the current tick.
+type tickWindow struct {
+	past   id.Tick
+	future id.Tick
+}
+
+// Checkpointer exports and restores the actions tracked by the Executor. This
+// allows the Executor to roll back the game state to an earlier tick.
+type Checkpointer interface {
+	// Checkpoint exports the state of the input actions.
+	Checkpoint(actions []action.Action) (interface{}, error)
+
+	// Restore reconstructs a list of actions from a checkpoint. Actions
+	// which reference entities which no longer exist must be skipped.
+	Restore(checkpoint interface{}) ([]action.Action, error)
+}
+
+// Referee decides when the game has ended.
+type Referee interface {
+	// Evaluate returns the final results of the game if the game has
+	// ended as of the input tick, and nil otherwise.
+	Evaluate(tick id.Tick) (*gdpb.GameOver, error)
+}
+
+// Stateful is implemented by Referee instances which track state across ticks,
+// e.g. which clients have fielded units so far. The state is checkpointed at
+// the end of each tick, and restored when the game is rolled back.
+type Stateful interface {
+	// Save exports the current state.
+	Save() interface{}
+
+	// Load replaces the current state with an exported state.
+	Load(state interface{}) error
+}
+
+// Metrics tracks the performance of the core game loop. A single Metrics
+// instance may be shared by several Executors, e.g. for each game hosted by a
+// server; the series of each Executor are labeled with its game ID.
+type Metrics struct {
+	tickDuration     *metrics.Histogram
+	tickOverruns     *metrics.Counter
+	visitorDuration  *metrics.Histogram
+	scheduledActions *metrics.Gauge
+	clients          *metrics.Gauge
+	broadcastSize    *metrics.Histogram
+}
+
+// NewMetrics registers the Executor performance metrics with the input
+// Registry.
+func NewMetrics(r *metrics.Registry) *Metrics {
+	return &Metrics{
+		tickDuration: r.NewHistogram(
+			"executor_tick_duration_seconds",
+			"Wall-clock time spent executing a single game tick.",
+			metrics.DefaultBuckets,
+			"game_id"),
+		tickOverruns: r.NewCounter(
+			"executor_tick_overruns_total",
+			"Number of game ticks which exceeded the tick duration.",
+			"game_id"),
+		visitorDuration: r.NewHistogram(
+			"executor_visitor_duration_seconds",
+			"Wall-clock time spent executing a single Visitor pass.",
+			metrics.DefaultBuckets,
+			"game_id",
+			"fsm_type"),
+		scheduledActions: r.NewGauge(
+			"executor_scheduled_actions",
+			"Number of actions scheduled for the current game tick.",
+			"game_id",
+			"fsm_type"),
+		clients: r.NewGauge(
+			"executor_clients",
+			"Number of connected clients.",
+			"game_id",
+			"client_state"),
+		broadcastSize: r.NewHistogram(
+			"executor_broadcast_size_bytes",
+			"Size of the game state update broadcast to clients.",
+			broadcastBuckets,
+			"game_id",
+			"type"),
+	}
+}
+
 // Executor encapsulates logic for executing the core game loop.
 type Executor struct {
 	// visitors is a list of all Visitor instances used by the Executor.
@@ -50,8 +161,96 @@ type Executor struct {
 	// clients is an append-only set of connected players / AI.
 	clients *clientlist.List
 
+	// tickMux guards the game state and schedule from being read
+	// mid-tick via Pause.
+	tickMux sync.Mutex
+
 	schedule      *schedule.Schedule
 	scheduleCache *schedule.Schedule
+
+	// recorder is an optional sink of all actions passed into Schedule,
+	// along with the tick at which the actions are applied.
+	recorder Recorder
+
+	// fastForward indicates Run should execute ticks as fast as possible
+	// instead of waiting for the tick duration to elapse. This is used
+	// for headless simulations.
+	fastForward bool
+
+	// endTick is the last tick executed by Run. Run will continue until
+	// Stop is called if this is unset.
+	endTick id.Tick
+
+	// runTick is the tick at which Run was called. This may be non-zero
+	// if the game was restored from a snapshot.
+	runTick id.Tick
+
+	// runTicks and runDuration track the number of ticks executed and the
+	// wall-clock time elapsed during the last call to Run.
+	runTicks    id.Tick
+	runDuration time.Duration
+
+	// metrics is an optional set of instruments tracking the performance
+	// of the core game loop.
+	metrics *Metrics
+
+	// gameID labels all metrics series reported by the Executor.
+	gameID id.GameID
+
+	// rollbackMux guards the scheduleCache from being extended while the
+	// game is being rolled back.
+	rollbackMux sync.RWMutex
+
+	// checkpointer is an optional hook which allows the game to be rolled
+	// back via Rollback.
+	checkpointer Checkpointer
+
+	// rollbackWindow is the maximum number of ticks by which the game may
+	// be rolled back.
+	rollbackWindow id.Tick
+
+	// checkpoints is a rolling history of the exported state of all
+	// tracked actions at the end of each tick, indexed by tick.
+	checkpoints map[id.Tick]interface{}
+
+	// history is a rolling history of the exported externally scheduled
+	// actions, indexed by the tick at which the actions were applied.
+	history map[id.Tick]interface{}
+
+	// skipped is a rolling history of the actions which were deferred to
+	// the next tick by a Visitor pass which ran out of time, indexed by
+	// the tick and the FSM type of the pass. Re-simulated ticks skip the
+	// same actions.
+	skipped map[id.Tick]map[fcpb.FSMType][]id.ActionID
+
+	// verdicts is a rolling history of the exported state of the Referee
+	// after the Referee was consulted at the end of each tick, indexed by
+	// tick. This is only tracked if the Referee implements Stateful.
+	verdicts map[id.Tick]interface{}
+
+	// commandWindow is the optional range of ticks around the current
+	// tick for which client commands are accepted.
+	commandWindow *tickWindow
+
+	// deferredMux guards the deferred property.
+	deferredMux sync.Mutex
+
+	// deferred is a list of actions which are scheduled for a future tick,
+	// indexed by the tick at which the actions were requested. These
+	// actions are applied in the tick immediately after the requested
+	// tick.
+	deferred map[id.Tick][]action.Action
+
+	// referee is an optional hook which ends the game once a victory
+	// condition has been met.
+	referee Referee
+
+	// gameOverMux guards the gameOver property.
+	gameOverMux sync.Mutex
+
+	// gameOver is the final results of the game, and is set once the
+	// referee has ended the game.
+	gameOver *gdpb.GameOver
 }
 
 func New(
@@ -67,7 +266,102 @@ func New(
 		clients:       clientlist.New(idLen),
 		schedule:      fsmSchedule,
 		scheduleCache: fsmSchedule.Pop(),
+		deferred:      map[id.Tick][]action.Action{},
+	}
+}
+
+// SetRecorder attaches a Recorder to the Executor. All actions passed into
+// Schedule will be forwarded to the Recorder at the beginning of the tick in
+// which they are applied, along with the actions which were deferred due to
+// the tick deadline. This must be called before Run.
+func (e *Executor) SetRecorder(r Recorder) { e.recorder = r }
+
+// SetMetrics reports the Executor performance to the input set of metrics,
+// labeled with the input game ID. This must be called before Run.
+func (e *Executor) SetMetrics(m *Metrics, gid id.GameID) {
+	e.metrics = m
+	e.gameID = gid
+}
+
+// SetCheckpointer enables rolling back the game by up to the input number of
+// ticks via Rollback. This must be called before Run.
+//
+// Rollback is disabled while a Recorder is attached, as a replay cannot
+// reproduce a game which has been rolled back.
+func (e *Executor) SetCheckpointer(c Checkpointer, window id.Tick) {
+	e.checkpointer = c
+	e.rollbackWindow = window
+	e.checkpoints = map[id.Tick]interface{}{}
+	e.history = map[id.Tick]interface{}{}
+	e.skipped = map[id.Tick]map[fcpb.FSMType][]id.ActionID{}
+	e.verdicts = map[id.Tick]interface{}{}
+}
+
+// SetCommandWindow restricts the ticks for which client commands are accepted
+// to the input number of ticks before and after the current tick. Commands
+// are accepted for any tick if this is unset. This must be called before Run.
+func (e *Executor) SetCommandWindow(past id.Tick, future id.Tick) {
+	e.commandWindow = &tickWindow{
+		past:   past,
+		future: future,
+	}
+}
+
+// ValidateTick checks that the input client-supplied tick falls within the
+// command window.
+func (e *Executor) ValidateTick(t id.Tick) error {
+	if e.commandWindow == nil {
+		return nil
+	}
+
+	now := e.gamestate.Status().Tick()
+	if t < now-e.commandWindow.past || t > now+e.commandWindow.future {
+		return status.Errorf(
+			codes.OutOfRange,
+			"tick %v is outside of the accepted command window [%v, %v]",
+			t,
+			now-e.commandWindow.past,
+			now+e.commandWindow.future)
+	}
+	return nil
+}
+
+// SetReferee attaches a Referee to the Executor. The Referee is consulted at
+// the end of each tick, and once the Referee reports the game has ended, the
+// results are broadcast to all clients and the Executor is stopped. This must
+// be called before Run.
+//
+// The Referee is also consulted at the end of each tick re-simulated by
+// Rollback. If the Referee implements Stateful, its state is rewound along
+// with the game.
+func (e *Executor) SetReferee(r Referee) { e.referee = r }
+
+// GameOver returns the final results of the game, or nil if the game has not
+// been ended by the Referee.
+func (e *Executor) GameOver() *gdpb.GameOver {
+	e.gameOverMux.Lock()
+	defer e.gameOverMux.Unlock()
+
+	return e.gameOver
+}
+
+// SetFastForward toggles headless mode, where Run will execute ticks as fast as
+// possible instead of pacing each tick to the tick duration. This must be
+// called before Run.
+func (e *Executor) SetFastForward(fastForward bool) { e.fastForward = fastForward }
+
+// SetEndTick instructs Run to stop the Executor after executing the input
+// tick. This must be called before Run.
+func (e *Executor) SetEndTick(t id.Tick) { e.endTick = t }
+
+// TicksPerSecond returns the average number of ticks executed per second of
+// wall-clock time during the last call to Run. This must be called after Run
+// returns.
+func (e *Executor) TicksPerSecond() float64 {
+	if e.runDuration <= 0 {
+		return 0
 	}
+	return e.runTicks.Value() / e.runDuration.Seconds()
 }
 
 // Status returns the current Executor status.
@@ -80,6 +374,28 @@ func (e *Executor) ClientExists(cid id.ClientID) bool { return e.clients.In(cid)
 // AddClient creates a new Client to be tracked by the Executor.
 func (e *Executor) AddClient() (id.ClientID, error) { return e.clients.Add() }
 
+// AddClientWithID creates a new Client with a fixed UUID to be tracked by the
+// Executor.
+func (e *Executor) AddClientWithID(cid id.ClientID) error { return e.clients.AddWithID(cid) }
+
+// SetClientTeam moves the specified client to the input team. Clients on the
+// same non-zero team are allies. Teams are fixed once the game has started.
+func (e *Executor) SetClientTeam(cid id.ClientID, team int32) error {
+	if e.gamestate.Status().IsStarted() {
+		return status.Errorf(codes.FailedPrecondition, "cannot change the team of client %v after the game has started", cid)
+	}
+	return e.clients.SetTeam(cid, team)
+}
+
+// ClientTeam returns the team of the specified client.
+func (e *Executor) ClientTeam(cid id.ClientID) (int32, error) { return e.clients.Team(cid) }
+
+// Allied checks if the two specified clients are allies.
+func (e *Executor) Allied(a, b id.ClientID) bool { return e.clients.Allied(a, b) }
+
+// Teams returns the team membership of all clients tracked by the Executor.
+func (e *Executor) Teams() []*gdpb.TeamMembership { return e.clients.Teams() }
+
 // StartClientStream instructs the Executor to mark the associated client
 // ready for game state updates.
 func (e *Executor) StartClientStream(cid id.ClientID) error { return e.clients.Start(cid) }
@@ -96,9 +412,18 @@ func (e *Executor) ClientChannel(cid id.ClientID) (<-chan *apipb.StreamDataRespo
 }
 
 // broadcast will send the current game state delta or full game state to
-// all connected clients. This is a blocking call.
-func (e *Executor) broadcast() error {
-	partial := e.gamestate.Export(e.gamestate.Status().Tick()-100, e.dirty.Pop())
+// all connected clients. The delta includes all curve data of the modified
+// curves after the input tick. This is a blocking call.
+func (e *Executor) broadcast(since id.Tick) error {
+	partial := e.gamestate.Export(since, e.dirty.Pop())
+	partial.Teams = e.clients.PopTeams()
+
+	if e.metrics != nil {
+		counts := e.clients.Count()
+		for s := range ccpb.ClientState_name {
+			e.metrics.clients.Set(float64(counts[ccpb.ClientState(s)]), e.gameID.Value(), ccpb.ClientState(s).String())
+		}
+	}
 
 	return e.clients.Broadcast(
 		// Return the game state update that will need to be broadcast
@@ -106,23 +431,34 @@ func (e *Executor) broadcast() error {
 		func() *apipb.StreamDataResponse {
 			// TODO(minkezhang): Decide if it's okay that the reported tick may not
 			// coincide with the ticks of the curve and entities.
-			return &apipb.StreamDataResponse{
+			return e.observeBroadcast("partial", &apipb.StreamDataResponse{
 				Tick:  e.gamestate.Status().Tick().Value(),
 				State: partial,
-			}
+			})
 		},
 		// Return a list of all Curve and Entity protos as of the
 		// current tick. This is used to broadcast the full game state
 		// to new or reconnecting clients.
 		func() *apipb.StreamDataResponse {
-			return &apipb.StreamDataResponse{
+			full := e.gamestate.Export(e.gamestate.Status().Tick(), e.gamestate.NoFilter())
+			full.Teams = e.clients.Teams()
+			return e.observeBroadcast("full", &apipb.StreamDataResponse{
 				Tick:  e.gamestate.Status().Tick().Value(),
-				State: e.gamestate.Export(e.gamestate.Status().Tick(), e.gamestate.NoFilter()),
-			}
+				State: full,
+			})
 		},
 	)
 }
 
+// observeBroadcast records the serialized size of the input game state
+// update.
+func (e *Executor) observeBroadcast(t string, pb *apipb.StreamDataResponse) *apipb.StreamDataResponse {
+	if e.metrics != nil {
+		e.metrics.broadcastSize.Observe(float64(proto.Size(pb)), e.gameID.Value(), t)
+	}
+	return pb
+}
+
 // Stop will teardown the Executor and close all client channels. This is
 // called at the end of the game.
 func (e *Executor) Stop() error {
@@ -139,7 +475,17 @@ func (e *Executor) Run() error {
 	if err := e.gamestate.Status().SetIsStarted(); err != nil {
 		return err
 	}
+
+	e.runTick = e.gamestate.Status().Tick()
+	defer func() {
+		e.runTicks = e.gamestate.Status().Tick() - e.runTick
+		e.runDuration = time.Since(e.gamestate.Status().StartTime())
+	}()
+
 	for !e.gamestate.Status().IsStopped() {
+		if e.endTick > 0 && e.gamestate.Status().Tick() >= e.endTick {
+			return e.Stop()
+		}
 		if err := e.doTick(); err != nil {
 			// TODO(minkezhang): Only return if error is fatal.
 			return err
@@ -148,41 +494,502 @@ func (e *Executor) Run() error {
 	return nil
 }
 
-// doTick executes a single iteration of the core game loop.
-func (e *Executor) doTick() error {
-	t := time.Now()
+// Step executes a single iteration of the core game loop without waiting for
+// the tick duration to elapse.
+//
+// Unlike Run, Step does not enforce a deadline on the Visitor passes, which
+// guarantees the game state is deterministic.
+func (e *Executor) Step() error { return e.step(time.Time{}, nil) }
+
+// StepDeferred executes a single iteration of the core game loop as in Step,
+// but defers the input actions to the next tick instead of visiting them,
+// indexed by FSM type. This is used to play back recorded games, where the
+// input actions are the actions which were deferred in the recorded tick.
+func (e *Executor) StepDeferred(deferred map[fcpb.FSMType][]id.ActionID) error {
+	return e.step(time.Time{}, deferred)
+}
+
+// Pause blocks the core game loop from advancing while the input function
+// executes. This allows callers to read a consistent view of the game state
+// between ticks, e.g. when saving a snapshot.
+func (e *Executor) Pause(f func() error) error {
+	e.tickMux.Lock()
+	defer e.tickMux.Unlock()
+
+	return f()
+}
+
+// Pending returns all actions tracked by the Executor, including actions which
+// have been scheduled but not yet applied. The returned list may include
+// actions which have already finished or been canceled.
+//
+// Pending must be called within Pause.
+func (e *Executor) Pending() []action.Action {
+	actions := append(e.schedule.Iter(), e.scheduleCache.Iter()...)
+
+	e.deferredMux.Lock()
+	defer e.deferredMux.Unlock()
+
+	for _, deferred := range e.deferred {
+		actions = append(actions, deferred...)
+	}
+	return actions
+}
+
+// visitorContext returns a context for a single Visitor pass. The time left
+// until the tick deadline is split evenly between the n remaining Visitor
+// passes -- time unused by a pass rolls over to subsequent passes.
+//
+// A zero deadline indicates the pass should not time out.
+func visitorContext(deadline time.Time, n int) (context.Context, context.CancelFunc) {
+	if deadline.IsZero() {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(
+		context.Background(),
+		time.Now().Add(time.Until(deadline)/time.Duration(n)))
+}
+
+// step advances the game state by a single tick and broadcasts the changes to
+// all clients.
+func (e *Executor) step(deadline time.Time, skip map[fcpb.FSMType][]id.ActionID) error {
+	e.tickMux.Lock()
+	defer e.tickMux.Unlock()
+
+	if err := e.simulate(deadline, skip); err != nil {
+		return err
+	}
+	if err := e.broadcast(e.gamestate.Status().Tick() - 100); err != nil {
+		return err
+	}
+	return e.judge()
+}
+
+// judge consults the Referee, and ends the game if a victory condition has
+// been met. The final results are sent to all clients before the client
+// channels are closed.
+//
+// judge must be called with the tickMux held.
+func (e *Executor) judge() error {
+	if e.referee == nil || e.GameOver() != nil {
+		return nil
+	}
+
+	tick := e.gamestate.Status().Tick()
+	pb, err := e.referee.Evaluate(tick)
+	if s, ok := e.referee.(Stateful); ok && e.checkpointer != nil {
+		e.verdicts[tick] = s.Save()
+	}
+	if err != nil || pb == nil {
+		return err
+	}
+
+	e.gameOverMux.Lock()
+	e.gameOver = pb
+	e.gameOverMux.Unlock()
+
+	if err := e.clients.SendAll(&apipb.StreamDataResponse{
+		Tick:     tick.Value(),
+		GameOver: pb,
+	}); err != nil {
+		return err
+	}
+	return e.Stop()
+}
+
+// simulate advances the game state by a single tick. Actions which are not
+// processed by a Visitor before the deadline are deferred to the next tick,
+// as are the input actions, indexed by FSM type. The deferred actions are
+// tracked so that the tick may be reproduced on rollback or replay.
+//
+// simulate must be called with the tickMux held.
+func (e *Executor) simulate(deadline time.Time, skip map[fcpb.FSMType][]id.ActionID) error {
 	e.gamestate.Status().IncrementTick()
+	tick := e.gamestate.Status().Tick()
+
+	if err := e.undefer(tick); err != nil {
+		return err
+	}
+
+	cache := e.scheduleCache.Pop()
+	if e.recorder != nil {
+		if err := e.recorder.Record(tick, cache.Iter()); err != nil {
+			return err
+		}
+	}
+	if e.checkpointer != nil {
+		c, err := e.checkpointer.Checkpoint(cache.Iter())
+		if err != nil {
+			return err
+		}
+		e.history[tick] = c
+	}
 
 	e.schedule.Clear()
-	if err := e.schedule.Merge(e.scheduleCache.Pop()); err != nil {
+	if err := e.schedule.Merge(cache); err != nil {
 		return err
 	}
 
-	for _, v := range e.visitors.Iter() {
-		if err := e.schedule.Get(v.Type()).Accept(v); err != nil {
+	skipped := map[fcpb.FSMType][]id.ActionID{}
+
+	visitors := e.visitors.Iter()
+	for i, v := range visitors {
+		l := e.schedule.Get(v.Type())
+		if e.metrics != nil {
+			e.metrics.scheduledActions.Set(float64(len(l.Iter())), e.gameID.Value(), v.Type().String())
+		}
+
+		ctx, cancel := visitorContext(deadline, len(visitors)-i)
+		start := time.Now()
+		aids, err := l.Accept(ctx, v, skip[v.Type()])
+		cancel()
+
+		if e.metrics != nil {
+			e.metrics.visitorDuration.Observe(time.Since(start).Seconds(), e.gameID.Value(), v.Type().String())
+		}
+
+		if err != nil {
+			return err
+		}
+		if len(aids) == 0 {
+			continue
+		}
+
+		skipped[v.Type()] = aids
+		if e.recorder != nil {
+			if err := e.recorder.Defer(tick, v.Type(), aids); err != nil {
+				return err
+			}
+		}
+	}
+
+	if e.checkpointer != nil {
+		e.skipped[tick] = skipped
+	}
+	return e.checkpoint()
+}
+
+// undefer moves all deferred actions which were requested before the input
+// tick into the schedule cache, in the order of the requested tick.
+func (e *Executor) undefer(tick id.Tick) error {
+	e.deferredMux.Lock()
+	defer e.deferredMux.Unlock()
+
+	var ticks []id.Tick
+	for t := range e.deferred {
+		if t < tick {
+			ticks = append(ticks, t)
+		}
+	}
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i] < ticks[j] })
+
+	for _, t := range ticks {
+		if err := e.scheduleCache.Extend(e.deferred[t]); err != nil {
+			return err
+		}
+		delete(e.deferred, t)
+	}
+	return nil
+}
+
+// checkpointDeferred exports the list of deferred actions.
+func (e *Executor) checkpointDeferred() (map[id.Tick]interface{}, error) {
+	e.deferredMux.Lock()
+	defer e.deferredMux.Unlock()
+
+	deferred := map[id.Tick]interface{}{}
+	for t, actions := range e.deferred {
+		c, err := e.checkpointer.Checkpoint(actions)
+		if err != nil {
+			return nil, err
+		}
+		deferred[t] = c
+	}
+	return deferred, nil
+}
+
+// restoreDeferred replaces the list of deferred actions with the input
+// exported actions.
+func (e *Executor) restoreDeferred(deferred map[id.Tick]interface{}) error {
+	e.deferredMux.Lock()
+	defer e.deferredMux.Unlock()
+
+	e.deferred = map[id.Tick][]action.Action{}
+	for t, c := range deferred {
+		actions, err := e.checkpointer.Restore(c)
+		if err != nil {
 			return err
 		}
+		e.deferred[t] = actions
+	}
+	return nil
+}
+
+// checkpoint exports the state of all tracked actions at the end of the
+// current tick, and discards checkpoints which fall outside of the rollback
+// window.
+func (e *Executor) checkpoint() error {
+	if e.checkpointer == nil {
+		return nil
+	}
+
+	tick := e.gamestate.Status().Tick()
+	c, err := e.checkpointer.Checkpoint(e.schedule.Iter())
+	if err != nil {
+		return err
+	}
+	e.checkpoints[tick] = c
+
+	for t := range e.checkpoints {
+		if t < tick-e.rollbackWindow {
+			delete(e.checkpoints, t)
+		}
+	}
+	for t := range e.history {
+		if t <= tick-e.rollbackWindow {
+			delete(e.history, t)
+		}
+	}
+	for t := range e.skipped {
+		if t <= tick-e.rollbackWindow {
+			delete(e.skipped, t)
+		}
+	}
+	for t := range e.verdicts {
+		if t < tick-e.rollbackWindow {
+			delete(e.verdicts, t)
+		}
+	}
+	return nil
+}
+
+// Rollback rewinds the game state to the end of the input tick, schedules the
+// actions generated by the input function, and re-simulates the game up to the
+// current tick. This allows commands which arrive late to be applied at the
+// tick at which they were issued. The input function is called after the game
+// has been rewound, and must not call back into the Executor.
+//
+// The game is rewound by truncating all curves after the input tick and
+// removing all entities created after the input tick. The tracked actions
+// are restored from the checkpoint taken at the input tick, and externally
+// scheduled actions are re-applied at their original ticks.
+//
+// If the input tick falls outside of the rollback window, the game is instead
+// rewound to the earliest tick in the window. If rollback is disabled or the
+// input tick is not in the past, the actions are scheduled for the next tick.
+//
+// Re-simulated ticks do not enforce a deadline, but defer the same actions as
+// the original ticks did, and the corrected game state is only broadcast after
+// the game has caught up to the current tick. The
+// Referee is consulted after each re-simulated tick, and may end the game. If
+// the input function returns an error, the game is re-simulated without the
+// late actions and the error is returned.
+func (e *Executor) Rollback(t id.Tick, f func() ([]action.Action, error)) error {
+	e.rollbackMux.Lock()
+	defer e.rollbackMux.Unlock()
+
+	e.tickMux.Lock()
+	defer e.tickMux.Unlock()
+
+	t = id.Tick(math.Floor(t.Value()))
+
+	now := e.gamestate.Status().Tick()
+	if e.checkpointer != nil && e.recorder == nil {
+		for ; t < now; t++ {
+			if _, found := e.checkpoints[t]; found {
+				break
+			}
+		}
+	}
+	if e.checkpointer == nil || e.recorder != nil || t >= now {
+		actions, err := f()
+		if err != nil {
+			return err
+		}
+		return e.scheduleCache.Extend(actions)
 	}
 
-	if err := e.broadcast(); err != nil {
+	// Actions which were scheduled for the next tick may reference
+	// entities which are re-created during re-simulation, and need to be
+	// reconstructed after the game has caught up.
+	pending, err := e.checkpointer.Checkpoint(e.scheduleCache.Pop().Iter())
+	if err != nil {
+		return err
+	}
+	deferred, err := e.checkpointDeferred()
+	if err != nil {
 		return err
 	}
 
-	// TODO(minkezhang): Add metrics collection here for tick
-	// distribution.
+	entities := e.gamestate.Entities()
+	for _, en := range entities.Iter() {
+		if en.Start() > t {
+			if err := entities.Remove(en.ID()); err != nil {
+				return err
+			}
+			continue
+		}
+		// Revive entities which were destroyed after the rollback
+		// tick.
+		if en.End() > t {
+			en.Delete(0)
+			if err := e.dirty.AddEntity(dirty.Entity{ID: en.ID()}); err != nil {
+				return err
+			}
+		}
+		for _, p := range en.Curves().Properties() {
+			en.Curves().Curve(p).Truncate(t)
+			if err := e.dirty.AddCurve(dirty.Curve{
+				EntityID: en.ID(),
+				Property: p,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	e.gamestate.Status().SetTick(t)
+	if s, ok := e.referee.(Stateful); ok {
+		if v, found := e.verdicts[t]; found {
+			if err := s.Load(v); err != nil {
+				return err
+			}
+		}
+	}
+
+	actions, err := e.checkpointer.Restore(e.checkpoints[t])
+	if err != nil {
+		return err
+	}
+	e.schedule.Pop()
+	if err := e.schedule.Extend(actions); err != nil {
+		return err
+	}
+
+	late, lateErr := f()
+	for k := t + 1; k <= now; k++ {
+		if c, found := e.history[k]; found {
+			actions, err := e.checkpointer.Restore(c)
+			if err != nil {
+				return err
+			}
+			if err := e.scheduleCache.Extend(actions); err != nil {
+				return err
+			}
+		}
+		// Late actions are scheduled after the actions which were
+		// originally applied at the same tick, and take precedence
+		// over them.
+		if k == t+1 && lateErr == nil {
+			if err := e.scheduleCache.Extend(late); err != nil {
+				return err
+			}
+		}
+		if err := e.simulate(time.Time{}, e.skipped[k]); err != nil {
+			return err
+		}
+		if err := e.judge(); err != nil {
+			return err
+		}
+	}
+
+	actions, err = e.checkpointer.Restore(pending)
+	if err != nil {
+		return err
+	}
+	if err := e.scheduleCache.Extend(actions); err != nil {
+		return err
+	}
+	if err := e.restoreDeferred(deferred); err != nil {
+		return err
+	}
+
+	// TODO(minkezhang): Notify clients of entities which no longer
+	// exist after the rollback.
+	if err := e.broadcast(t); err != nil {
+		return err
+	}
+	return lateErr
+}
+
+// observeStep executes a single tick and records the execution time, excluding
+// any time spent waiting for the next tick.
+func (e *Executor) observeStep(deadline time.Time) error {
+	start := time.Now()
+	err := e.step(deadline, nil)
+	if e.metrics != nil {
+		e.metrics.tickDuration.Observe(time.Since(start).Seconds(), e.gameID.Value())
+	}
+	return err
+}
+
+// doTick executes a single iteration of the core game loop.
+//
+// In fast-forward mode, the tick is executed without a deadline, as in Step.
+func (e *Executor) doTick() error {
+	if e.fastForward {
+		return e.observeStep(time.Time{})
+	}
+
+	// deadline is the wall-clock time at which the next tick is scheduled
+	// to end.
 	tickDuration := e.gamestate.Status().TickDuration()
-	u := e.gamestate.Status().StartTime().Add(
-		time.Duration(e.gamestate.Status().Tick()) * tickDuration).Sub(t)
-	if u < tickDuration {
+	deadline := e.gamestate.Status().StartTime().Add(
+		time.Duration(e.gamestate.Status().Tick()+1-e.runTick) * tickDuration)
+
+	if err := e.observeStep(deadline); err != nil {
+		return err
+	}
+
+	if u := time.Until(deadline); u > 0 {
 		time.Sleep(u)
 	} else {
+		if e.metrics != nil {
+			e.metrics.tickOverruns.Add(1, e.gameID.Value())
+		}
 		log.Printf(
-			"[%.f] took too long: execution time %v > %v",
-			e.gamestate.Status().Tick(), u, tickDuration)
+			"[%.f] took too long: execution time exceeded %v by %v",
+			e.gamestate.Status().Tick(), tickDuration, -u)
 	}
 	return nil
 }
 
+// ScheduleAt applies the actions generated by the input function as if the
+// actions were scheduled via Schedule at the input tick, i.e. the actions are
+// applied in the tick immediately after the input tick. The input tick is
+// typically supplied by the client, and must fall within the command window.
+//
+// Actions requested for a past tick are applied via Rollback. Actions
+// requested for a future tick are generated immediately, but are deferred
+// until the requested tick.
+func (e *Executor) ScheduleAt(t id.Tick, f func() ([]action.Action, error)) error {
+	t = id.Tick(math.Floor(t.Value()))
+	if err := e.ValidateTick(t); err != nil {
+		return err
+	}
+
+	if t <= e.gamestate.Status().Tick() {
+		return e.Rollback(t, f)
+	}
+
+	e.rollbackMux.RLock()
+	defer e.rollbackMux.RUnlock()
+
+	actions, err := f()
+	if err != nil {
+		return err
+	}
+
+	e.deferredMux.Lock()
+	defer e.deferredMux.Unlock()
+
+	e.deferred[t] = append(e.deferred[t], actions...)
+	return nil
+}
+
 func (e *Executor) Schedule(actions []action.Action) error {
+	e.rollbackMux.RLock()
+	defer e.rollbackMux.RUnlock()
+
 	return e.scheduleCache.Extend(actions)
 }
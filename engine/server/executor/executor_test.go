@@ -12,9 +12,15 @@ import (
 	"github.com/downflux/game/engine/gamestate/dirty"
 	"github.com/downflux/game/engine/gamestate/gamestate"
 	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/metrics/metrics"
 	"github.com/downflux/game/engine/visitor/mock/simple"
 	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
 	entitylist "github.com/downflux/game/engine/entity/list"
 	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
 	simpleaction "github.com/downflux/game/engine/fsm/mock/simple"
@@ -23,9 +29,78 @@ import (
 )
 
 var (
+	_ Stateful = &counter{}
+
 	tickDuration = 100 * time.Millisecond
 )
 
+// recorder is a mock Recorder which caches the scheduled and deferred actions.
+type recorder struct {
+	actions  map[id.Tick][]action.Action
+	deferred map[id.Tick][]id.ActionID
+}
+
+func (r *recorder) Record(tick id.Tick, actions []action.Action) error {
+	if r.actions == nil {
+		r.actions = map[id.Tick][]action.Action{}
+	}
+	r.actions[tick] = append(r.actions[tick], actions...)
+	return nil
+}
+
+func (r *recorder) Defer(tick id.Tick, fsmType fcpb.FSMType, aids []id.ActionID) error {
+	if r.deferred == nil {
+		r.deferred = map[id.Tick][]id.ActionID{}
+	}
+	r.deferred[tick] = append(r.deferred[tick], aids...)
+	return nil
+}
+
+// checkpointer is a mock Checkpointer which passes through the list of
+// actions.
+type checkpointer struct{}
+
+func (c checkpointer) Checkpoint(actions []action.Action) (interface{}, error) {
+	return append([]action.Action{}, actions...), nil
+}
+
+func (c checkpointer) Restore(checkpoint interface{}) ([]action.Action, error) {
+	return checkpoint.([]action.Action), nil
+}
+
+// referee is a mock Referee which ends the game at a fixed tick.
+type referee struct {
+	end id.Tick
+}
+
+func (r referee) Evaluate(tick id.Tick) (*gdpb.GameOver, error) {
+	if tick < r.end {
+		return nil, nil
+	}
+	return &gdpb.GameOver{
+		Tick:   tick.Value(),
+		Reason: gcpb.GameOverReason_GAME_OVER_REASON_TIME_LIMIT,
+	}, nil
+}
+
+// counter is a mock Stateful Referee which counts the number of ticks it has
+// judged, and never ends the game.
+type counter struct {
+	count int
+}
+
+func (r *counter) Evaluate(tick id.Tick) (*gdpb.GameOver, error) {
+	r.count++
+	return nil, nil
+}
+
+func (r *counter) Save() interface{} { return r.count }
+
+func (r *counter) Load(state interface{}) error {
+	r.count = state.(int)
+	return nil
+}
+
 func newExecutor(t *testing.T) *Executor {
 	visitors, err := visitorlist.New([]visitor.Visitor{simple.New()})
 	if err != nil {
@@ -76,6 +151,8 @@ func TestDoTick(t *testing.T) {
 	mock := e.visitors.Visitor(fcpb.FSMType_FSM_TYPE_MOVE).(*simple.Visitor)
 	count := mock.Count()
 
+	// The tick deadline is relative to the time at which Run is called.
+	e.gamestate.Status().SetStartTime()
 	e.doTick()
 	if got := e.Status().GetTick(); got != tick+1 {
 		t.Fatalf("GetTick() = %v, want = %v", got, tick+1)
@@ -93,3 +170,443 @@ func TestDoTick(t *testing.T) {
 		t.Errorf("Count() = %v, want = %v", get, count+1)
 	}
 }
+
+func TestDoTickDeferred(t *testing.T) {
+	const priority = 0
+	aid := id.ActionID("action-id")
+
+	testConfigs := []struct {
+		name         string
+		recorder     *recorder
+		checkpointer Checkpointer
+	}{
+		{name: "Deadline"},
+		{name: "Recorder", recorder: &recorder{}},
+		{name: "Checkpointer", checkpointer: checkpointer{}},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			e := newExecutor(t)
+			if c.recorder != nil {
+				e.SetRecorder(c.recorder)
+			}
+			if c.checkpointer != nil {
+				e.SetCheckpointer(c.checkpointer, 10)
+			}
+			if err := e.Schedule([]action.Action{
+				simpleaction.New(aid, priority),
+			}); err != nil {
+				t.Fatalf("Schedule() = %v, want = nil", err)
+			}
+
+			mock := e.visitors.Visitor(fcpb.FSMType_FSM_TYPE_MOVE).(*simple.Visitor)
+			count := mock.Count()
+
+			// Move the tick deadline into the past, so that the
+			// Visitor passes time out immediately.
+			e.gamestate.Status().SetStartTime()
+			e.runTick = e.gamestate.Status().Tick() + 10
+			if err := e.doTick(); err != nil {
+				t.Fatalf("doTick() = %v, want = nil", err)
+			}
+
+			if got := mock.Count() - count; got != 0 {
+				t.Errorf("Count() = %v, want = %v", got, 0)
+			}
+
+			tick := e.gamestate.Status().Tick()
+			want := []id.ActionID{aid}
+			if c.recorder != nil {
+				if diff := cmp.Diff(want, c.recorder.deferred[tick]); diff != "" {
+					t.Errorf("Defer() mismatch (-want +got):\n%v", diff)
+				}
+			}
+			if c.checkpointer != nil {
+				if diff := cmp.Diff(want, e.skipped[tick][fcpb.FSMType_FSM_TYPE_MOVE]); diff != "" {
+					t.Errorf("skipped mismatch (-want +got):\n%v", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestStepRecorder(t *testing.T) {
+	const priority = 0
+	aid := id.ActionID("action-id")
+
+	e := newExecutor(t)
+	r := &recorder{}
+	e.SetRecorder(r)
+
+	if err := e.Schedule([]action.Action{
+		simpleaction.New(aid, priority),
+	}); err != nil {
+		t.Fatalf("Schedule() = %v, want = nil", err)
+	}
+
+	tick := e.gamestate.Status().Tick()
+	if err := e.Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+	if err := e.Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	if got := len(r.actions[tick+1]); got != 1 {
+		t.Fatalf("len() = %v, want = %v", got, 1)
+	}
+	if got := r.actions[tick+1][0].ID(); got != aid {
+		t.Errorf("ID() = %v, want = %v", got, aid)
+	}
+	if got := len(r.actions[tick+2]); got != 0 {
+		t.Errorf("len() = %v, want = %v", got, 0)
+	}
+}
+
+func TestPending(t *testing.T) {
+	const priority = 0
+	aid := id.ActionID("action-id")
+
+	e := newExecutor(t)
+	if err := e.Schedule([]action.Action{
+		simpleaction.New(aid, priority),
+	}); err != nil {
+		t.Fatalf("Schedule() = %v, want = nil", err)
+	}
+
+	check := func() error {
+		actions := e.Pending()
+		if got := len(actions); got != 1 {
+			t.Fatalf("len() = %v, want = %v", got, 1)
+		}
+		if got := actions[0].ID(); got != aid {
+			t.Errorf("ID() = %v, want = %v", got, aid)
+		}
+		return nil
+	}
+
+	// Scheduled actions are pending before they are applied.
+	if err := e.Pause(check); err != nil {
+		t.Fatalf("Pause() = %v, want = nil", err)
+	}
+
+	if err := e.Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+	if err := e.Pause(check); err != nil {
+		t.Fatalf("Pause() = %v, want = nil", err)
+	}
+}
+
+func TestRunFastForward(t *testing.T) {
+	const endTick = 100
+
+	e := newExecutor(t)
+	e.SetFastForward(true)
+	e.SetEndTick(endTick)
+
+	start := time.Now()
+	if err := e.Run(); err != nil {
+		t.Fatalf("Run() = %v, want = nil", err)
+	}
+
+	// A paced run would take endTick * tickDuration to complete.
+	if got := time.Since(start); got >= endTick*tickDuration {
+		t.Errorf("Run() took %v, want < %v", got, endTick*tickDuration)
+	}
+	if got := e.gamestate.Status().Tick(); got != endTick {
+		t.Errorf("Tick() = %v, want = %v", got, endTick)
+	}
+	if !e.gamestate.Status().IsStopped() {
+		t.Errorf("IsStopped() = %v, want = %v", false, true)
+	}
+	if got := e.TicksPerSecond(); got <= 0 {
+		t.Errorf("TicksPerSecond() = %v, want > 0", got)
+	}
+}
+
+func TestRunReferee(t *testing.T) {
+	const end = 10
+
+	e := newExecutor(t)
+	e.SetFastForward(true)
+	e.SetReferee(referee{end: end})
+
+	if err := e.Run(); err != nil {
+		t.Fatalf("Run() = %v, want = nil", err)
+	}
+
+	if got := e.gamestate.Status().Tick(); got != end {
+		t.Errorf("Tick() = %v, want = %v", got, end)
+	}
+	if !e.gamestate.Status().IsStopped() {
+		t.Errorf("IsStopped() = %v, want = %v", false, true)
+	}
+	if got := e.GameOver().GetTick(); got != end {
+		t.Errorf("GetTick() = %v, want = %v", got, end)
+	}
+}
+
+func TestDoTickMetrics(t *testing.T) {
+	const priority = 0
+
+	const gid = id.GameID("game-id")
+
+	e := newExecutor(t)
+	e.SetMetrics(NewMetrics(metrics.New()), gid)
+
+	if err := e.Schedule([]action.Action{
+		simpleaction.New(id.ActionID("action-id"), priority),
+	}); err != nil {
+		t.Fatalf("Schedule() = %v, want = nil", err)
+	}
+
+	e.gamestate.Status().SetStartTime()
+	if err := e.doTick(); err != nil {
+		t.Fatalf("doTick() = %v, want = nil", err)
+	}
+
+	if got := e.metrics.tickDuration.Count(gid.Value()); got != 1 {
+		t.Errorf("Count() = %v, want = %v", got, 1)
+	}
+	if got := e.metrics.visitorDuration.Count(gid.Value(), fcpb.FSMType_FSM_TYPE_MOVE.String()); got != 1 {
+		t.Errorf("Count() = %v, want = %v", got, 1)
+	}
+	if got := e.metrics.scheduledActions.Get(gid.Value(), fcpb.FSMType_FSM_TYPE_MOVE.String()); got != 1 {
+		t.Errorf("Get() = %v, want = %v", got, 1)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	const priority = 0
+	const window = 10
+	aid := id.ActionID("action-id")
+	lateID := id.ActionID("late-action-id")
+
+	e := newExecutor(t)
+	e.SetCheckpointer(checkpointer{}, window)
+
+	if err := e.Schedule([]action.Action{
+		simpleaction.New(aid, priority),
+	}); err != nil {
+		t.Fatalf("Schedule() = %v, want = nil", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := e.Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+
+	now := e.gamestate.Status().Tick()
+	mock := e.visitors.Visitor(fcpb.FSMType_FSM_TYPE_MOVE).(*simple.Visitor)
+	count := mock.Count()
+
+	var rollbackTick id.Tick
+	if err := e.Rollback(now-2, func() ([]action.Action, error) {
+		rollbackTick = e.gamestate.Status().Tick()
+		return []action.Action{simpleaction.New(lateID, priority)}, nil
+	}); err != nil {
+		t.Fatalf("Rollback() = %v, want = nil", err)
+	}
+
+	if rollbackTick != now-2 {
+		t.Errorf("Tick() = %v, want = %v", rollbackTick, now-2)
+	}
+	if got := e.gamestate.Status().Tick(); got != now {
+		t.Errorf("Tick() = %v, want = %v", got, now)
+	}
+
+	// The late action is applied at the tick immediately after the
+	// rollback tick.
+	if got := len(e.history[now-1].([]action.Action)); got != 1 {
+		t.Errorf("len() = %v, want = %v", got, 1)
+	}
+	if a := e.schedule.Get(fcpb.FSMType_FSM_TYPE_MOVE).Get(lateID); a == nil {
+		t.Error("Get() = nil, want a non-nil value")
+	}
+	if a := e.schedule.Get(fcpb.FSMType_FSM_TYPE_MOVE).Get(aid); a == nil {
+		t.Error("Get() = nil, want a non-nil value")
+	}
+
+	// Both actions are visited in each of the two re-simulated ticks.
+	if got := mock.Count(); got != count+4 {
+		t.Errorf("Count() = %v, want = %v", got, count+4)
+	}
+}
+
+func TestRollbackDeferred(t *testing.T) {
+	const priority = 0
+	aid := id.ActionID("action-id")
+	lateID := id.ActionID("late-action-id")
+
+	e := newExecutor(t)
+	e.SetCheckpointer(checkpointer{}, 10)
+
+	if err := e.Schedule([]action.Action{
+		simpleaction.New(aid, priority),
+	}); err != nil {
+		t.Fatalf("Schedule() = %v, want = nil", err)
+	}
+	if err := e.Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	// Defer the action in the second tick by moving the tick deadline into
+	// the past.
+	e.gamestate.Status().SetStartTime()
+	e.runTick = e.gamestate.Status().Tick() + 10
+	if err := e.doTick(); err != nil {
+		t.Fatalf("doTick() = %v, want = nil", err)
+	}
+
+	if err := e.Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	now := e.gamestate.Status().Tick()
+	mock := e.visitors.Visitor(fcpb.FSMType_FSM_TYPE_MOVE).(*simple.Visitor)
+	count := mock.Count()
+
+	if err := e.Rollback(now-2, func() ([]action.Action, error) {
+		return []action.Action{simpleaction.New(lateID, priority)}, nil
+	}); err != nil {
+		t.Fatalf("Rollback() = %v, want = nil", err)
+	}
+
+	// The originally deferred action is skipped again in the first
+	// re-simulated tick, while the late action is not.
+	if got := mock.Count(); got != count+3 {
+		t.Errorf("Count() = %v, want = %v", got, count+3)
+	}
+	if diff := cmp.Diff([]id.ActionID{aid}, e.skipped[now-1][fcpb.FSMType_FSM_TYPE_MOVE]); diff != "" {
+		t.Errorf("skipped mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestRollbackReferee(t *testing.T) {
+	e := newExecutor(t)
+	e.SetCheckpointer(checkpointer{}, 10)
+
+	r := &counter{}
+	e.SetReferee(r)
+
+	for i := 0; i < 3; i++ {
+		if err := e.Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+
+	now := e.gamestate.Status().Tick()
+	if err := e.Rollback(now-2, func() ([]action.Action, error) { return nil, nil }); err != nil {
+		t.Fatalf("Rollback() = %v, want = nil", err)
+	}
+
+	// The Referee state is rewound to the rollback tick, and the Referee
+	// judges each of the two re-simulated ticks.
+	if r.count != 3 {
+		t.Errorf("count = %v, want = %v", r.count, 3)
+	}
+}
+
+func TestRollbackDisabled(t *testing.T) {
+	const priority = 0
+	aid := id.ActionID("action-id")
+
+	e := newExecutor(t)
+	if err := e.Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	now := e.gamestate.Status().Tick()
+	if err := e.Rollback(now-1, func() ([]action.Action, error) {
+		return []action.Action{simpleaction.New(aid, priority)}, nil
+	}); err != nil {
+		t.Fatalf("Rollback() = %v, want = nil", err)
+	}
+
+	if got := e.gamestate.Status().Tick(); got != now {
+		t.Errorf("Tick() = %v, want = %v", got, now)
+	}
+	if a := e.scheduleCache.Get(fcpb.FSMType_FSM_TYPE_MOVE).Get(aid); a == nil {
+		t.Error("Get() = nil, want a non-nil value")
+	}
+}
+
+func TestScheduleAtOutOfRange(t *testing.T) {
+	const priority = 0
+
+	e := newExecutor(t)
+	e.SetCommandWindow(2, 2)
+	if err := e.Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	now := e.gamestate.Status().Tick()
+	for _, tick := range []id.Tick{now - 3, now + 3} {
+		if err := e.ScheduleAt(tick, func() ([]action.Action, error) {
+			return []action.Action{simpleaction.New(id.ActionID("action-id"), priority)}, nil
+		}); status.Code(err) != codes.OutOfRange {
+			t.Errorf("ScheduleAt() = %v, want = %v", err, codes.OutOfRange)
+		}
+	}
+	if got := len(e.Pending()); got != 0 {
+		t.Errorf("len() = %v, want = %v", got, 0)
+	}
+}
+
+func TestScheduleAtFuture(t *testing.T) {
+	const priority = 0
+	aid := id.ActionID("action-id")
+
+	e := newExecutor(t)
+	e.SetCommandWindow(2, 2)
+
+	now := e.gamestate.Status().Tick()
+	if err := e.ScheduleAt(now+2, func() ([]action.Action, error) {
+		return []action.Action{simpleaction.New(aid, priority)}, nil
+	}); err != nil {
+		t.Fatalf("ScheduleAt() = %v, want = nil", err)
+	}
+
+	// The action is applied at the tick immediately after the requested
+	// tick.
+	for i := 0; i < 3; i++ {
+		if a := e.schedule.Get(fcpb.FSMType_FSM_TYPE_MOVE).Get(aid); a != nil {
+			t.Fatalf("[%v] Get() = %v, want = nil", e.gamestate.Status().Tick(), a)
+		}
+		if got := len(e.Pending()); got != 1 {
+			t.Fatalf("len() = %v, want = %v", got, 1)
+		}
+		if err := e.Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+	if got := e.gamestate.Status().Tick(); got != now+3 {
+		t.Fatalf("Tick() = %v, want = %v", got, now+3)
+	}
+	if a := e.schedule.Get(fcpb.FSMType_FSM_TYPE_MOVE).Get(aid); a == nil {
+		t.Error("Get() = nil, want a non-nil value")
+	}
+}
+
+func TestSetClientTeamStarted(t *testing.T) {
+	e := newExecutor(t)
+	cid, err := e.AddClient()
+	if err != nil {
+		t.Fatalf("AddClient() = _, %v, want = nil", err)
+	}
+	if err := e.SetClientTeam(cid, 1); err != nil {
+		t.Fatalf("SetClientTeam() = %v, want = nil", err)
+	}
+
+	if err := e.gamestate.Status().SetIsStarted(); err != nil {
+		t.Fatalf("SetIsStarted() = %v, want = nil", err)
+	}
+	if err := e.SetClientTeam(cid, 2); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("SetClientTeam() = %v, want = %v", err, codes.FailedPrecondition)
+	}
+	if got, err := e.ClientTeam(cid); err != nil || got != 1 {
+		t.Errorf("ClientTeam() = %v, %v, want = %v, nil", got, err, 1)
+	}
+}
@@ -81,6 +81,11 @@ func (s *Status) Tick() id.Tick { return id.Tick(atomic.LoadInt64(&(s.tickImpl))
 // beginning of each tick loop.
 func (s *Status) IncrementTick() { atomic.AddInt64(&(s.tickImpl), 1) }
 
+// SetTick overrides the current game tick. This is used to resume a game from
+// a snapshot or to roll back the game, and must not be called while the
+// Executor is executing a tick.
+func (s *Status) SetTick(t id.Tick) { atomic.StoreInt64(&(s.tickImpl), int64(t)) }
+
 // IsStarted returns if the Executor is currently executing ticks.
 func (s *Status) IsStarted() bool {
 	s.statusEnumMux.Lock()
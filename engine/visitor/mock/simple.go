@@ -1,6 +1,8 @@
 package simple
 
 import (
+	"context"
+
 	"github.com/downflux/game/engine/visitor/visitor"
 
 	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
@@ -18,7 +20,7 @@ func New() *Visitor {
 	}
 }
 
-func (v *Visitor) Visit(a visitor.Agent) error {
+func (v *Visitor) Visit(ctx context.Context, a visitor.Agent) error {
 	v.counter += 1
 	return nil
 }
@@ -6,6 +6,8 @@
 package visitor
 
 import (
+	"context"
+
 	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
 )
 
@@ -13,8 +15,10 @@ type Agent interface {
 	// Accept conditionally allows the Visitor to mutate the Agent.
 	//
 	// Example:
-	//  func (a *ConcreteAgent) Accept(v Vistor) { return v.Visit(a) }
-	Accept(v Visitor) error
+	//  func (a *ConcreteAgent) Accept(ctx context.Context, v Vistor) error {
+	//    return v.Visit(ctx, a)
+	//  }
+	Accept(ctx context.Context, v Visitor) error
 }
 
 // Visitor defines the list of functions necessary for a process regularly
@@ -23,16 +27,31 @@ type Visitor interface {
 	// Type returns a registered FSMType.
 	Type() fcpb.FSMType
 
-	// Visit will run appropriate commands for the current tick. If
-	// a timeout occurs, the function will return early. This function
-	// may be called concurrently by the game engine.
+	// Visit will run appropriate commands for the current tick. This
+	// function may be called concurrently by the game engine.
 	//
-	// TODO(minkezhang): implement timeout behavior.
+	// If the input context is done before the Agent is mutated, Visit
+	// must return early without mutating the Agent, and either return
+	// nil or the context error. The Agent will be visited again in the
+	// next tick.
 	//
 	// Visitors should never return an unimplemented error -- return
 	// a no-op instead. This ensures Entity objects do not have to do
 	// conditional branches in the Accept function.
-	Visit(a Agent) error
+	Visit(ctx context.Context, a Agent) error
+}
+
+// Planner is an optional interface which a Visitor may implement to inspect
+// all Agents scheduled for the current tick before any of them are visited,
+// e.g. to share work between Agents.
+//
+// Plan is called once per tick, and is never called concurrently with Visit.
+// Plan is given all Agents scheduled for the current tick, including Agents
+// which will be deferred to the next tick. Plan may therefore mutate Agents
+// only if it does so regardless of whether the input context is done, e.g. to
+// settle contention between Agents in a stable order.
+type Planner interface {
+	Plan(ctx context.Context, agents []Agent) error
 }
 
 type Base struct {
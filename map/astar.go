@@ -2,6 +2,7 @@
 package tileastar
 
 import (
+	"context"
 	"math"
 
 	"github.com/downflux/game/map/utils"
@@ -9,14 +10,13 @@ import (
 	"google.golang.org/grpc/status"
 
 	gdpb "github.com/downflux/game/api/data_go_proto"
-	mcpb "github.com/downflux/game/map/api/constants_go_proto"
 	tile "github.com/downflux/game/map/map"
 	fastar "github.com/fzipp/astar"
 )
 
 // dFunc provides a shim for the tile.Map neighbor distance function.
-func dFunc(c map[mcpb.TerrainType]float64, src, dest fastar.Node) float64 {
-	cost, err := tile.D(c, src.(*tile.Tile), dest.(*tile.Tile))
+func dFunc(m *tile.Map, src, dest fastar.Node) float64 {
+	cost, err := m.D(src.(*tile.Tile), dest.(*tile.Tile))
 	if err != nil {
 		return math.Inf(0)
 	}
@@ -34,6 +34,9 @@ func hFunc(src, dest fastar.Node) float64 {
 
 // graphImpl implements fzipp.astar.Graph for the tile.Map struct.
 type graphImpl struct {
+	// ctx interrupts the path search once done.
+	ctx context.Context
+
 	// m holds a reference to the underlying terrain map.
 	m *tile.Map
 
@@ -53,8 +56,12 @@ func boundedBy(a, b, c *gdpb.Coordinate) bool {
 	return (a.GetX() <= b.GetX() && a.GetY() <= b.GetY()) && (b.GetX() < c.GetX() && b.GetY() < c.GetY())
 }
 
-// Neighbours returns neighboring Tile objects from a tile.Map.
+// Neighbours returns neighboring Tile objects from a tile.Map. No neighbors
+// are returned once the search context is done, which ends the search early.
 func (t graphImpl) Neighbours(n fastar.Node) []fastar.Node {
+	if t.ctx.Err() != nil {
+		return nil
+	}
 	neighbors, _ := t.m.Neighbors(n.(*tile.Tile).Val.GetCoordinate())
 	var res []fastar.Node
 	for _, n := range neighbors {
@@ -80,7 +87,10 @@ func (t graphImpl) Neighbours(n fastar.Node) []fastar.Node {
 // bounding box as defined by the tile.Map should be used here. The lower bound
 // of the bounding box is defined as the boundary Coordinate, and the size of
 // the box is specified by the dimension Coordinate.
-func Path(m *tile.Map, src, dest utils.MapCoordinate, boundary, dimension *gdpb.Coordinate) ([]*tile.Tile, float64, error) {
+//
+// If the input context is done before the search completes, the context error
+// is returned.
+func Path(ctx context.Context, m *tile.Map, src, dest utils.MapCoordinate, boundary, dimension *gdpb.Coordinate) ([]*tile.Tile, float64, error) {
 	if m == nil {
 		return nil, 0, status.Errorf(codes.FailedPrecondition, "cannot have nil tile.Map input")
 	}
@@ -95,9 +105,12 @@ func Path(m *tile.Map, src, dest utils.MapCoordinate, boundary, dimension *gdpb.
 	}
 
 	d := func(a, b fastar.Node) float64 {
-		return dFunc(m.C, a, b)
+		return dFunc(m, a, b)
+	}
+	nodes := fastar.FindPath(graphImpl{ctx: ctx, m: m, boundary: boundary, dimension: dimension}, tSrc, tDest, d, hFunc)
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
 	}
-	nodes := fastar.FindPath(graphImpl{m: m, boundary: boundary, dimension: dimension}, tSrc, tDest, d, hFunc)
 
 	var tiles []*tile.Tile
 	for _, node := range nodes {
@@ -112,3 +125,24 @@ func Path(m *tile.Map, src, dest utils.MapCoordinate, boundary, dimension *gdpb.
 	}
 	return tiles, cost, nil
 }
+
+// Smooth straightens the input path by removing intermediate Tile objects
+// wherever there is a line of sight between the Tile before and the Tile
+// after, i.e. string pulling. The first and last Tile objects of the path are
+// always kept. Only blocked Tile objects are considered when checking for a
+// line of sight; differences in terrain cost are ignored.
+func Smooth(m *tile.Map, p []*tile.Tile) []*tile.Tile {
+	if len(p) <= 2 {
+		return p
+	}
+
+	res := []*tile.Tile{p[0]}
+	anchor := p[0]
+	for i := 2; i < len(p); i++ {
+		if !m.LineOfSight(anchor.Coordinate(), p[i].Coordinate()) {
+			anchor = p[i-1]
+			res = append(res, anchor)
+		}
+	}
+	return append(res, p[len(p)-1])
+}
@@ -1,6 +1,7 @@
 package tileastar
 
 import (
+	"context"
 	"math"
 	"testing"
 
@@ -177,7 +178,7 @@ func TestAStarSearchError(t *testing.T) {
 				t.Fatalf("ImportMap() = %v, want = nil", err)
 			}
 
-			if _, _, err = Path(tm, utils.MC(c.src), utils.MC(c.dest), c.boundary, c.dimension); err == nil {
+			if _, _, err = Path(context.Background(), tm, utils.MC(c.src), utils.MC(c.dest), c.boundary, c.dimension); err == nil {
 				t.Fatal("Path() = nil, want a non-nil error")
 			}
 		})
@@ -283,7 +284,7 @@ func TestAStarSearch(t *testing.T) {
 				t.Fatalf("ImportMap() = %v, want = nil", err)
 			}
 
-			tiles, cost, err := Path(tm, utils.MC(c.src), utils.MC(c.dest), c.boundary, c.dimension)
+			tiles, cost, err := Path(context.Background(), tm, utils.MC(c.src), utils.MC(c.dest), c.boundary, c.dimension)
 			if err != nil {
 				t.Fatalf("Path() = %v, want = nil", err)
 			}
@@ -303,3 +304,122 @@ func TestAStarSearch(t *testing.T) {
 		})
 	}
 }
+
+// openMap constructs an open n x n tile.Map with the input movement type,
+// where the input coordinates are blocked.
+func openMap(t *testing.T, n int32, movementType mcpb.MovementType, blocked []*gdpb.Coordinate) *tile.Map {
+	pb := &mdpb.TileMap{
+		Dimension:    &gdpb.Coordinate{X: n, Y: n},
+		MovementType: movementType,
+		TerrainCosts: []*mdpb.TerrainCost{
+			{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS, Cost: 1},
+			{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_BLOCKED, Cost: math.Inf(0)},
+		},
+	}
+	for x := int32(0); x < n; x++ {
+		for y := int32(0); y < n; y++ {
+			pb.Tiles = append(pb.GetTiles(), &mdpb.Tile{
+				Coordinate:  &gdpb.Coordinate{X: x, Y: y},
+				TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS,
+			})
+		}
+	}
+	tm, err := tile.ImportMap(pb)
+	if err != nil {
+		t.Fatalf("ImportMap() = _, %v, want = _, nil", err)
+	}
+	for _, c := range blocked {
+		tm.TileFromCoordinate(c).SetTerrainType(mcpb.TerrainType_TERRAIN_TYPE_BLOCKED)
+	}
+	return tm
+}
+
+func TestDiagonalSearch(t *testing.T) {
+	testConfigs := []struct {
+		name    string
+		blocked []*gdpb.Coordinate
+		want    aStarResult
+	}{
+		{
+			name: "Open",
+			want: aStarResult{
+				path: []*gdpb.Coordinate{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 2}},
+				cost: 2 * math.Sqrt2,
+			},
+		},
+		{
+			name:    "NoCornerCutting",
+			blocked: []*gdpb.Coordinate{{X: 1, Y: 0}, {X: 1, Y: 1}},
+			want: aStarResult{
+				path: []*gdpb.Coordinate{{X: 0, Y: 0}, {X: 0, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}},
+				cost: 4,
+			},
+		},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			tm := openMap(t, 3, mcpb.MovementType_MOVEMENT_TYPE_DIAGONAL, c.blocked)
+			p, cost, err := Path(context.Background(), tm, utils.MapCoordinate{X: 0, Y: 0}, utils.MapCoordinate{X: 2, Y: 2}, &gdpb.Coordinate{}, tm.D)
+			if err != nil {
+				t.Fatalf("Path() = _, _, %v, want = _, _, nil", err)
+			}
+
+			var got []*gdpb.Coordinate
+			for _, t := range p {
+				got = append(got, t.Coordinate())
+			}
+			if diff := cmp.Diff(c.want.path, got, protocmp.Transform()); diff != "" {
+				t.Errorf("Path() mismatch (-want +got):\n%v", diff)
+			}
+			if math.Abs(cost-c.want.cost) > 1e-9 {
+				t.Errorf("Path() = _, %v, _, want = _, %v, _", cost, c.want.cost)
+			}
+		})
+	}
+}
+
+func TestSmooth(t *testing.T) {
+	testConfigs := []struct {
+		name    string
+		blocked []*gdpb.Coordinate
+		path    []*gdpb.Coordinate
+		want    []*gdpb.Coordinate
+	}{
+		{
+			name: "Short",
+			path: []*gdpb.Coordinate{{X: 0, Y: 0}, {X: 1, Y: 0}},
+			want: []*gdpb.Coordinate{{X: 0, Y: 0}, {X: 1, Y: 0}},
+		},
+		{
+			name: "Open",
+			path: []*gdpb.Coordinate{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 3, Y: 1}},
+			want: []*gdpb.Coordinate{{X: 0, Y: 0}, {X: 3, Y: 1}},
+		},
+		{
+			name:    "Obstacle",
+			blocked: []*gdpb.Coordinate{{X: 1, Y: 1}},
+			path:    []*gdpb.Coordinate{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 2, Y: 2}},
+			want:    []*gdpb.Coordinate{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 2}},
+		},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			tm := openMap(t, 4, mcpb.MovementType_MOVEMENT_TYPE_ANY_ANGLE, c.blocked)
+
+			var p []*tile.Tile
+			for _, pb := range c.path {
+				p = append(p, tm.TileFromCoordinate(pb))
+			}
+
+			var got []*gdpb.Coordinate
+			for _, t := range Smooth(tm, p) {
+				got = append(got, t.Coordinate())
+			}
+			if diff := cmp.Diff(c.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("Smooth() mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -26,6 +26,16 @@ var (
 		{X: 1, Y: 0},
 		{X: -1, Y: 0},
 	}
+
+	// diagonalCoordinates provides the Coordinate deltas between a
+	// specific Coordinate and the diagonally adjacent Coordinates to
+	// additionally expand to for maps which allow diagonal movement.
+	diagonalCoordinates = []*gdpb.Coordinate{
+		{X: 1, Y: 1},
+		{X: 1, Y: -1},
+		{X: -1, Y: 1},
+		{X: -1, Y: -1},
+	}
 )
 
 // IsAdjacent tests if two Tile objects are adjacent to one another.
@@ -33,6 +43,11 @@ func IsAdjacent(src, dst *Tile) bool {
 	return math.Abs(float64(dst.X()-src.X()))+math.Abs(float64(dst.Y()-src.Y())) == 1
 }
 
+// IsDiagonal tests if two Tile objects share a corner but not an edge.
+func IsDiagonal(src, dst *Tile) bool {
+	return math.Abs(float64(dst.X()-src.X())) == 1 && math.Abs(float64(dst.Y()-src.Y())) == 1
+}
+
 // D gets exact cost between two neighboring Tiles.
 //
 // We're only taking the "difficulty" metric of the target Tile here; moving
@@ -66,6 +81,10 @@ type Map struct {
 
 	// C is an embedded lookup table of terrain costs.
 	C map[mcpb.TerrainType]float64
+
+	// MovementType specifies the directions in which entities may move
+	// between neighboring Tile objects.
+	MovementType mcpb.MovementType
 }
 
 // ImportMap constructs a new Map object from the input protobuf.
@@ -78,9 +97,10 @@ func ImportMap(pb *mdpb.TileMap) (*Map, error) {
 		tc[c.GetTerrainType()] = c.GetCost()
 	}
 	tm := &Map{
-		D: pb.GetDimension(),
-		M: m,
-		C: tc,
+		D:            pb.GetDimension(),
+		M:            m,
+		C:            tc,
+		MovementType: pb.GetMovementType(),
 	}
 
 	for _, pbt := range pb.GetTiles() {
@@ -119,7 +139,41 @@ func (m *Map) TileFromCoordinate(c *gdpb.Coordinate) *Tile {
 	return m.Tile(c.GetX(), c.GetY())
 }
 
+// D gets the exact cost between two neighboring Tiles in the Map. If the Map
+// allows diagonal movement, the cost of moving between two diagonal Tiles is
+// scaled by the extra distance travelled.
+func (m *Map) D(src, dst *Tile) (float64, error) {
+	if m.Diagonal() && IsDiagonal(src, dst) {
+		return m.C[dst.TerrainType()] * math.Sqrt2, nil
+	}
+	return D(m.C, src, dst)
+}
+
+// Diagonal returns true if entities may move diagonally between Tile
+// objects in the Map.
+func (m *Map) Diagonal() bool {
+	return m.MovementType == mcpb.MovementType_MOVEMENT_TYPE_DIAGONAL || m.AnyAngle()
+}
+
+// AnyAngle returns true if paths planned over the Map should be straightened
+// by removing intermediate Tiles with a line of sight between them.
+func (m *Map) AnyAngle() bool {
+	return m.MovementType == mcpb.MovementType_MOVEMENT_TYPE_ANY_ANGLE
+}
+
+// Open returns true if the input coordinates refer to a Tile in the Map
+// which may be traversed.
+func (m *Map) Open(x, y int32) bool {
+	t := m.Tile(x, y)
+	return t != nil && m.C[t.TerrainType()] < math.Inf(0)
+}
+
 // Neighbors returns the adjacent Tiles of an input Tile object.
+//
+// If the Map allows diagonal movement, diagonally adjacent Tiles are also
+// returned, as long as both Tiles sharing an edge with the input and the
+// diagonal Tile are open, i.e. a path may not cut the corner of a blocked
+// Tile.
 func (m *Map) Neighbors(coordinate *gdpb.Coordinate) ([]*Tile, error) {
 	src := m.TileFromCoordinate(coordinate)
 	if src == nil {
@@ -134,9 +188,60 @@ func (m *Map) Neighbors(coordinate *gdpb.Coordinate) ([]*Tile, error) {
 			neighbors = append(neighbors, t)
 		}
 	}
+
+	if !m.Diagonal() || !m.Open(coordinate.GetX(), coordinate.GetY()) {
+		return neighbors, nil
+	}
+	for _, c := range diagonalCoordinates {
+		x, y := coordinate.GetX()+c.GetX(), coordinate.GetY()+c.GetY()
+		if m.Open(x, y) && m.Open(x, coordinate.GetY()) && m.Open(coordinate.GetX(), y) {
+			neighbors = append(neighbors, m.Tile(x, y))
+		}
+	}
 	return neighbors, nil
 }
 
+// LineOfSight returns true if an entity may move in a straight line between
+// the centers of the two input Tile coordinates without touching a blocked
+// Tile. Here each Tile is a unit square centered on its coordinate; a segment
+// which only grazes the corner of a Tile is considered to touch that Tile,
+// which is consistent with the corner-cutting rule in Neighbors.
+func (m *Map) LineOfSight(src, dst *gdpb.Coordinate) bool {
+	x0, y0 := float64(src.GetX()), float64(src.GetY())
+	x1, y1 := float64(dst.GetX()), float64(dst.GetY())
+	if x0 > x1 {
+		x0, y0, x1, y1 = x1, y1, x0, y0
+	}
+
+	// y returns the y-coordinate of the segment at the input x.
+	y := func(x float64) float64 {
+		if x1 == x0 {
+			return y0
+		}
+		return y0 + (y1-y0)*(x-x0)/(x1-x0)
+	}
+
+	for i := int32(math.Ceil(x0 - 0.5)); i <= int32(math.Floor(x1+0.5)); i++ {
+		lo := math.Max(float64(i)-0.5, x0)
+		hi := math.Min(float64(i)+0.5, x1)
+		if lo > hi {
+			continue
+		}
+
+		ya, yb := y(lo), y(hi)
+		if x1 == x0 {
+			ya, yb = y0, y1
+		}
+		yMin, yMax := math.Min(ya, yb), math.Max(ya, yb)
+		for j := int32(math.Ceil(yMin - 0.5)); j <= int32(math.Floor(yMax+0.5)); j++ {
+			if !m.Open(i, j) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // Tile represents a physical map node.
 type Tile struct {
 	// Val is the underlying representation of the map node. It may be
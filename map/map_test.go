@@ -1,6 +1,7 @@
 package tile
 
 import (
+	"math"
 	"testing"
 
 	"github.com/downflux/game/map/utils"
@@ -204,3 +205,143 @@ func TestGetNeighbors(t *testing.T) {
 		})
 	}
 }
+
+// newMap constructs an open n x n Map with the input movement type, where
+// the input coordinates are blocked.
+func newMap(t *testing.T, n int32, movementType mcpb.MovementType, blocked []*gdpb.Coordinate) *Map {
+	pb := &mdpb.TileMap{
+		Dimension:    &gdpb.Coordinate{X: n, Y: n},
+		MovementType: movementType,
+		TerrainCosts: []*mdpb.TerrainCost{
+			{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS, Cost: 1},
+			{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_BLOCKED, Cost: math.Inf(0)},
+		},
+	}
+	for x := int32(0); x < n; x++ {
+		for y := int32(0); y < n; y++ {
+			pb.Tiles = append(pb.GetTiles(), &mdpb.Tile{
+				Coordinate:  &gdpb.Coordinate{X: x, Y: y},
+				TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS,
+			})
+		}
+	}
+	m, err := ImportMap(pb)
+	if err != nil {
+		t.Fatalf("ImportMap() = _, %v, want = _, nil", err)
+	}
+	for _, c := range blocked {
+		m.TileFromCoordinate(c).SetTerrainType(mcpb.TerrainType_TERRAIN_TYPE_BLOCKED)
+	}
+	return m
+}
+
+func TestMapD(t *testing.T) {
+	testConfigs := []struct {
+		name         string
+		movementType mcpb.MovementType
+		c1           *gdpb.Coordinate
+		c2           *gdpb.Coordinate
+		want         float64
+		success      bool
+	}{
+		{name: "Cardinal", movementType: mcpb.MovementType_MOVEMENT_TYPE_DIAGONAL, c1: &gdpb.Coordinate{X: 0, Y: 0}, c2: &gdpb.Coordinate{X: 0, Y: 1}, want: 1, success: true},
+		{name: "Diagonal", movementType: mcpb.MovementType_MOVEMENT_TYPE_DIAGONAL, c1: &gdpb.Coordinate{X: 0, Y: 0}, c2: &gdpb.Coordinate{X: 1, Y: 1}, want: math.Sqrt2, success: true},
+		{name: "DiagonalCardinalMap", movementType: mcpb.MovementType_MOVEMENT_TYPE_CARDINAL, c1: &gdpb.Coordinate{X: 0, Y: 0}, c2: &gdpb.Coordinate{X: 1, Y: 1}, success: false},
+		{name: "NotAdjacent", movementType: mcpb.MovementType_MOVEMENT_TYPE_DIAGONAL, c1: &gdpb.Coordinate{X: 0, Y: 0}, c2: &gdpb.Coordinate{X: 2, Y: 1}, success: false},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			m := newMap(t, 3, c.movementType, nil)
+			res, err := m.D(m.TileFromCoordinate(c.c1), m.TileFromCoordinate(c.c2))
+			if success := err == nil; success != c.success {
+				t.Fatalf("D() = _, %v, want success = %v", err, c.success)
+			}
+			if c.success && res != c.want {
+				t.Errorf("D() = %v, want = %v", res, c.want)
+			}
+		})
+	}
+}
+
+func TestDiagonalNeighbors(t *testing.T) {
+	/**
+	 *       - - -
+	 *       - X -
+	 * Y = 0 - - -
+	 *   X = 0
+	 */
+	m := newMap(t, 3, mcpb.MovementType_MOVEMENT_TYPE_DIAGONAL, []*gdpb.Coordinate{{X: 1, Y: 1}})
+
+	testConfigs := []struct {
+		name       string
+		coordinate *gdpb.Coordinate
+		want       []*Tile
+	}{
+		{name: "CornerCutting", coordinate: &gdpb.Coordinate{X: 0, Y: 0}, want: []*Tile{
+			m.Tile(0, 1),
+			m.Tile(1, 0),
+		}},
+		{name: "Edge", coordinate: &gdpb.Coordinate{X: 0, Y: 1}, want: []*Tile{
+			m.Tile(0, 0),
+			m.Tile(0, 2),
+		}},
+		{name: "Blocked", coordinate: &gdpb.Coordinate{X: 1, Y: 1}, want: nil},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := m.Neighbors(c.coordinate)
+			if err != nil {
+				t.Fatalf("Neighbors() = _, %v, want = _, nil", err)
+			}
+			if !cmp.Equal(res, c.want, cmpopts.SortSlices(tileLess), protocmp.Transform()) {
+				t.Errorf("Neighbors((%v, %v)) = %v, want = %v", c.coordinate.GetX(), c.coordinate.GetY(), res, c.want)
+			}
+		})
+	}
+
+	open := newMap(t, 3, mcpb.MovementType_MOVEMENT_TYPE_DIAGONAL, nil)
+	if res, err := open.Neighbors(&gdpb.Coordinate{X: 1, Y: 1}); err != nil || len(res) != 8 {
+		t.Errorf("Neighbors() = %v, %v, want = %v, nil", len(res), err, 8)
+	}
+}
+
+func TestLineOfSight(t *testing.T) {
+	/**
+	 *       - - - -
+	 *       - - - -
+	 *       - X - -
+	 * Y = 0 - - - -
+	 *   X = 0
+	 */
+	m := newMap(t, 4, mcpb.MovementType_MOVEMENT_TYPE_ANY_ANGLE, []*gdpb.Coordinate{{X: 1, Y: 1}})
+
+	testConfigs := []struct {
+		name string
+		src  *gdpb.Coordinate
+		dst  *gdpb.Coordinate
+		want bool
+	}{
+		{name: "Trivial", src: &gdpb.Coordinate{X: 0, Y: 0}, dst: &gdpb.Coordinate{X: 0, Y: 0}, want: true},
+		{name: "Horizontal", src: &gdpb.Coordinate{X: 0, Y: 0}, dst: &gdpb.Coordinate{X: 3, Y: 0}, want: true},
+		{name: "HorizontalBlocked", src: &gdpb.Coordinate{X: 0, Y: 1}, dst: &gdpb.Coordinate{X: 3, Y: 1}, want: false},
+		{name: "Vertical", src: &gdpb.Coordinate{X: 2, Y: 3}, dst: &gdpb.Coordinate{X: 2, Y: 0}, want: true},
+		{name: "Diagonal", src: &gdpb.Coordinate{X: 0, Y: 0}, dst: &gdpb.Coordinate{X: 2, Y: 2}, want: false},
+		{name: "CornerCutting", src: &gdpb.Coordinate{X: 0, Y: 1}, dst: &gdpb.Coordinate{X: 1, Y: 2}, want: false},
+		{name: "Sloped", src: &gdpb.Coordinate{X: 0, Y: 2}, dst: &gdpb.Coordinate{X: 3, Y: 3}, want: true},
+		{name: "SlopedBlocked", src: &gdpb.Coordinate{X: 3, Y: 2}, dst: &gdpb.Coordinate{X: 0, Y: 0}, want: false},
+		{name: "OutOfBounds", src: &gdpb.Coordinate{X: 0, Y: 0}, dst: &gdpb.Coordinate{X: 4, Y: 0}, want: false},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if got := m.LineOfSight(c.src, c.dst); got != c.want {
+				t.Errorf("LineOfSight(%v, %v) = %v, want = %v", c.src, c.dst, got, c.want)
+			}
+			if got := m.LineOfSight(c.dst, c.src); got != c.want {
+				t.Errorf("LineOfSight(%v, %v) = %v, want = %v", c.dst, c.src, got, c.want)
+			}
+		})
+	}
+}
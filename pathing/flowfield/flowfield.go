@@ -0,0 +1,165 @@
+// Package flowfield implements flow field path planning over a tile.Map.
+//
+// A flow field is computed once for a destination, and records for every
+// tile in the map the cost of the cheapest path to the destination (i.e. the
+// integration field), along with the next tile along that path. Any number of
+// entities moving to the same destination may then look up their paths
+// without running separate searches.
+package flowfield
+
+import (
+	"container/heap"
+	"context"
+	"math"
+
+	"github.com/downflux/game/map/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	tile "github.com/downflux/game/map/map"
+)
+
+// item is a tile in the search frontier.
+type item struct {
+	c    utils.MapCoordinate
+	cost float64
+}
+
+// queue implements heap.Interface for the search frontier. Ties are broken
+// by coordinate to make the flow field deterministic.
+type queue []item
+
+func (q queue) Len() int { return len(q) }
+func (q queue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return utils.LessThan(q[i].c, q[j].c)
+}
+func (q queue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x interface{}) { *q = append(*q, x.(item)) }
+func (q *queue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	x := old[n-1]
+	*q = old[:n-1]
+	return x
+}
+
+// Field is the flow field for a single destination.
+type Field struct {
+	tm          *tile.Map
+	destination utils.MapCoordinate
+
+	// cost is the cost of the cheapest path from each reachable tile to
+	// the destination.
+	cost map[utils.MapCoordinate]float64
+
+	// next is the next tile along the cheapest path from each reachable
+	// tile to the destination.
+	next map[utils.MapCoordinate]utils.MapCoordinate
+}
+
+// New computes the flow field of the input destination over the input
+// tile.Map via a Dijkstra search outwards from the destination. The Field is
+// not updated when the tile.Map changes, and should be discarded instead.
+//
+// If the input context is done before the search completes, the context error
+// is returned.
+func New(ctx context.Context, tm *tile.Map, destination utils.MapCoordinate) (*Field, error) {
+	if tm.Tile(destination.X, destination.Y) == nil {
+		return nil, status.Errorf(codes.NotFound, "a Tile cannot be found with the input coordinates %v", destination)
+	}
+
+	f := &Field{
+		tm:          tm,
+		destination: destination,
+		cost:        map[utils.MapCoordinate]float64{},
+		next:        map[utils.MapCoordinate]utils.MapCoordinate{},
+	}
+	if !tm.Open(destination.X, destination.Y) {
+		return f, nil
+	}
+
+	f.cost[destination] = 0
+	q := &queue{{c: destination, cost: 0}}
+	for q.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		u := heap.Pop(q).(item)
+		if u.cost > f.cost[u.c] {
+			continue
+		}
+
+		dst := tm.Tile(u.c.X, u.c.Y)
+		neighbors, err := tm.Neighbors(dst.Coordinate())
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range neighbors {
+			// Neighbors are symmetric, so the cost of moving from
+			// the neighbor into the current tile may be computed
+			// directly.
+			d, err := tm.D(n, dst)
+			if err != nil {
+				return nil, err
+			}
+
+			c := utils.MC(n.Coordinate())
+			if cost, found := f.cost[c]; !found || u.cost+d < cost {
+				f.cost[c] = u.cost + d
+				f.next[c] = u.c
+				heap.Push(q, item{c: c, cost: u.cost + d})
+			}
+		}
+	}
+	return f, nil
+}
+
+// Destination returns the destination tile of the Field.
+func (f *Field) Destination() utils.MapCoordinate { return f.destination }
+
+// Cost returns the cost of the cheapest path from the input tile to the
+// destination. The cost is infinite if the destination is unreachable.
+func (f *Field) Cost(c utils.MapCoordinate) float64 {
+	if cost, found := f.cost[c]; found {
+		return cost
+	}
+	return math.Inf(0)
+}
+
+// Next returns the next tile along the cheapest path from the input tile to
+// the destination. Next returns false if the input tile is the destination,
+// or if the destination is unreachable from the input tile.
+func (f *Field) Next(c utils.MapCoordinate) (utils.MapCoordinate, bool) {
+	n, found := f.next[c]
+	return n, found
+}
+
+// Path returns the path from the input source tile to the destination,
+// mirroring the hierarchical A* Path function. The returned path starts with
+// the source tile, and is followed by at most l more tiles; if l is set to 0,
+// the entire path is returned. The returned cost is the cost of the entire
+// path to the destination. An empty path indicates the destination cannot be
+// reached from the source.
+func (f *Field) Path(src utils.MapCoordinate, l int) ([]*tile.Tile, float64, error) {
+	if l < 0 {
+		return nil, 0, status.Error(codes.FailedPrecondition, "cannot specify a negative path length")
+	}
+	if f.tm.Tile(src.X, src.Y) == nil {
+		return nil, 0, status.Errorf(codes.NotFound, "a Tile cannot be found with the input coordinates %v", src)
+	}
+
+	cost := f.Cost(src)
+	if math.IsInf(cost, 0) {
+		return nil, cost, nil
+	}
+
+	p := []*tile.Tile{f.tm.Tile(src.X, src.Y)}
+	for c, ok := f.Next(src); ok && (l == 0 || len(p) <= l); c, ok = f.Next(c) {
+		p = append(p, f.tm.Tile(c.X, c.Y))
+	}
+	return p, cost, nil
+}
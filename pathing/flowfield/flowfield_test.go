@@ -0,0 +1,155 @@
+package flowfield
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/downflux/game/map/utils"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	mcpb "github.com/downflux/game/map/api/constants_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	tile "github.com/downflux/game/map/map"
+)
+
+// newMap constructs an open n x n tile.Map with the input movement type,
+// where the input coordinates are blocked.
+func newMap(t *testing.T, n int32, movementType mcpb.MovementType, blocked []utils.MapCoordinate) *tile.Map {
+	pb := &mdpb.TileMap{
+		Dimension:    &gdpb.Coordinate{X: n, Y: n},
+		MovementType: movementType,
+		TerrainCosts: []*mdpb.TerrainCost{
+			{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS, Cost: 1},
+			{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_BLOCKED, Cost: math.Inf(0)},
+		},
+	}
+	for x := int32(0); x < n; x++ {
+		for y := int32(0); y < n; y++ {
+			pb.Tiles = append(pb.GetTiles(), &mdpb.Tile{
+				Coordinate:  &gdpb.Coordinate{X: x, Y: y},
+				TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS,
+			})
+		}
+	}
+	tm, err := tile.ImportMap(pb)
+	if err != nil {
+		t.Fatalf("ImportMap() = _, %v, want = _, nil", err)
+	}
+	for _, c := range blocked {
+		tm.Tile(c.X, c.Y).SetTerrainType(mcpb.TerrainType_TERRAIN_TYPE_BLOCKED)
+	}
+	return tm
+}
+
+func TestNewError(t *testing.T) {
+	tm := newMap(t, 3, mcpb.MovementType_MOVEMENT_TYPE_CARDINAL, nil)
+	if _, err := New(context.Background(), tm, utils.MapCoordinate{X: 3, Y: 3}); err == nil {
+		t.Error("New() = _, nil, want a non-nil error")
+	}
+}
+
+func TestCost(t *testing.T) {
+	/**
+	 *       - - -
+	 *       X X -
+	 * Y = 0 - - -
+	 *   X = 0
+	 */
+	wall := []utils.MapCoordinate{{X: 0, Y: 1}, {X: 1, Y: 1}}
+	dest := utils.MapCoordinate{X: 0, Y: 2}
+
+	testConfigs := []struct {
+		name         string
+		movementType mcpb.MovementType
+		blocked      []utils.MapCoordinate
+		src          utils.MapCoordinate
+		want         float64
+	}{
+		{name: "Destination", movementType: mcpb.MovementType_MOVEMENT_TYPE_CARDINAL, blocked: wall, src: dest, want: 0},
+		{name: "Adjacent", movementType: mcpb.MovementType_MOVEMENT_TYPE_CARDINAL, blocked: wall, src: utils.MapCoordinate{X: 1, Y: 2}, want: 1},
+		{name: "Detour", movementType: mcpb.MovementType_MOVEMENT_TYPE_CARDINAL, blocked: wall, src: utils.MapCoordinate{X: 0, Y: 0}, want: 6},
+		{name: "DiagonalNoCornerCutting", movementType: mcpb.MovementType_MOVEMENT_TYPE_DIAGONAL, blocked: wall, src: utils.MapCoordinate{X: 0, Y: 0}, want: 6},
+		{name: "Diagonal", movementType: mcpb.MovementType_MOVEMENT_TYPE_DIAGONAL, src: utils.MapCoordinate{X: 2, Y: 0}, want: 2 * math.Sqrt2},
+		{name: "Blocked", movementType: mcpb.MovementType_MOVEMENT_TYPE_CARDINAL, blocked: wall, src: utils.MapCoordinate{X: 1, Y: 1}, want: math.Inf(0)},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			f, err := New(newMap(t, 3, c.movementType, c.blocked), dest)
+			if err != nil {
+				t.Fatalf("New() = _, %v, want = _, nil", err)
+			}
+			if got := f.Cost(c.src); math.Abs(got-c.want) > 1e-9 && !(math.IsInf(got, 0) && math.IsInf(c.want, 0)) {
+				t.Errorf("Cost() = %v, want = %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestPath(t *testing.T) {
+	/**
+	 *       - - -
+	 *       X X -
+	 * Y = 0 - - -
+	 *   X = 0
+	 */
+	tm := newMap(t, 3, mcpb.MovementType_MOVEMENT_TYPE_CARDINAL, []utils.MapCoordinate{{X: 0, Y: 1}, {X: 1, Y: 1}})
+	f, err := New(context.Background(), tm, utils.MapCoordinate{X: 0, Y: 2})
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = _, nil", err)
+	}
+
+	testConfigs := []struct {
+		name string
+		src  utils.MapCoordinate
+		l    int
+		want []*gdpb.Coordinate
+	}{
+		{
+			name: "Full",
+			src:  utils.MapCoordinate{X: 0, Y: 0},
+			l:    0,
+			want: []*gdpb.Coordinate{
+				{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 2, Y: 2}, {X: 1, Y: 2}, {X: 0, Y: 2},
+			},
+		},
+		{
+			name: "Partial",
+			src:  utils.MapCoordinate{X: 0, Y: 0},
+			l:    2,
+			want: []*gdpb.Coordinate{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}},
+		},
+		{
+			name: "Destination",
+			src:  utils.MapCoordinate{X: 0, Y: 2},
+			l:    0,
+			want: []*gdpb.Coordinate{{X: 0, Y: 2}},
+		},
+		{
+			name: "Unreachable",
+			src:  utils.MapCoordinate{X: 1, Y: 1},
+			l:    0,
+			want: nil,
+		},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			p, _, err := f.Path(c.src, c.l)
+			if err != nil {
+				t.Fatalf("Path() = _, _, %v, want = _, _, nil", err)
+			}
+
+			var got []*gdpb.Coordinate
+			for _, t := range p {
+				got = append(got, t.Coordinate())
+			}
+			if diff := cmp.Diff(c.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("Path() mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
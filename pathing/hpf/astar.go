@@ -4,6 +4,7 @@
 package astar
 
 import (
+	"context"
 	"math"
 
 	"github.com/downflux/game/map/utils"
@@ -24,7 +25,7 @@ var (
 
 // clusterBoundedTilePath constructs a path between two tile.Tile objects
 // co-located in the same cluster.
-func clusterBoundedTilePath(tm *tile.Map, g *graph.Graph, src, dest utils.MapCoordinate) ([]*tile.Tile, float64, error) {
+func clusterBoundedTilePath(ctx context.Context, tm *tile.Map, g *graph.Graph, src, dest utils.MapCoordinate) ([]*tile.Tile, float64, error) {
 	c1, err := cluster.ClusterCoordinateFromTileCoordinate(g.NodeMap.ClusterMap, src)
 	if err != nil {
 		return nil, 0, err
@@ -47,7 +48,7 @@ func clusterBoundedTilePath(tm *tile.Map, g *graph.Graph, src, dest utils.MapCoo
 		return nil, 0, err
 	}
 
-	return tileastar.Path(tm, src, dest, utils.PB(tileBoundary), utils.PB(tileDimension))
+	return tileastar.Path(ctx, tm, src, dest, utils.PB(tileBoundary), utils.PB(tileDimension))
 }
 
 // Path takes as input the source and destination coordinates from the
@@ -55,29 +56,28 @@ func clusterBoundedTilePath(tm *tile.Map, g *graph.Graph, src, dest utils.MapCoo
 // objects originating from the start location. The length of the returned
 // path may be specified; if the length is set to 0, then the entire path
 // is returned.
-func Path(tm *tile.Map, g *graph.Graph, src, dest utils.MapCoordinate, l int) ([]*tile.Tile, float64, error) {
+//
+// Path does not mutate the input tile.Map or graph.Graph, and may be called
+// concurrently, as long as neither is mutated during the call.
+//
+// If the input context is done before the search completes, the context error
+// is returned.
+func Path(ctx context.Context, tm *tile.Map, g *graph.Graph, src, dest utils.MapCoordinate, l int) ([]*tile.Tile, float64, error) {
 	if l < 0 {
 		return nil, 0, status.Error(codes.FailedPrecondition, "cannot specify a negative path length")
 	}
 
-	p, c, _ := clusterBoundedTilePath(tm, g, src, dest)
-	if p != nil {
-		return p, c, nil
-	}
-
-	srcID, err := graph.InsertEphemeralNode(tm, g, src)
-	if err != nil {
+	p, c, _ := clusterBoundedTilePath(ctx, tm, g, src, dest)
+	if err := ctx.Err(); err != nil {
 		return nil, 0, err
 	}
-	defer graph.RemoveEphemeralNode(g, src, srcID)
-
-	destID, err := graph.InsertEphemeralNode(tm, g, dest)
-	if err != nil {
-		return nil, 0, err
+	if p != nil {
+		return p, c, nil
 	}
-	defer graph.RemoveEphemeralNode(g, dest, destID)
 
-	nPath, cost, err := graphastar.Path(tm, g, src, dest)
+	// The source and destination are added to the abstract graph for the
+	// duration of the query only, without mutating the shared graph.
+	nPath, cost, err := graphastar.Path(ctx, tm, g, src, dest)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -119,7 +119,7 @@ func Path(tm *tile.Map, g *graph.Graph, src, dest utils.MapCoordinate, l int) ([
 		var p []*tile.Tile
 		if c1 == c2 {
 			var err error
-			p, _, err = clusterBoundedTilePath(tm, g, t1, t2)
+			p, _, err = clusterBoundedTilePath(ctx, tm, g, t1, t2)
 			if err != nil {
 				return nil, 0, err
 			}
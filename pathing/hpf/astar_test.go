@@ -1,12 +1,16 @@
 package astar
 
 import (
+	"context"
 	"math"
 	"testing"
 
 	"github.com/downflux/game/map/utils"
 	"github.com/downflux/game/pathing/hpf/graph"
 	"github.com/google/go-cmp/cmp"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/testing/protocmp"
 
 	gdpb "github.com/downflux/game/api/data_go_proto"
@@ -280,7 +284,7 @@ func TestPath(t *testing.T) {
 
 	for _, c := range testConfigs {
 		t.Run(c.name, func(t *testing.T) {
-			path, cost, err := Path(c.tm, c.g, utils.MC(c.src), utils.MC(c.dest), c.l)
+			path, cost, err := Path(context.Background(), c.tm, c.g, utils.MC(c.src), utils.MC(c.dest), c.l)
 			if err != nil {
 				t.Fatalf("Path() = _, _, %v, want = _, _, nil", err)
 			}
@@ -296,3 +300,38 @@ func TestPath(t *testing.T) {
 		})
 	}
 }
+
+func TestPathConcurrent(t *testing.T) {
+	const n = 20
+
+	tm, err := buildTileMap(
+		utils.MC(&gdpb.Coordinate{X: 10, Y: 10}),
+		[]utils.MapCoordinate{{X: 5, Y: 1}, {X: 5, Y: 2}, {X: 5, Y: 3}, {X: 5, Y: 4}, {X: 5, Y: 5}},
+	)
+	if err != nil {
+		t.Fatalf("buildTileMap() = _, %v, want = _, nil", err)
+	}
+	g, err := graph.BuildGraph(tm, &gdpb.Coordinate{X: 3, Y: 3})
+	if err != nil {
+		t.Fatalf("BuildGraph() = _, %v, want = _, nil", err)
+	}
+
+	src := utils.MapCoordinate{X: 1, Y: 1}
+	dest := utils.MapCoordinate{X: 8, Y: 2}
+	var eg errgroup.Group
+	for i := 0; i < n; i++ {
+		eg.Go(func() error {
+			p, _, err := Path(context.Background(), tm, g, src, dest, 0)
+			if err != nil {
+				return err
+			}
+			if len(p) == 0 || utils.MC(p[len(p)-1].Coordinate()) != dest {
+				return status.Errorf(codes.Internal, "Path() = %v, want a path ending at %v", p, dest)
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		t.Errorf("Wait() = %v, want = nil", err)
+	}
+}
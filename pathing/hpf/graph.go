@@ -3,6 +3,7 @@
 package graph
 
 import (
+	"context"
 	"math"
 	"math/rand"
 
@@ -48,6 +49,9 @@ func H(src, dst *pdpb.AbstractNode) (float64, error) {
 // Graph contains the necessary state information to make an efficient
 // path planning call on very large maps via hierarchical A* search, as
 // described in Botea 2004.
+//
+// The Graph may be read by multiple concurrent path queries (see Overlay), but
+// must not be mutated concurrently with any query.
 type Graph struct {
 	// NodeMap is a hash of AbstractNodes.
 	NodeMap *node.Map
@@ -80,6 +84,29 @@ func BuildGraph(tm *tile.Map, tileDimension *gdpb.Coordinate) (*Graph, error) {
 	if err != nil {
 		return nil, err
 	}
+	addTransitions(g, transitions)
+
+	// Build Tile-Tile edges within a cluster of a cluster.Map.
+	for _, c := range cluster.Iterator(g.NodeMap.ClusterMap) {
+		nodes, err := g.NodeMap.GetByCluster(c)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nodes {
+			if err := connect(tm, g, utils.MC(n.GetTileCoordinate())); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// addTransitions inserts the AbstractNode endpoints of the input transitions
+// into the Graph, along with the INTER_EDGE AbstractEdge connecting each pair
+// of endpoints. AbstractNodes which already exist in the Graph, e.g. tiles
+// shared between multiple transitions, are not duplicated.
+func addTransitions(g *Graph, transitions []entrance.Transition) {
 	for _, t := range transitions {
 		g.NodeMap.Add(t.N1)
 		g.NodeMap.Add(t.N2)
@@ -90,21 +117,149 @@ func BuildGraph(tm *tile.Map, tileDimension *gdpb.Coordinate) (*Graph, error) {
 			Weight:      1, // Inter-edges are always of cost 1, per Botea.
 		})
 	}
+}
 
-	// Build Tile-Tile edges within a cluster of a cluster.Map.
-	for _, c := range cluster.Iterator(g.NodeMap.ClusterMap) {
+// RebuildClusters recomputes the abstract representation of the input
+// clusters after the terrain of the underlying tile.Map has changed, e.g.
+// when a building is placed on or removed from the map.
+//
+// All transitions along the borders of the input clusters are rebuilt, as are
+// the INTRA_EDGE AbstractEdge instances within the input clusters. AbstractNodes
+// in neighboring clusters which no longer border an open tile are dropped, and
+// the remaining AbstractNodes in neighboring clusters are connected to any new
+// transitions. The rest of the Graph is left untouched.
+//
+// Ephemeral AbstractNodes in the input clusters are kept and reconnected to
+// the rebuilt cluster.
+func RebuildClusters(tm *tile.Map, g *Graph, clusters []utils.MapCoordinate) error {
+	cm := g.NodeMap.ClusterMap
+
+	affected := map[utils.MapCoordinate]bool{}
+	for _, c := range clusters {
+		if err := cluster.ValidateClusterInRange(cm, c); err != nil {
+			return err
+		}
+		affected[c] = true
+	}
+
+	// neighbors tracks the unchanged clusters which share a border with
+	// an affected cluster.
+	neighbors := map[utils.MapCoordinate]bool{}
+	borders := map[[2]utils.MapCoordinate]bool{}
+	for c := range affected {
 		nodes, err := g.NodeMap.GetByCluster(c)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		for _, n := range nodes {
-			if err := connect(tm, g, utils.MC(n.GetTileCoordinate())); err != nil {
-				return nil, err
+			t := utils.MC(n.GetTileCoordinate())
+			if n.GetIsEphemeral() {
+				if err := disconnectEdges(g, t); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := disconnect(g, t); err != nil {
+				return err
+			}
+		}
+
+		ns, err := cluster.Neighbors(cm, c)
+		if err != nil {
+			return err
+		}
+		for _, d := range ns {
+			if !cluster.IsAdjacent(cm, c, d) {
+				continue
+			}
+			if !affected[d] {
+				neighbors[d] = true
+			}
+			if utils.LessThan(c, d) {
+				borders[[2]utils.MapCoordinate{c, d}] = true
+			} else {
+				borders[[2]utils.MapCoordinate{d, c}] = true
 			}
 		}
 	}
 
-	return g, nil
+	// Transition nodes in neighboring clusters which have lost all of
+	// their INTER_EDGE connections no longer lie on an open border.
+	for c := range neighbors {
+		nodes, err := g.NodeMap.GetByCluster(c)
+		if err != nil {
+			return err
+		}
+		for _, n := range nodes {
+			if n.GetIsEphemeral() {
+				continue
+			}
+			t := utils.MC(n.GetTileCoordinate())
+			edges, err := g.EdgeMap.GetBySource(t)
+			if err != nil {
+				return err
+			}
+			isTransition := false
+			for _, e := range edges {
+				if e.GetEdgeType() == pcpb.EdgeType_EDGE_TYPE_INTER {
+					isTransition = true
+					break
+				}
+			}
+			if !isTransition {
+				if err := disconnect(g, t); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for b := range borders {
+		transitions, err := entrance.BuildTransitions(tm, cm, b[0], b[1])
+		if err != nil {
+			return err
+		}
+		addTransitions(g, transitions)
+	}
+
+	for _, cs := range []map[utils.MapCoordinate]bool{affected, neighbors} {
+		for c := range cs {
+			nodes, err := g.NodeMap.GetByCluster(c)
+			if err != nil {
+				return err
+			}
+			for _, n := range nodes {
+				if err := connect(tm, g, utils.MC(n.GetTileCoordinate())); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Update refreshes the Graph after the terrain of the input tiles has been
+// changed in the underlying tile.Map, e.g. via tile.Tile.SetTerrainType.
+//
+// Only the clusters containing the input tiles are rebuilt, along with the
+// transitions to their immediate neighbors; see RebuildClusters.
+func (g *Graph) Update(tm *tile.Map, tiles []utils.MapCoordinate) error {
+	found := map[utils.MapCoordinate]bool{}
+	var clusters []utils.MapCoordinate
+	for _, t := range tiles {
+		c, err := cluster.ClusterCoordinateFromTileCoordinate(g.NodeMap.ClusterMap, t)
+		if err != nil {
+			return err
+		}
+		if !found[c] {
+			found[c] = true
+			clusters = append(clusters, c)
+		}
+	}
+	if len(clusters) == 0 {
+		return nil
+	}
+	return RebuildClusters(tm, g, clusters)
 }
 
 // connect takes as input an AbstractNode, builds all possible INTRA_EDGE
@@ -143,41 +298,198 @@ func connect(tm *tile.Map, g *Graph, t utils.MapCoordinate) error {
 			continue
 		}
 
-		tileBoundary, err := cluster.TileBoundary(g.NodeMap.ClusterMap, c)
+		e, err = intraEdge(tm, g, c, n1, n2)
 		if err != nil {
 			return err
 		}
-		tileDimension, err := cluster.TileDimension(g.NodeMap.ClusterMap, c)
+		if e != nil {
+			g.EdgeMap.Add(e)
+		}
+	}
+
+	return nil
+}
+
+// intraEdge builds the INTRA_EDGE AbstractEdge between two AbstractNode
+// instances in the input cluster. intraEdge returns nil if there is no path
+// between the two nodes which lies within the cluster.
+func intraEdge(tm *tile.Map, g *Graph, c utils.MapCoordinate, n1, n2 *pdpb.AbstractNode) (*pdpb.AbstractEdge, error) {
+	tileBoundary, err := cluster.TileBoundary(g.NodeMap.ClusterMap, c)
+	if err != nil {
+		return nil, err
+	}
+	tileDimension, err := cluster.TileDimension(g.NodeMap.ClusterMap, c)
+	if err != nil {
+		return nil, err
+	}
+
+	p, cost, err := tileastar.Path(
+		context.Background(),
+		tm,
+		utils.MC(n1.GetTileCoordinate()),
+		utils.MC(n2.GetTileCoordinate()),
+		utils.PB(tileBoundary),
+		utils.PB(tileDimension))
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, nil
+	}
+
+	return &pdpb.AbstractEdge{
+		Source:      n1.GetTileCoordinate(),
+		Destination: n2.GetTileCoordinate(),
+		EdgeType:    pcpb.EdgeType_EDGE_TYPE_INTRA,
+		Weight:      cost,
+	}, nil
+}
+
+// Overlay is a read-only view of a Graph with additional ephemeral
+// AbstractNode instances, e.g. the source and destination of a single path
+// query. The ephemeral AbstractNodes and the AbstractEdges connecting them to
+// the rest of the cluster are stored in the Overlay instead of the Graph, so
+// that multiple path queries may run concurrently over the same Graph.
+type Overlay struct {
+	g *Graph
+
+	// nodes holds the ephemeral AbstractNodes of the Overlay.
+	nodes map[utils.MapCoordinate]*pdpb.AbstractNode
+
+	// edges holds the AbstractEdges connected to the ephemeral
+	// AbstractNodes, indexed by both endpoints.
+	edges map[utils.MapCoordinate][]*pdpb.AbstractEdge
+}
+
+// NewOverlay constructs an Overlay of the input Graph, adding an ephemeral
+// AbstractNode for each input tile coordinate which does not already match an
+// AbstractNode in the Graph. Each ephemeral AbstractNode is connected to the
+// non-ephemeral AbstractNodes in the same cluster.
+//
+// The Graph is not mutated, and may be shared between concurrent calls, but
+// must not be mutated itself (e.g. via Update) while the Overlay is in use.
+func NewOverlay(tm *tile.Map, g *Graph, tiles ...utils.MapCoordinate) (*Overlay, error) {
+	o := &Overlay{
+		g:     g,
+		nodes: map[utils.MapCoordinate]*pdpb.AbstractNode{},
+		edges: map[utils.MapCoordinate][]*pdpb.AbstractEdge{},
+	}
+
+	for _, t := range tiles {
+		n1, err := o.Get(t)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if n1 != nil {
+			continue
 		}
 
-		p, cost, err := tileastar.Path(
-			tm,
-			utils.MC(n1.GetTileCoordinate()),
-			utils.MC(n2.GetTileCoordinate()),
-			utils.PB(tileBoundary),
-			utils.PB(tileDimension))
+		n1 = &pdpb.AbstractNode{IsEphemeral: true, TileCoordinate: utils.PB(t)}
+		o.nodes[t] = n1
+
+		c, err := cluster.ClusterCoordinateFromTileCoordinate(g.NodeMap.ClusterMap, t)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		nodes, err := g.NodeMap.GetByCluster(c)
+		if err != nil {
+			return nil, err
+		}
+		for _, n2 := range nodes {
+			// As in connect, ephemeral nodes are invisible to one
+			// another.
+			if n2.GetIsEphemeral() {
+				continue
+			}
+			e, err := intraEdge(tm, g, c, n1, n2)
+			if err != nil {
+				return nil, err
+			}
+			if e != nil {
+				o.edges[t] = append(o.edges[t], e)
+				o.edges[utils.MC(n2.GetTileCoordinate())] = append(o.edges[utils.MC(n2.GetTileCoordinate())], e)
+			}
 		}
+	}
+	return o, nil
+}
+
+// Get returns the AbstractNode at the input tile coordinate, which may be
+// either an ephemeral AbstractNode of the Overlay or an AbstractNode of the
+// underlying Graph.
+func (o *Overlay) Get(t utils.MapCoordinate) (*pdpb.AbstractNode, error) {
+	if n, found := o.nodes[t]; found {
+		return n, nil
+	}
+	return o.g.NodeMap.Get(t)
+}
 
-		if p != nil {
-			g.EdgeMap.Add(&pdpb.AbstractEdge{
-				Source:      n1.GetTileCoordinate(),
-				Destination: n2.GetTileCoordinate(),
-				EdgeType:    pcpb.EdgeType_EDGE_TYPE_INTRA,
-				Weight:      cost,
-			})
+// edge returns the AbstractEdge connecting the two input tile coordinates.
+func (o *Overlay) edge(t1, t2 utils.MapCoordinate) (*pdpb.AbstractEdge, error) {
+	for _, e := range o.edges[t1] {
+		if (utils.MC(e.GetSource()) == t1 && utils.MC(e.GetDestination()) == t2) || (utils.MC(e.GetSource()) == t2 && utils.MC(e.GetDestination()) == t1) {
+			return e, nil
 		}
 	}
+	return o.g.EdgeMap.Get(t1, t2)
+}
 
-	return nil
+// D gets exact cost between two neighboring AbstractNodes in the Overlay.
+func (o *Overlay) D(src, dst *pdpb.AbstractNode) (float64, error) {
+	e, err := o.edge(utils.MC(src.GetTileCoordinate()), utils.MC(dst.GetTileCoordinate()))
+	if err != nil {
+		return 0, err
+	}
+	if e == nil {
+		return 0, status.Error(codes.NotFound, "an AbstractEdge does not exist with the given AbstractNode endpoints in the Overlay")
+	}
+	return e.GetWeight(), nil
+}
+
+// Neighbors returns all adjacent AbstractNode instances in the Overlay. As
+// in Graph.Neighbors, the returned instances may include ephemeral
+// AbstractNodes.
+func (o *Overlay) Neighbors(n *pdpb.AbstractNode) ([]*pdpb.AbstractNode, error) {
+	t := utils.MC(n.GetTileCoordinate())
+	node, err := o.Get(t)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, status.Error(codes.FailedPrecondition, "cannot find specified node")
+	}
+
+	edges, err := o.g.EdgeMap.GetBySource(t)
+	if err != nil {
+		return nil, err
+	}
+	edges = append(edges, o.edges[t]...)
+
+	var neighbors []*pdpb.AbstractNode
+	for _, e := range edges {
+		d := utils.MC(e.GetSource())
+		if d == t {
+			d = utils.MC(e.GetDestination())
+		}
+
+		m, err := o.Get(d)
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			return nil, status.Errorf(codes.NotFound, "invalid node coordinate %v specified for edge %v", d, e)
+		}
+		neighbors = append(neighbors, m)
+	}
+	return neighbors, nil
 }
 
 // AddEphemeralNode adds a temporary AbstractNode to the Graph and connects it
 // to the rest of the cluster via AbstractEdge instances.
 //
+// Unlike NewOverlay, InsertEphemeralNode mutates the Graph, and therefore may
+// not be called concurrently with other path queries.
+//
 // Function returns a UUID which needs to be tracked by the caller to be passed
 // into RemoveEphemeralNode. This function is a no-op if the input coordinates
 // match a non-ephemeral AbstractNode.
@@ -215,6 +527,12 @@ func InsertEphemeralNode(tm *tile.Map, g *Graph, t utils.MapCoordinate) (int64,
 // to it, as well as remove the node itself from the Graph.
 func disconnect(g *Graph, t utils.MapCoordinate) error {
 	g.NodeMap.Pop(t)
+	return disconnectEdges(g, t)
+}
+
+// disconnectEdges removes all edges connected to the input AbstractNode, but
+// leaves the node itself in the Graph.
+func disconnectEdges(g *Graph, t utils.MapCoordinate) error {
 	edges, err := g.EdgeMap.GetBySource(t)
 	if err != nil {
 		return err
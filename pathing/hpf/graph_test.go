@@ -691,3 +691,244 @@ func TestDeleteEphemeralNode(t *testing.T) {
 		t.Errorf("Get() = %v, %v, want = nil, nil", n, err)
 	}
 }
+
+func abstractNodes(t *testing.T, g *Graph) []*pdpb.AbstractNode {
+	var nodes []*pdpb.AbstractNode
+	for _, c := range cluster.Iterator(g.NodeMap.ClusterMap) {
+		ns, err := g.NodeMap.GetByCluster(c)
+		if err != nil {
+			t.Fatalf("GetByCluster() = _, %v, want = _, nil", err)
+		}
+		nodes = append(nodes, ns...)
+	}
+	return nodes
+}
+
+func TestRebuildClusters(t *testing.T) {
+	clusterDimension := &gdpb.Coordinate{X: 3, Y: 3}
+
+	testConfigs := []struct {
+		name     string
+		blocked  []utils.MapCoordinate
+		clusters []utils.MapCoordinate
+	}{
+		{
+			name:     "Interior",
+			blocked:  []utils.MapCoordinate{{X: 1, Y: 1}},
+			clusters: []utils.MapCoordinate{{X: 0, Y: 0}},
+		},
+		{
+			name:     "Border",
+			blocked:  []utils.MapCoordinate{{X: 2, Y: 0}, {X: 2, Y: 1}, {X: 2, Y: 2}},
+			clusters: []utils.MapCoordinate{{X: 0, Y: 0}},
+		},
+		{
+			name:     "MultipleClusters",
+			blocked:  []utils.MapCoordinate{{X: 2, Y: 2}, {X: 2, Y: 3}, {X: 3, Y: 2}, {X: 3, Y: 3}},
+			clusters: []utils.MapCoordinate{{X: 0, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 0}, {X: 1, Y: 1}},
+		},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			tm, err := tile.ImportMap(largeMapProto)
+			if err != nil {
+				t.Fatalf("ImportMap() = _, %v, want = _, nil", err)
+			}
+			tm.C[mcpb.TerrainType_TERRAIN_TYPE_BLOCKED] = math.Inf(0)
+
+			g, err := BuildGraph(tm, clusterDimension)
+			if err != nil {
+				t.Fatalf("BuildGraph() = _, %v, want = _, nil", err)
+			}
+
+			// Blocking and then reopening the tiles should each
+			// result in the same Graph as building the Graph from
+			// scratch.
+			for _, terrainType := range []mcpb.TerrainType{
+				mcpb.TerrainType_TERRAIN_TYPE_BLOCKED,
+				mcpb.TerrainType_TERRAIN_TYPE_PLAINS,
+			} {
+				for _, b := range c.blocked {
+					tm.TileFromCoordinate(utils.PB(b)).SetTerrainType(terrainType)
+				}
+
+				if err := RebuildClusters(tm, g, c.clusters); err != nil {
+					t.Fatalf("RebuildClusters() = %v, want = nil", err)
+				}
+
+				want, err := BuildGraph(tm, clusterDimension)
+				if err != nil {
+					t.Fatalf("BuildGraph() = _, %v, want = _, nil", err)
+				}
+
+				if diff := cmp.Diff(
+					abstractNodes(t, want),
+					abstractNodes(t, g),
+					protocmp.Transform(),
+					cmpopts.SortSlices(nodeLess),
+				); diff != "" {
+					t.Errorf("GetByCluster() mismatch for terrain %v (-want +got):\n%s", terrainType, diff)
+				}
+				if !edgeMapEqual(*want.EdgeMap, *g.EdgeMap) {
+					t.Errorf("edgeMapEqual() = false for terrain %v, want = true", terrainType)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	clusterDimension := &gdpb.Coordinate{X: 3, Y: 3}
+
+	testConfigs := []struct {
+		name  string
+		tiles []utils.MapCoordinate
+	}{
+		{name: "NoTiles", tiles: nil},
+		{name: "Interior", tiles: []utils.MapCoordinate{{X: 4, Y: 4}}},
+		{name: "Border", tiles: []utils.MapCoordinate{{X: 3, Y: 0}, {X: 3, Y: 1}, {X: 3, Y: 2}}},
+		{name: "Corner", tiles: []utils.MapCoordinate{{X: 2, Y: 2}, {X: 3, Y: 3}}},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			tm, err := tile.ImportMap(largeMapProto)
+			if err != nil {
+				t.Fatalf("ImportMap() = _, %v, want = _, nil", err)
+			}
+			tm.C[mcpb.TerrainType_TERRAIN_TYPE_BLOCKED] = math.Inf(0)
+
+			g, err := BuildGraph(tm, clusterDimension)
+			if err != nil {
+				t.Fatalf("BuildGraph() = _, %v, want = _, nil", err)
+			}
+
+			// Ephemeral nodes inserted before the terrain change
+			// should remain usable after the update.
+			e := utils.MapCoordinate{X: 5, Y: 5}
+			key, err := InsertEphemeralNode(tm, g, e)
+			if err != nil {
+				t.Fatalf("InsertEphemeralNode() = _, %v, want = _, nil", err)
+			}
+
+			for _, u := range c.tiles {
+				tm.TileFromCoordinate(utils.PB(u)).SetTerrainType(mcpb.TerrainType_TERRAIN_TYPE_BLOCKED)
+			}
+			if err := g.Update(tm, c.tiles); err != nil {
+				t.Fatalf("Update() = %v, want = nil", err)
+			}
+
+			if n, err := g.NodeMap.Get(e); err != nil || n == nil {
+				t.Errorf("Get() = %v, %v, want = non-nil, nil", n, err)
+			}
+			if err := RemoveEphemeralNode(g, e, key); err != nil {
+				t.Fatalf("RemoveEphemeralNode() = %v, want = nil", err)
+			}
+
+			want, err := BuildGraph(tm, clusterDimension)
+			if err != nil {
+				t.Fatalf("BuildGraph() = _, %v, want = _, nil", err)
+			}
+			if diff := cmp.Diff(
+				abstractNodes(t, want),
+				abstractNodes(t, g),
+				protocmp.Transform(),
+				cmpopts.SortSlices(nodeLess),
+			); diff != "" {
+				t.Errorf("GetByCluster() mismatch (-want +got):\n%s", diff)
+			}
+			if !edgeMapEqual(*want.EdgeMap, *g.EdgeMap) {
+				t.Errorf("edgeMapEqual() = false, want = true")
+			}
+		})
+	}
+}
+
+func TestUpdateOutOfRange(t *testing.T) {
+	tm, err := tile.ImportMap(largeMapProto)
+	if err != nil {
+		t.Fatalf("ImportMap() = _, %v, want = _, nil", err)
+	}
+	g, err := BuildGraph(tm, &gdpb.Coordinate{X: 3, Y: 3})
+	if err != nil {
+		t.Fatalf("BuildGraph() = _, %v, want = _, nil", err)
+	}
+	if err := g.Update(tm, []utils.MapCoordinate{{X: 6, Y: 6}}); err == nil {
+		t.Error("Update() = nil, want a non-nil error")
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	tm, err := tile.ImportMap(largeMapProto)
+	if err != nil {
+		t.Fatalf("ImportMap() = _, %v, want = _, nil", err)
+	}
+	g, err := BuildGraph(tm, &gdpb.Coordinate{X: 3, Y: 3})
+	if err != nil {
+		t.Fatalf("BuildGraph() = _, %v, want = _, nil", err)
+	}
+	want, err := BuildGraph(tm, &gdpb.Coordinate{X: 3, Y: 3})
+	if err != nil {
+		t.Fatalf("BuildGraph() = _, %v, want = _, nil", err)
+	}
+
+	src := utils.MapCoordinate{X: 1, Y: 1}
+	dest := utils.MapCoordinate{X: 4, Y: 4}
+	o, err := NewOverlay(tm, g, src, dest)
+	if err != nil {
+		t.Fatalf("NewOverlay() = _, %v, want = _, nil", err)
+	}
+
+	for _, c := range []utils.MapCoordinate{src, dest} {
+		n, err := o.Get(c)
+		if err != nil || n == nil || !n.GetIsEphemeral() {
+			t.Fatalf("Get() = %v, %v, want = <ephemeral node>, nil", n, err)
+		}
+
+		neighbors, err := o.Neighbors(n)
+		if err != nil {
+			t.Fatalf("Neighbors() = _, %v, want = _, nil", err)
+		}
+		if len(neighbors) == 0 {
+			t.Errorf("Neighbors() = %v, want a non-empty list", neighbors)
+		}
+		for _, m := range neighbors {
+			if m.GetIsEphemeral() {
+				t.Errorf("Neighbors() = %v, want only non-ephemeral nodes", neighbors)
+			}
+			if _, err := o.D(n, m); err != nil {
+				t.Errorf("D() = _, %v, want = _, nil", err)
+			}
+
+			// Non-ephemeral neighbors should see the ephemeral node
+			// through the Overlay only.
+			found := false
+			ns, err := o.Neighbors(m)
+			if err != nil {
+				t.Fatalf("Neighbors() = _, %v, want = _, nil", err)
+			}
+			for _, l := range ns {
+				if utils.MC(l.GetTileCoordinate()) == c {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Neighbors() = %v, want to include %v", ns, c)
+			}
+		}
+	}
+
+	// The underlying Graph must not be mutated by the Overlay.
+	if diff := cmp.Diff(
+		abstractNodes(t, want),
+		abstractNodes(t, g),
+		protocmp.Transform(),
+		cmpopts.SortSlices(nodeLess),
+	); diff != "" {
+		t.Errorf("GetByCluster() mismatch (-want +got):\n%s", diff)
+	}
+	if !edgeMapEqual(*want.EdgeMap, *g.EdgeMap) {
+		t.Errorf("edgeMapEqual() = false, want = true")
+	}
+}
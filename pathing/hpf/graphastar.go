@@ -3,6 +3,7 @@
 package graphastar
 
 import (
+	"context"
 	"math"
 
 	"github.com/downflux/game/map/utils"
@@ -21,10 +22,10 @@ var (
 		codes.Unimplemented, "function not implemented")
 )
 
-// dFunc provides a shim for the graph.Graph neighbor distance
+// dFunc provides a shim for the graph.Overlay neighbor distance
 // function.
-func dFunc(g *graph.Graph, src, dest fastar.Node) float64 {
-	cost, err := graph.D(g, src.(*pdpb.AbstractNode), dest.(*pdpb.AbstractNode))
+func dFunc(o *graph.Overlay, src, dest fastar.Node) float64 {
+	cost, err := o.D(src.(*pdpb.AbstractNode), dest.(*pdpb.AbstractNode))
 	if err != nil {
 		return math.Inf(0)
 	}
@@ -42,11 +43,15 @@ func hFunc(src, dest fastar.Node) float64 {
 	return cost
 }
 
-// graphImpl implements fzipp.astar.Graph for the graph.Graph struct.
+// graphImpl implements fzipp.astar.Graph for the graph.Overlay struct.
 type graphImpl struct {
-	// g holds information on how different AbstractNode objects are
-	// connected via AbstractEdge links.
-	g *graph.Graph
+	// ctx interrupts the path search once done.
+	ctx context.Context
+
+	// o holds information on how different AbstractNode objects are
+	// connected via AbstractEdge links, including the ephemeral source
+	// and destination nodes of the query.
+	o *graph.Overlay
 
 	// src and dest are the query filters passed into Path; we cache these
 	// to pass auxiliary context to Neighbours.
@@ -56,11 +61,17 @@ type graphImpl struct {
 // Neighbours returns neighboring AbstractNode objects from a
 // graph.Graph.
 //
-// Neighbours filters out ephemeral AbstractNode objects, i.e. temporary
-// nodes generated by concurrent astar.Path operations which are not
-// the source or destination nodes.
+// Neighbours filters out ephemeral AbstractNode objects which are not
+// the source or destination nodes, e.g. nodes inserted directly into the
+// graph.Graph via graph.InsertEphemeralNode.
+//
+// No neighbors are returned once the search context is done, which ends the
+// search early.
 func (g graphImpl) Neighbours(n fastar.Node) []fastar.Node {
-	neighbors, _ := g.g.Neighbors(n.(*pdpb.AbstractNode))
+	if g.ctx.Err() != nil {
+		return nil
+	}
+	neighbors, _ := g.o.Neighbors(n.(*pdpb.AbstractNode))
 	var res []fastar.Node
 	for _, n := range neighbors {
 		if !n.GetIsEphemeral() || proto.Equal(g.src, n) || proto.Equal(g.dest, n) {
@@ -76,12 +87,17 @@ func (g graphImpl) Neighbours(n fastar.Node) []fastar.Node {
 // calculated by calling D over the returned path. An empty path indicates
 // there is no path found between the two AbstractNode objects.
 //
-// The input AbstractNode instances are query filters; they do not have to be
-// explicitly the same instances which reside in the Graph object.
+// The source and destination do not need to be AbstractNode instances in the
+// Graph; ephemeral AbstractNodes are created for the query as necessary in a
+// graph.Overlay, and the Graph itself is not mutated. Path may therefore be
+// called concurrently over the same Graph.
 //
 // The returned path object returns a reference to the internal AbstractNode
 // instances. They should be treated as read-only objects.
-func Path(tm *tile.Map, g *graph.Graph, src, dest utils.MapCoordinate) ([]*pdpb.AbstractNode, float64, error) {
+//
+// If the input context is done before the search completes, the context error
+// is returned.
+func Path(ctx context.Context, tm *tile.Map, g *graph.Graph, src, dest utils.MapCoordinate) ([]*pdpb.AbstractNode, float64, error) {
 	if tm == nil {
 		return nil, 0, status.Error(codes.FailedPrecondition, "cannot have nil tile.Map input")
 	}
@@ -89,11 +105,16 @@ func Path(tm *tile.Map, g *graph.Graph, src, dest utils.MapCoordinate) ([]*pdpb.
 		return nil, 0, status.Error(codes.FailedPrecondition, "cannot have nil graph.Graph input")
 	}
 
-	srcNode, err := g.NodeMap.Get(src)
+	o, err := graph.NewOverlay(tm, g, src, dest)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	srcNode, err := o.Get(src)
 	if err != nil {
 		return nil, 0, err
 	}
-	destNode, err := g.NodeMap.Get(dest)
+	destNode, err := o.Get(dest)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -102,13 +123,17 @@ func Path(tm *tile.Map, g *graph.Graph, src, dest utils.MapCoordinate) ([]*pdpb.
 	}
 
 	d := func(a, b fastar.Node) float64 {
-		return dFunc(g, a, b)
+		return dFunc(o, a, b)
 	}
 	nodes := fastar.FindPath(graphImpl{
-		g:    g,
+		ctx:  ctx,
+		o:    o,
 		src:  proto.Clone(srcNode).(*pdpb.AbstractNode),
 		dest: proto.Clone(destNode).(*pdpb.AbstractNode),
 	}, srcNode, destNode, d, hFunc)
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
 
 	var res []*pdpb.AbstractNode
 	for _, node := range nodes {
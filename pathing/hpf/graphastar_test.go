@@ -1,6 +1,7 @@
 package graphastar
 
 import (
+	"context"
 	"math"
 	"testing"
 
@@ -240,7 +241,7 @@ func TestPath(t *testing.T) {
 				t.Fatalf("BuildGraph() = _, %v, want = _, nil", err)
 			}
 
-			nodes, cost, err := Path(tm, g, c.src, c.dest)
+			nodes, cost, err := Path(context.Background(), tm, g, c.src, c.dest)
 			if err != nil {
 				t.Fatalf("Path() = %v, want = nil", err)
 			}
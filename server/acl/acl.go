@@ -0,0 +1,152 @@
+// Package acl implements shared control of units between players.
+//
+// Each unit is owned by a single client, and may by default only be commanded
+// by the owning client. Clients may grant control over all of their units to
+// other (e.g. allied) clients.
+//
+// Grants take effect at a specific tick, so that commands which are applied
+// retroactively (e.g. on rollback) are checked against the grants in effect at
+// the tick at which the command was issued.
+package acl
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/downflux/game/engine/id/id"
+)
+
+// change records a grant or revocation of control at a specific tick.
+type change struct {
+	tick    id.Tick
+	granted bool
+}
+
+// Record is an exported change of control, e.g. for saving the ACL into a
+// game snapshot.
+type Record struct {
+	Owner   id.ClientID
+	Grantee id.ClientID
+	Tick    id.Tick
+	Granted bool
+}
+
+// ACL tracks the list of clients which have been granted control over the
+// units of each client.
+type ACL struct {
+	// mux guards the grants property.
+	mux sync.RWMutex
+
+	// grants is the history of grants from a client to each other client,
+	// hashed by the owning client UUID. Each history is sorted by tick.
+	grants map[id.ClientID]map[id.ClientID][]change
+}
+
+// New constructs a new ACL instance.
+func New() *ACL {
+	return &ACL{
+		grants: map[id.ClientID]map[id.ClientID][]change{},
+	}
+}
+
+// Grant allows the grantee to command all units of the owner from the input
+// tick onwards.
+func (a *ACL) Grant(owner id.ClientID, grantee id.ClientID, tick id.Tick) {
+	a.set(owner, grantee, tick, true)
+}
+
+// Revoke removes a previous grant from the owner to the grantee from the
+// input tick onwards.
+func (a *ACL) Revoke(owner id.ClientID, grantee id.ClientID, tick id.Tick) {
+	a.set(owner, grantee, tick, false)
+}
+
+// set records a change of control at the input tick. A later change at the
+// same tick replaces the earlier one.
+func (a *ACL) set(owner id.ClientID, grantee id.ClientID, tick id.Tick, granted bool) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	if _, found := a.grants[owner]; !found {
+		a.grants[owner] = map[id.ClientID][]change{}
+	}
+	h := a.grants[owner][grantee]
+
+	i := sort.Search(len(h), func(i int) bool { return h[i].tick >= tick })
+	if i < len(h) && h[i].tick == tick {
+		h[i].granted = granted
+		return
+	}
+	h = append(h, change{})
+	copy(h[i+1:], h[i:])
+	h[i] = change{tick: tick, granted: granted}
+	a.grants[owner][grantee] = h
+}
+
+// Export returns the full history of grants and revocations. Replaying the
+// records in order via Grant and Revoke reconstructs the ACL.
+func (a *ACL) Export() []Record {
+	a.mux.RLock()
+	defer a.mux.RUnlock()
+
+	var records []Record
+	for owner, grantees := range a.grants {
+		for grantee, h := range grantees {
+			for _, c := range h {
+				records = append(records, Record{
+					Owner:   owner,
+					Grantee: grantee,
+					Tick:    c.tick,
+					Granted: c.granted,
+				})
+			}
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Owner != records[j].Owner {
+			return records[i].Owner < records[j].Owner
+		}
+		if records[i].Grantee != records[j].Grantee {
+			return records[i].Grantee < records[j].Grantee
+		}
+		return records[i].Tick < records[j].Tick
+	})
+	return records
+}
+
+// Grantees returns the list of clients which may currently command the units
+// of the owner, excluding the owner itself. Grants which only take effect at
+// a future tick are included.
+func (a *ACL) Grantees(owner id.ClientID) []id.ClientID {
+	a.mux.RLock()
+	defer a.mux.RUnlock()
+
+	var grantees []id.ClientID
+	for cid, h := range a.grants[owner] {
+		if len(h) > 0 && h[len(h)-1].granted {
+			grantees = append(grantees, cid)
+		}
+	}
+	return grantees
+}
+
+// Allowed checks if the input client may command units owned by the owner at
+// the input tick.
+//
+// Units which are not owned by any client (e.g. debug units) may not be
+// commanded by any client.
+func (a *ACL) Allowed(owner id.ClientID, cid id.ClientID, tick id.Tick) bool {
+	if owner == "" {
+		return false
+	}
+	if owner == cid {
+		return true
+	}
+
+	a.mux.RLock()
+	defer a.mux.RUnlock()
+
+	h := a.grants[owner][cid]
+	i := sort.Search(len(h), func(i int) bool { return h[i].tick > tick })
+	return i > 0 && h[i-1].granted
+}
@@ -0,0 +1,120 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/downflux/game/engine/id/id"
+)
+
+func TestAllowed(t *testing.T) {
+	owner := id.ClientID("owner")
+	ally := id.ClientID("ally")
+	enemy := id.ClientID("enemy")
+
+	a := New()
+	a.Grant(owner, ally, 10)
+
+	testConfigs := []struct {
+		name  string
+		owner id.ClientID
+		cid   id.ClientID
+		tick  id.Tick
+		want  bool
+	}{
+		{name: "Owner", owner: owner, cid: owner, tick: 10, want: true},
+		{name: "Ally", owner: owner, cid: ally, tick: 10, want: true},
+		{name: "AllyLater", owner: owner, cid: ally, tick: 20, want: true},
+		{name: "AllyBeforeGrant", owner: owner, cid: ally, tick: 5, want: false},
+		{name: "Enemy", owner: owner, cid: enemy, tick: 10, want: false},
+		{name: "NoGrantBack", owner: ally, cid: owner, tick: 10, want: false},
+		{name: "Unowned", owner: "", cid: enemy, tick: 10, want: false},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if got := a.Allowed(c.owner, c.cid, c.tick); got != c.want {
+				t.Errorf("Allowed() = %v, want = %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	owner := id.ClientID("owner")
+	ally := id.ClientID("ally")
+
+	a := New()
+	a.Grant(owner, ally, 10)
+	a.Revoke(owner, ally, 20)
+
+	testConfigs := []struct {
+		name string
+		tick id.Tick
+		want bool
+	}{
+		{name: "BeforeGrant", tick: 5, want: false},
+		{name: "Granted", tick: 15, want: true},
+		{name: "Revoked", tick: 20, want: false},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if got := a.Allowed(owner, ally, c.tick); got != c.want {
+				t.Errorf("Allowed() = %v, want = %v", got, c.want)
+			}
+		})
+	}
+
+	if got := len(a.Grantees(owner)); got != 0 {
+		t.Errorf("len() = %v, want = %v", got, 0)
+	}
+}
+
+func TestGrantOutOfOrder(t *testing.T) {
+	owner := id.ClientID("owner")
+	ally := id.ClientID("ally")
+
+	a := New()
+	a.Revoke(owner, ally, 20)
+	a.Grant(owner, ally, 10)
+
+	if !a.Allowed(owner, ally, 15) {
+		t.Errorf("Allowed() = %v, want = %v", false, true)
+	}
+	if a.Allowed(owner, ally, 20) {
+		t.Errorf("Allowed() = %v, want = %v", true, false)
+	}
+	if got := len(a.Grantees(owner)); got != 0 {
+		t.Errorf("len() = %v, want = %v", got, 0)
+	}
+}
+
+func TestExport(t *testing.T) {
+	owner := id.ClientID("owner")
+	ally := id.ClientID("ally")
+
+	a := New()
+	a.Grant(owner, ally, 10)
+	a.Revoke(owner, ally, 20)
+	a.Grant(ally, owner, 15)
+
+	b := New()
+	for _, r := range a.Export() {
+		if r.Granted {
+			b.Grant(r.Owner, r.Grantee, r.Tick)
+		} else {
+			b.Revoke(r.Owner, r.Grantee, r.Tick)
+		}
+	}
+
+	for _, c := range []struct {
+		owner id.ClientID
+		cid   id.ClientID
+	}{{owner: owner, cid: ally}, {owner: ally, cid: owner}} {
+		for _, tick := range []id.Tick{5, 10, 15, 20, 25} {
+			if got, want := b.Allowed(c.owner, c.cid, tick), a.Allowed(c.owner, c.cid, tick); got != want {
+				t.Errorf("Allowed(%v, %v, %v) = %v, want = %v", c.owner, c.cid, tick, got, want)
+			}
+		}
+	}
+}
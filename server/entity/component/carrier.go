@@ -0,0 +1,37 @@
+package carrier
+
+import (
+	"github.com/downflux/game/engine/curve/common/delta"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/component/moveable"
+)
+
+// Component is implemented by harvesters, which carry resources collected
+// from resource fields.
+type Component interface {
+	moveable.Component
+
+	CarryCapacity() float64
+	CarryRate() float64
+	Cargo(t id.Tick) float64
+	CargoCurve() *delta.Curve
+}
+
+type Base struct {
+	capacity float64
+	rate     float64
+	cargo    *delta.Curve
+}
+
+func New(capacity float64, rate float64, cargo *delta.Curve) *Base {
+	return &Base{
+		capacity: capacity,
+		rate:     rate,
+		cargo:    cargo,
+	}
+}
+
+func (c Base) CarryCapacity() float64   { return c.capacity }
+func (c Base) CarryRate() float64       { return c.rate }
+func (c Base) Cargo(t id.Tick) float64  { return c.cargo.Get(t).(float64) }
+func (c Base) CargoCurve() *delta.Curve { return c.cargo }
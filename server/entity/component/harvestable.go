@@ -0,0 +1,30 @@
+package harvestable
+
+import (
+	"github.com/downflux/game/engine/curve/common/delta"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/component/positionable"
+)
+
+// Component is implemented by resource fields, from which harvesters may
+// collect resources.
+type Component interface {
+	positionable.Component
+
+	ID() id.EntityID
+	Resources(t id.Tick) float64
+	ResourcesCurve() *delta.Curve
+}
+
+type Base struct {
+	resources *delta.Curve
+}
+
+func New(r *delta.Curve) *Base {
+	return &Base{
+		resources: r,
+	}
+}
+
+func (c *Base) Resources(t id.Tick) float64  { return c.resources.Get(t).(float64) }
+func (c *Base) ResourcesCurve() *delta.Curve { return c.resources }
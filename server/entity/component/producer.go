@@ -0,0 +1,44 @@
+package producer
+
+import (
+	"github.com/downflux/game/engine/curve/common/step"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/component/positionable"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+)
+
+// Component is implemented by static buildings which own a production queue,
+// e.g. factories.
+type Component interface {
+	positionable.Component
+
+	ID() id.EntityID
+	CanProduce(t gcpb.EntityType) bool
+
+	// RallyPoint returns the position to which newly produced entities
+	// will move after being built.
+	RallyPoint(t id.Tick) *gdpb.Position
+	RallyPointCurve() *step.Curve
+}
+
+type Base struct {
+	produces   map[gcpb.EntityType]bool
+	rallyPoint *step.Curve
+}
+
+func New(produces []gcpb.EntityType, rallyPoint *step.Curve) *Base {
+	m := map[gcpb.EntityType]bool{}
+	for _, t := range produces {
+		m[t] = true
+	}
+	return &Base{
+		produces:   m,
+		rallyPoint: rallyPoint,
+	}
+}
+
+func (c *Base) CanProduce(t gcpb.EntityType) bool   { return c.produces[t] }
+func (c *Base) RallyPoint(t id.Tick) *gdpb.Position { return c.rallyPoint.Get(t).(*gdpb.Position) }
+func (c *Base) RallyPointCurve() *step.Curve        { return c.rallyPoint }
@@ -0,0 +1,55 @@
+package structure
+
+import (
+	"math"
+
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/component/positionable"
+
+	gdpb "github.com/downflux/game/api/data_go_proto"
+)
+
+// Component is implemented by static buildings which block the map tiles
+// they occupy, e.g. factories and refineries.
+type Component interface {
+	positionable.Component
+
+	ID() id.EntityID
+
+	// Footprint returns the size, in tiles, of the rectangle of tiles
+	// occupied by the building. The position of the building is the
+	// lower-left corner of the rectangle.
+	Footprint() *gdpb.Coordinate
+}
+
+type Base struct {
+	footprint *gdpb.Coordinate
+}
+
+// New constructs a new Base instance. Unset footprint dimensions default to
+// a single tile.
+func New(footprint *gdpb.Coordinate) *Base {
+	d := &gdpb.Coordinate{X: footprint.GetX(), Y: footprint.GetY()}
+	if d.GetX() <= 0 {
+		d.X = 1
+	}
+	if d.GetY() <= 0 {
+		d.Y = 1
+	}
+	return &Base{
+		footprint: d,
+	}
+}
+
+func (c Base) Footprint() *gdpb.Coordinate { return c.footprint }
+
+// Distance returns the Euclidean distance between the input position and the
+// closest tile occupied by the input structure.
+func Distance(s Component, t id.Tick, p *gdpb.Position) float64 {
+	q := s.Position(t)
+	d := s.Footprint()
+
+	dx := math.Max(0, math.Max(q.GetX()-p.GetX(), p.GetX()-(q.GetX()+float64(d.GetX()-1))))
+	dy := math.Max(0, math.Max(q.GetY()-p.GetY(), p.GetY()-(q.GetY()+float64(d.GetY()-1))))
+	return math.Hypot(dx, dy)
+}
@@ -0,0 +1,95 @@
+// Package factory encapsulates logic for factories, which are static buildings
+// that own a production queue.
+package factory
+
+import (
+	"reflect"
+
+	"github.com/downflux/game/engine/curve/common/delta"
+	"github.com/downflux/game/engine/curve/common/linearmove"
+	"github.com/downflux/game/engine/curve/common/step"
+	"github.com/downflux/game/engine/curve/curve"
+	"github.com/downflux/game/engine/curve/list"
+	"github.com/downflux/game/engine/entity/component/lifecycle"
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/component/positionable"
+	"github.com/downflux/game/server/entity/component/producer"
+	"github.com/downflux/game/server/entity/component/structure"
+	"github.com/downflux/game/server/entity/component/targetable"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	curvecomponent "github.com/downflux/game/engine/entity/component/curve"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+type (
+	targetComponent    = targetable.Base
+	positionComponent  = positionable.Base
+	structureComponent = structure.Base
+	producerComponent  = producer.Base
+	lifecycleComponent = lifecycle.Component
+	curveComponent     = curvecomponent.Component
+)
+
+// Entity implements the entity.Entity interface and represents a factory.
+type Entity struct {
+	entity.Base
+	targetComponent
+	positionComponent
+	structureComponent
+	producerComponent
+	lifecycleComponent
+	curveComponent
+}
+
+// New constructs a new instance of the factory described by the input
+// definition. The rally point of the factory defaults to the factory position.
+func New(
+	pb *edpb.FactoryDefinition,
+	eid id.EntityID,
+	t id.Tick,
+	pos *gdpb.Position,
+	cid id.ClientID) (*Entity, error) {
+	mc := linearmove.New(eid, t)
+	mc.Add(t, pos)
+
+	cidc := step.New(
+		eid,
+		t,
+		gcpb.EntityProperty_ENTITY_PROPERTY_CLIENT_ID,
+		reflect.TypeOf(id.ClientID("")),
+	)
+	cidc.Add(t, cid)
+
+	rc := step.New(
+		eid,
+		t,
+		gcpb.EntityProperty_ENTITY_PROPERTY_RALLY_POINT,
+		reflect.TypeOf(&gdpb.Position{}),
+	)
+	rc.Add(t, pos)
+
+	hp := delta.New(step.New(eid, t, gcpb.EntityProperty_ENTITY_PROPERTY_HEALTH, reflect.TypeOf(float64(0))))
+	if err := hp.Add(t, pb.GetHealth()); err != nil {
+		return nil, err
+	}
+
+	curves, err := list.New([]curve.Curve{mc, hp, cidc, rc})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Entity{
+		Base: *entity.New(
+			pb.GetEntityType(), eid, cidc),
+
+		targetComponent:    *targetable.New(hp),
+		positionComponent:  *positionable.New(mc),
+		structureComponent: *structure.New(pb.GetFootprint()),
+		producerComponent:  *producer.New(pb.GetProduces(), rc),
+		lifecycleComponent: *lifecycle.New(t),
+		curveComponent:     *curvecomponent.New(curves),
+	}, nil
+}
@@ -0,0 +1,17 @@
+package factory
+
+import (
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/server/entity/component/positionable"
+	"github.com/downflux/game/server/entity/component/producer"
+	"github.com/downflux/game/server/entity/component/structure"
+	"github.com/downflux/game/server/entity/component/targetable"
+)
+
+var (
+	_ entity.Entity          = &Entity{}
+	_ targetable.Component   = &Entity{}
+	_ positionable.Component = &Entity{}
+	_ structure.Component    = &Entity{}
+	_ producer.Component     = &Entity{}
+)
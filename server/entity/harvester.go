@@ -0,0 +1,92 @@
+// Package harvester encapsulates logic for harvester units, which collect
+// resources from resource fields and unload them at a refinery.
+package harvester
+
+import (
+	"reflect"
+
+	"github.com/downflux/game/engine/curve/common/delta"
+	"github.com/downflux/game/engine/curve/common/linearmove"
+	"github.com/downflux/game/engine/curve/common/step"
+	"github.com/downflux/game/engine/curve/curve"
+	"github.com/downflux/game/engine/curve/list"
+	"github.com/downflux/game/engine/entity/component/lifecycle"
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/component/carrier"
+	"github.com/downflux/game/server/entity/component/moveable"
+	"github.com/downflux/game/server/entity/component/positionable"
+	"github.com/downflux/game/server/entity/component/targetable"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	curvecomponent "github.com/downflux/game/engine/entity/component/curve"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+type (
+	moveComponent      = moveable.Base
+	carryComponent     = carrier.Base
+	targetComponent    = targetable.Base
+	positionComponent  = positionable.Base
+	lifecycleComponent = lifecycle.Component
+	curveComponent     = curvecomponent.Component
+)
+
+// Entity implements the entity.Entity interface and represents a harvester.
+type Entity struct {
+	entity.Base
+	moveComponent
+	carryComponent
+	targetComponent
+	positionComponent
+	lifecycleComponent
+	curveComponent
+}
+
+// New constructs a new instance of the harvester described by the input
+// definition. The harvester starts with no cargo.
+func New(
+	pb *edpb.HarvesterDefinition,
+	eid id.EntityID,
+	t id.Tick,
+	pos *gdpb.Position,
+	cid id.ClientID) (*Entity, error) {
+	mc := linearmove.New(eid, t)
+	mc.Add(t, pos)
+
+	cidc := step.New(
+		eid,
+		t,
+		gcpb.EntityProperty_ENTITY_PROPERTY_CLIENT_ID,
+		reflect.TypeOf(id.ClientID("")),
+	)
+	cidc.Add(t, cid)
+
+	hp := delta.New(step.New(eid, t, gcpb.EntityProperty_ENTITY_PROPERTY_HEALTH, reflect.TypeOf(float64(0))))
+	if err := hp.Add(t, pb.GetHealth()); err != nil {
+		return nil, err
+	}
+
+	cargo := delta.New(step.New(eid, t, gcpb.EntityProperty_ENTITY_PROPERTY_CARGO, reflect.TypeOf(float64(0))))
+	if err := cargo.Add(t, float64(0)); err != nil {
+		return nil, err
+	}
+
+	curves, err := list.New([]curve.Curve{mc, hp, cidc, cargo})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Entity{
+		Base: *entity.New(
+			pb.GetEntityType(), eid, cidc),
+
+		moveComponent:      *moveable.New(pb.GetMoveVelocity()),
+		carryComponent:     *carrier.New(pb.GetCapacity(), pb.GetHarvestRate(), cargo),
+		targetComponent:    *targetable.New(hp),
+		positionComponent:  *positionable.New(mc),
+		lifecycleComponent: *lifecycle.New(t),
+		curveComponent:     *curvecomponent.New(curves),
+	}, nil
+}
@@ -0,0 +1,17 @@
+package harvester
+
+import (
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/server/entity/component/carrier"
+	"github.com/downflux/game/server/entity/component/moveable"
+	"github.com/downflux/game/server/entity/component/positionable"
+	"github.com/downflux/game/server/entity/component/targetable"
+)
+
+var (
+	_ entity.Entity          = &Entity{}
+	_ moveable.Component     = &Entity{}
+	_ carrier.Component      = &Entity{}
+	_ targetable.Component   = &Entity{}
+	_ positionable.Component = &Entity{}
+)
@@ -0,0 +1,72 @@
+// Package player encapsulates the economy of a single client, e.g. the
+// credits available to the client for producing new entities.
+package player
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/downflux/game/engine/curve/common/delta"
+	"github.com/downflux/game/engine/curve/common/step"
+	"github.com/downflux/game/engine/curve/curve"
+	"github.com/downflux/game/engine/curve/list"
+	"github.com/downflux/game/engine/entity/component/lifecycle"
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/id/id"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	curvecomponent "github.com/downflux/game/engine/entity/component/curve"
+)
+
+type (
+	lifecycleComponent = lifecycle.Component
+	curveComponent     = curvecomponent.Component
+)
+
+// Entity implements the entity.Entity interface and tracks the credits of a
+// single client. The credits curve is broadcast to clients as with any other
+// entity curve.
+type Entity struct {
+	entity.Base
+	lifecycleComponent
+	curveComponent
+
+	credits *delta.Curve
+}
+
+// ID returns the UUID of the player entity of the input client. Each client
+// has at most one player entity.
+func ID(cid id.ClientID) id.EntityID { return id.EntityID(fmt.Sprintf("player-%v", cid)) }
+
+// New constructs a new player entity for the input client, with the input
+// number of credits.
+func New(eid id.EntityID, t id.Tick, cid id.ClientID, credits float64) (*Entity, error) {
+	cidc := step.New(
+		eid,
+		t,
+		gcpb.EntityProperty_ENTITY_PROPERTY_CLIENT_ID,
+		reflect.TypeOf(id.ClientID("")),
+	)
+	cidc.Add(t, cid)
+
+	cc := delta.New(step.New(eid, t, gcpb.EntityProperty_ENTITY_PROPERTY_CREDITS, reflect.TypeOf(float64(0))))
+	if err := cc.Add(t, credits); err != nil {
+		return nil, err
+	}
+
+	curves, err := list.New([]curve.Curve{cidc, cc})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Entity{
+		Base: *entity.New(
+			gcpb.EntityType_ENTITY_TYPE_PLAYER, eid, cidc),
+		lifecycleComponent: *lifecycle.New(t),
+		curveComponent:     *curvecomponent.New(curves),
+		credits:            cc,
+	}, nil
+}
+
+func (e *Entity) Credits(t id.Tick) float64  { return e.credits.Get(t).(float64) }
+func (e *Entity) CreditsCurve() *delta.Curve { return e.credits }
@@ -0,0 +1,9 @@
+package player
+
+import (
+	"github.com/downflux/game/engine/entity/entity"
+)
+
+var (
+	_ entity.Entity = &Entity{}
+)
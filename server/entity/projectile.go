@@ -16,11 +16,7 @@ import (
 	gcpb "github.com/downflux/game/api/constants_go_proto"
 	gdpb "github.com/downflux/game/api/data_go_proto"
 	curvecomponent "github.com/downflux/game/engine/entity/component/curve"
-)
-
-const (
-	// moveVelocity is measured in tiles per second.
-	moveVelocity = 20
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
 )
 
 type (
@@ -38,7 +34,9 @@ type Entity struct {
 	positionComponent
 }
 
-func New(eid id.EntityID, t id.Tick, pos *gdpb.Position, cid id.ClientID) (*Entity, error) {
+// New constructs a new instance of the projectile described by the input
+// definition.
+func New(pb *edpb.ProjectileDefinition, eid id.EntityID, t id.Tick, pos *gdpb.Position, cid id.ClientID) (*Entity, error) {
 	mc := linearmove.New(eid, t)
 	mc.Add(t, pos)
 
@@ -57,9 +55,10 @@ func New(eid id.EntityID, t id.Tick, pos *gdpb.Position, cid id.ClientID) (*Enti
 
 	return &Entity{
 		Base: *entity.New(
-			gcpb.EntityType_ENTITY_TYPE_TANK_PROJECTILE, eid, cidc),
-		moveComponent:     *moveable.New(moveVelocity),
-		positionComponent: *positionable.New(mc),
-		curveComponent:    *curvecomponent.New(curves),
+			pb.GetEntityType(), eid, cidc),
+		moveComponent:      *moveable.New(pb.GetMoveVelocity()),
+		positionComponent:  *positionable.New(mc),
+		lifecycleComponent: *lifecycle.New(t),
+		curveComponent:     *curvecomponent.New(curves),
 	}, nil
 }
@@ -0,0 +1,214 @@
+// Package registry tracks the unit archetypes which may be spawned in a game.
+//
+// Example
+//
+//  r, err := registry.New(pb)
+//  t, err := tank.New(r.Unit(gcpb.EntityType_ENTITY_TYPE_TANK), ...)
+package registry
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+// Registry is a read-only lookup table of unit, projectile, harvester,
+// refinery, and factory definitions, keyed by EntityType.
+type Registry struct {
+	units       map[gcpb.EntityType]*edpb.UnitDefinition       // Read-only.
+	projectiles map[gcpb.EntityType]*edpb.ProjectileDefinition // Read-only.
+	harvesters  map[gcpb.EntityType]*edpb.HarvesterDefinition  // Read-only.
+	refineries  map[gcpb.EntityType]*edpb.RefineryDefinition   // Read-only.
+	factories   map[gcpb.EntityType]*edpb.FactoryDefinition    // Read-only.
+
+	startingCredits float64 // Read-only.
+}
+
+// New constructs a new Registry instance from the input definitions. Each
+// EntityType may only be defined once, and all projectiles fired by a unit and
+// all entities produced by a factory must also be defined.
+func New(pb *edpb.Registry) (*Registry, error) {
+	r := &Registry{
+		units:       map[gcpb.EntityType]*edpb.UnitDefinition{},
+		projectiles: map[gcpb.EntityType]*edpb.ProjectileDefinition{},
+		harvesters:  map[gcpb.EntityType]*edpb.HarvesterDefinition{},
+		refineries:  map[gcpb.EntityType]*edpb.RefineryDefinition{},
+		factories:   map[gcpb.EntityType]*edpb.FactoryDefinition{},
+
+		startingCredits: pb.GetStartingCredits(),
+	}
+	if r.startingCredits < 0 {
+		return nil, status.Error(codes.InvalidArgument, "starting credits must be non-negative")
+	}
+
+	defined := map[gcpb.EntityType]bool{}
+	define := func(t gcpb.EntityType) error {
+		if t == gcpb.EntityType_ENTITY_TYPE_UNKNOWN {
+			return status.Error(codes.InvalidArgument, "an entity type must be specified")
+		}
+		if defined[t] {
+			return status.Errorf(codes.AlreadyExists, "entity type %v is defined more than once", t)
+		}
+		defined[t] = true
+		return nil
+	}
+
+	for _, p := range pb.GetProjectiles() {
+		if err := define(p.GetEntityType()); err != nil {
+			return nil, err
+		}
+		if p.GetMoveVelocity() <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "projectile %v must have a positive velocity", p.GetEntityType())
+		}
+		r.projectiles[p.GetEntityType()] = p
+	}
+
+	for _, u := range pb.GetUnits() {
+		if err := define(u.GetEntityType()); err != nil {
+			return nil, err
+		}
+		if u.GetHealth() <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "unit %v must have positive health", u.GetEntityType())
+		}
+		if u.GetAttack() == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unit %v must define an attack", u.GetEntityType())
+		}
+		if _, found := r.projectiles[u.GetAttack().GetProjectileType()]; !found {
+			return nil, status.Errorf(codes.NotFound, "unit %v fires undefined projectile %v", u.GetEntityType(), u.GetAttack().GetProjectileType())
+		}
+		if u.GetCost() < 0 || u.GetBuildTicks() < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "unit %v must have a non-negative cost and build time", u.GetEntityType())
+		}
+		r.units[u.GetEntityType()] = u
+	}
+
+	for _, h := range pb.GetHarvesters() {
+		if err := define(h.GetEntityType()); err != nil {
+			return nil, err
+		}
+		if h.GetHealth() <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "harvester %v must have positive health", h.GetEntityType())
+		}
+		if h.GetCapacity() <= 0 || h.GetHarvestRate() <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "harvester %v must have a positive capacity and harvest rate", h.GetEntityType())
+		}
+		if h.GetCost() < 0 || h.GetBuildTicks() < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "harvester %v must have a non-negative cost and build time", h.GetEntityType())
+		}
+		r.harvesters[h.GetEntityType()] = h
+	}
+
+	for _, f := range pb.GetRefineries() {
+		if err := define(f.GetEntityType()); err != nil {
+			return nil, err
+		}
+		if f.GetHealth() <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "refinery %v must have positive health", f.GetEntityType())
+		}
+		if f.GetCost() < 0 || f.GetBuildTicks() < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "refinery %v must have a non-negative cost and build time", f.GetEntityType())
+		}
+		if f.GetFootprint().GetX() < 0 || f.GetFootprint().GetY() < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "refinery %v must have a non-negative footprint", f.GetEntityType())
+		}
+		r.refineries[f.GetEntityType()] = f
+	}
+
+	for _, f := range pb.GetFactories() {
+		if err := define(f.GetEntityType()); err != nil {
+			return nil, err
+		}
+		if f.GetHealth() <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "factory %v must have positive health", f.GetEntityType())
+		}
+		if f.GetCost() < 0 || f.GetBuildTicks() < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "factory %v must have a non-negative cost and build time", f.GetEntityType())
+		}
+		if f.GetFootprint().GetX() < 0 || f.GetFootprint().GetY() < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "factory %v must have a non-negative footprint", f.GetEntityType())
+		}
+		r.factories[f.GetEntityType()] = f
+	}
+
+	// Factories may only produce entities which are defined after all
+	// definitions have been loaded.
+	for _, f := range pb.GetFactories() {
+		for _, t := range f.GetProduces() {
+			if _, ok := r.Cost(t); !ok {
+				return nil, status.Errorf(codes.NotFound, "factory %v produces undefined entity %v", f.GetEntityType(), t)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// Unit returns the definition of the input unit type, or nil if the type is
+// not a registered unit.
+func (r *Registry) Unit(t gcpb.EntityType) *edpb.UnitDefinition { return r.units[t] }
+
+// Projectile returns the definition of the input projectile type, or nil if
+// the type is not a registered projectile.
+func (r *Registry) Projectile(t gcpb.EntityType) *edpb.ProjectileDefinition {
+	return r.projectiles[t]
+}
+
+// Harvester returns the definition of the input harvester type, or nil if the
+// type is not a registered harvester.
+func (r *Registry) Harvester(t gcpb.EntityType) *edpb.HarvesterDefinition {
+	return r.harvesters[t]
+}
+
+// Refinery returns the definition of the input refinery type, or nil if the
+// type is not a registered refinery.
+func (r *Registry) Refinery(t gcpb.EntityType) *edpb.RefineryDefinition {
+	return r.refineries[t]
+}
+
+// Factory returns the definition of the input factory type, or nil if the type
+// is not a registered factory.
+func (r *Registry) Factory(t gcpb.EntityType) *edpb.FactoryDefinition {
+	return r.factories[t]
+}
+
+// Cost returns the number of credits charged for producing an entity of the
+// input type. Cost returns false if the type may not be produced.
+func (r *Registry) Cost(t gcpb.EntityType) (float64, bool) {
+	if u := r.Unit(t); u != nil {
+		return u.GetCost(), true
+	}
+	if h := r.Harvester(t); h != nil {
+		return h.GetCost(), true
+	}
+	if f := r.Refinery(t); f != nil {
+		return f.GetCost(), true
+	}
+	if f := r.Factory(t); f != nil {
+		return f.GetCost(), true
+	}
+	return 0, false
+}
+
+// BuildTicks returns the number of ticks a factory takes to produce an entity
+// of the input type. BuildTicks returns false if the type may not be produced.
+func (r *Registry) BuildTicks(t gcpb.EntityType) (float64, bool) {
+	if u := r.Unit(t); u != nil {
+		return u.GetBuildTicks(), true
+	}
+	if h := r.Harvester(t); h != nil {
+		return h.GetBuildTicks(), true
+	}
+	if f := r.Refinery(t); f != nil {
+		return f.GetBuildTicks(), true
+	}
+	if f := r.Factory(t); f != nil {
+		return f.GetBuildTicks(), true
+	}
+	return 0, false
+}
+
+// StartingCredits returns the number of credits granted to each client at the
+// start of the game.
+func (r *Registry) StartingCredits() float64 { return r.startingCredits }
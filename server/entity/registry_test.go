@@ -0,0 +1,180 @@
+package registry
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+var (
+	projectilePB = &edpb.ProjectileDefinition{
+		EntityType:   gcpb.EntityType_ENTITY_TYPE_TANK_PROJECTILE,
+		MoveVelocity: 20,
+	}
+	unitPB = &edpb.UnitDefinition{
+		EntityType:   gcpb.EntityType_ENTITY_TYPE_TANK,
+		MoveVelocity: 2,
+		Health:       100,
+		Attack: &edpb.AttackDefinition{
+			Strength:       2,
+			Range:          2,
+			Velocity:       10,
+			Cooloff:        10,
+			ProjectileType: gcpb.EntityType_ENTITY_TYPE_TANK_PROJECTILE,
+		},
+	}
+)
+
+func TestNew(t *testing.T) {
+	testConfigs := []struct {
+		name string
+		pb   *edpb.Registry
+		want codes.Code
+	}{
+		{
+			name: "Valid",
+			pb: &edpb.Registry{
+				Units:       []*edpb.UnitDefinition{unitPB},
+				Projectiles: []*edpb.ProjectileDefinition{projectilePB},
+			},
+			want: codes.OK,
+		},
+		{
+			name: "Duplicate",
+			pb: &edpb.Registry{
+				Projectiles: []*edpb.ProjectileDefinition{projectilePB, projectilePB},
+			},
+			want: codes.AlreadyExists,
+		},
+		{
+			name: "MissingProjectile",
+			pb: &edpb.Registry{
+				Units: []*edpb.UnitDefinition{unitPB},
+			},
+			want: codes.NotFound,
+		},
+		{
+			name: "InvalidHarvester",
+			pb: &edpb.Registry{
+				Harvesters: []*edpb.HarvesterDefinition{{
+					EntityType: gcpb.EntityType_ENTITY_TYPE_HARVESTER,
+					Health:     100,
+				}},
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "UndefinedFactoryProduct",
+			pb: &edpb.Registry{
+				Factories: []*edpb.FactoryDefinition{{
+					EntityType: gcpb.EntityType_ENTITY_TYPE_FACTORY,
+					Health:     100,
+					Produces:   []gcpb.EntityType{gcpb.EntityType_ENTITY_TYPE_TANK},
+				}},
+			},
+			want: codes.NotFound,
+		},
+		{
+			name: "NegativeFootprint",
+			pb: &edpb.Registry{
+				Refineries: []*edpb.RefineryDefinition{{
+					EntityType: gcpb.EntityType_ENTITY_TYPE_REFINERY,
+					Health:     100,
+					Footprint:  &gdpb.Coordinate{X: -1, Y: 1},
+				}},
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "NegativeStartingCredits",
+			pb: &edpb.Registry{
+				StartingCredits: -1,
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "MissingAttack",
+			pb: &edpb.Registry{
+				Units: []*edpb.UnitDefinition{{
+					EntityType: gcpb.EntityType_ENTITY_TYPE_TANK,
+					Health:     100,
+				}},
+			},
+			want: codes.InvalidArgument,
+		},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := New(c.pb); status.Code(err) != c.want {
+				t.Errorf("New() = _, %v, want = %v", err, c.want)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	r, err := New(&edpb.Registry{
+		Units:       []*edpb.UnitDefinition{unitPB},
+		Projectiles: []*edpb.ProjectileDefinition{projectilePB},
+	})
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+
+	if got := r.Unit(gcpb.EntityType_ENTITY_TYPE_TANK); got != unitPB {
+		t.Errorf("Unit() = %v, want = %v", got, unitPB)
+	}
+	if got := r.Unit(gcpb.EntityType_ENTITY_TYPE_TANK_PROJECTILE); got != nil {
+		t.Errorf("Unit() = %v, want = nil", got)
+	}
+	if got := r.Projectile(gcpb.EntityType_ENTITY_TYPE_TANK_PROJECTILE); got != projectilePB {
+		t.Errorf("Projectile() = %v, want = %v", got, projectilePB)
+	}
+}
+
+func TestCost(t *testing.T) {
+	r, err := New(&edpb.Registry{
+		Units:       []*edpb.UnitDefinition{unitPB},
+		Projectiles: []*edpb.ProjectileDefinition{projectilePB},
+		Refineries: []*edpb.RefineryDefinition{{
+			EntityType: gcpb.EntityType_ENTITY_TYPE_REFINERY,
+			Health:     100,
+			Cost:       200,
+		}},
+		Factories: []*edpb.FactoryDefinition{{
+			EntityType: gcpb.EntityType_ENTITY_TYPE_FACTORY,
+			Health:     100,
+			Cost:       400,
+			Produces:   []gcpb.EntityType{gcpb.EntityType_ENTITY_TYPE_TANK},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+
+	testConfigs := []struct {
+		name string
+		t    gcpb.EntityType
+		cost float64
+		ok   bool
+	}{
+		{name: "Unit", t: gcpb.EntityType_ENTITY_TYPE_TANK, cost: unitPB.GetCost(), ok: true},
+		{name: "Refinery", t: gcpb.EntityType_ENTITY_TYPE_REFINERY, cost: 200, ok: true},
+		{name: "Factory", t: gcpb.EntityType_ENTITY_TYPE_FACTORY, cost: 400, ok: true},
+		{name: "Projectile", t: gcpb.EntityType_ENTITY_TYPE_TANK_PROJECTILE, cost: 0, ok: false},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if cost, ok := r.Cost(c.t); cost != c.cost || ok != c.ok {
+				t.Errorf("Cost() = %v, %v, want = %v, %v", cost, ok, c.cost, c.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Package registrytest provides the entity definitions shared by server tests.
+//
+// Each function returns a new copy of the definition, so that tests may tweak
+// the returned definition (e.g. add a cost or other entity types) without
+// affecting other tests.
+package registrytest
+
+import (
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+// Tank returns the definition of a tank which fires tank projectiles.
+func Tank() *edpb.UnitDefinition {
+	return &edpb.UnitDefinition{
+		EntityType:   gcpb.EntityType_ENTITY_TYPE_TANK,
+		MoveVelocity: 2,
+		Health:       100,
+		Attack: &edpb.AttackDefinition{
+			Strength:       2,
+			Range:          2,
+			Velocity:       10,
+			Cooloff:        10,
+			ProjectileType: gcpb.EntityType_ENTITY_TYPE_TANK_PROJECTILE,
+		},
+	}
+}
+
+// TankProjectile returns the definition of the projectile fired by the tank.
+func TankProjectile() *edpb.ProjectileDefinition {
+	return &edpb.ProjectileDefinition{
+		EntityType:   gcpb.EntityType_ENTITY_TYPE_TANK_PROJECTILE,
+		MoveVelocity: 20,
+	}
+}
+
+// Registry returns a registry which only defines the tank and its projectile.
+func Registry() *edpb.Registry {
+	return &edpb.Registry{
+		Units:       []*edpb.UnitDefinition{Tank()},
+		Projectiles: []*edpb.ProjectileDefinition{TankProjectile()},
+	}
+}
@@ -0,0 +1,72 @@
+// Package resource encapsulates logic for resource fields, which are placed on
+// the map and depleted by harvesters.
+package resource
+
+import (
+	"reflect"
+
+	"github.com/downflux/game/engine/curve/common/delta"
+	"github.com/downflux/game/engine/curve/common/linearmove"
+	"github.com/downflux/game/engine/curve/common/step"
+	"github.com/downflux/game/engine/curve/curve"
+	"github.com/downflux/game/engine/curve/list"
+	"github.com/downflux/game/engine/entity/component/lifecycle"
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/component/harvestable"
+	"github.com/downflux/game/server/entity/component/positionable"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	curvecomponent "github.com/downflux/game/engine/entity/component/curve"
+)
+
+type (
+	harvestComponent   = harvestable.Base
+	positionComponent  = positionable.Base
+	lifecycleComponent = lifecycle.Component
+	curveComponent     = curvecomponent.Component
+)
+
+// Entity implements the entity.Entity interface and represents a resource
+// field. Resource fields are not owned by any client.
+type Entity struct {
+	entity.Base
+	harvestComponent
+	positionComponent
+	lifecycleComponent
+	curveComponent
+}
+
+// New constructs a new resource field holding the input amount of resources.
+func New(eid id.EntityID, t id.Tick, pos *gdpb.Position, resources float64) (*Entity, error) {
+	mc := linearmove.New(eid, t)
+	mc.Add(t, pos)
+
+	cidc := step.New(
+		eid,
+		t,
+		gcpb.EntityProperty_ENTITY_PROPERTY_CLIENT_ID,
+		reflect.TypeOf(id.ClientID("")),
+	)
+	cidc.Add(t, id.ClientID(""))
+
+	rc := delta.New(step.New(eid, t, gcpb.EntityProperty_ENTITY_PROPERTY_RESOURCES, reflect.TypeOf(float64(0))))
+	if err := rc.Add(t, resources); err != nil {
+		return nil, err
+	}
+
+	curves, err := list.New([]curve.Curve{mc, cidc, rc})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Entity{
+		Base: *entity.New(
+			gcpb.EntityType_ENTITY_TYPE_RESOURCE_FIELD, eid, cidc),
+		harvestComponent:   *harvestable.New(rc),
+		positionComponent:  *positionable.New(mc),
+		lifecycleComponent: *lifecycle.New(t),
+		curveComponent:     *curvecomponent.New(curves),
+	}, nil
+}
@@ -0,0 +1,13 @@
+package resource
+
+import (
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/server/entity/component/harvestable"
+	"github.com/downflux/game/server/entity/component/positionable"
+)
+
+var (
+	_ entity.Entity          = &Entity{}
+	_ harvestable.Component  = &Entity{}
+	_ positionable.Component = &Entity{}
+)
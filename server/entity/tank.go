@@ -1,4 +1,5 @@
-// Package tank encapsulates logic for a basic tank unit.
+// Package tank encapsulates logic for armored units, e.g. tanks, whose
+// attributes are described by a UnitDefinition.
 package tank
 
 import (
@@ -22,23 +23,7 @@ import (
 	gcpb "github.com/downflux/game/api/constants_go_proto"
 	gdpb "github.com/downflux/game/api/data_go_proto"
 	curvecomponent "github.com/downflux/game/engine/entity/component/curve"
-)
-
-const (
-	// moveVelocity is measured in tiles per second.
-	moveVelocity = 2
-
-	// attackVelocity is measured in tiles per second.
-	attackVelocity = 10
-
-	// cooloff is measured in ticks.
-	// TODO(minkezhang): Refactor to be in terms of seconds instead.
-	cooloff = id.Tick(10)
-
-	strength    = 2
-	attackRange = 2
-
-	health = float64(100)
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
 )
 
 type (
@@ -62,8 +47,10 @@ type Entity struct {
 	curveComponent
 }
 
-// New constructs a new instance of the Tank.
+// New constructs a new instance of the unit described by the input definition.
+// The input projectile is fired by the unit when attacking.
 func New(
+	pb *edpb.UnitDefinition,
 	eid id.EntityID,
 	t id.Tick,
 	pos *gdpb.Position,
@@ -71,7 +58,7 @@ func New(
 	proj *projectile.Entity) (*Entity, error) {
 	mc := linearmove.New(eid, t)
 	mc.Add(t, pos)
-	ac := timer.New(eid, t, cooloff, gcpb.EntityProperty_ENTITY_PROPERTY_ATTACK_TIMER)
+	ac := timer.New(eid, t, id.Tick(pb.GetAttack().GetCooloff()), gcpb.EntityProperty_ENTITY_PROPERTY_ATTACK_TIMER)
 	tc := step.New(eid, t, gcpb.EntityProperty_ENTITY_PROPERTY_ATTACK_TARGET, reflect.TypeOf(id.ClientID("")))
 
 	cidc := step.New(
@@ -83,7 +70,7 @@ func New(
 	cidc.Add(t, cid)
 
 	hp := delta.New(step.New(eid, t, gcpb.EntityProperty_ENTITY_PROPERTY_HEALTH, reflect.TypeOf(float64(0))))
-	if err := hp.Add(t, health); err != nil {
+	if err := hp.Add(t, pb.GetHealth()); err != nil {
 		return nil, err
 	}
 
@@ -94,13 +81,17 @@ func New(
 
 	return &Entity{
 		Base: *entity.New(
-			gcpb.EntityType_ENTITY_TYPE_TANK, eid, cidc),
+			pb.GetEntityType(), eid, cidc),
 
-		moveComponent: *moveable.New(moveVelocity),
+		moveComponent: *moveable.New(pb.GetMoveVelocity()),
 		attackComponent: *attackable.New(
-			strength, attackRange, attackVelocity, tc, ac, proj),
-		targetComponent:   *targetable.New(hp),
-		positionComponent: *positionable.New(mc),
-		curveComponent:    *curvecomponent.New(curves),
+			pb.GetAttack().GetStrength(),
+			pb.GetAttack().GetRange(),
+			pb.GetAttack().GetVelocity(),
+			tc, ac, proj),
+		targetComponent:    *targetable.New(hp),
+		positionComponent:  *positionable.New(mc),
+		lifecycleComponent: *lifecycle.New(t),
+		curveComponent:     *curvecomponent.New(curves),
 	}, nil
 }
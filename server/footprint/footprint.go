@@ -0,0 +1,279 @@
+// Package footprint tracks the map tiles occupied by structures.
+//
+// Tiles covered by the footprint of a structure are blocked for as long as the
+// structure exists, and the abstract pathing graph of the map is rebuilt around
+// the blocked tiles, so that units path around structures.
+package footprint
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/map/utils"
+	"github.com/downflux/game/pathing/hpf/graph"
+	"github.com/downflux/game/server/entity/component/structure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	mcpb "github.com/downflux/game/map/api/constants_go_proto"
+	tile "github.com/downflux/game/map/map"
+)
+
+// Tiles returns the coordinates of the tiles covered by a footprint of the
+// input dimension, anchored at the input position.
+func Tiles(p *gdpb.Position, d *gdpb.Coordinate) []utils.MapCoordinate {
+	x, y := int32(p.GetX()), int32(p.GetY())
+
+	var tiles []utils.MapCoordinate
+	for i := int32(0); i < d.GetX(); i++ {
+		for j := int32(0); j < d.GetY(); j++ {
+			tiles = append(tiles, utils.MapCoordinate{X: x + i, Y: y + j})
+		}
+	}
+	return tiles
+}
+
+// Open checks if the input tile exists and is passable.
+func Open(tm *tile.Map, c utils.MapCoordinate) bool {
+	t := tm.Tile(c.X, c.Y)
+	return t != nil && !math.IsInf(tm.C[t.TerrainType()], 0)
+}
+
+// Nearest returns the open tile closest to the input tile. Tiles are searched
+// in rings of increasing distance around the input tile; ties within a ring
+// are broken by the Euclidean distance to the input tile, and then by the tile
+// coordinate, to ensure replays are deterministic.
+//
+// Nearest returns the input tile and false if the input tile lies outside the
+// map, or if there are no open tiles on the map.
+func Nearest(tm *tile.Map, c utils.MapCoordinate) (utils.MapCoordinate, bool) {
+	if tm.Tile(c.X, c.Y) == nil {
+		return c, false
+	}
+
+	r := tm.D.GetX()
+	if tm.D.GetY() > r {
+		r = tm.D.GetY()
+	}
+
+	for d := int32(0); d < r; d++ {
+		var candidates []utils.MapCoordinate
+		for _, t := range ring(c, d) {
+			if Open(tm, t) {
+				candidates = append(candidates, t)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		l := func(t utils.MapCoordinate) int32 {
+			return (t.X-c.X)*(t.X-c.X) + (t.Y-c.Y)*(t.Y-c.Y)
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			if l(a) != l(b) {
+				return l(a) < l(b)
+			}
+			return utils.LessThan(a, b)
+		})
+		return candidates[0], true
+	}
+	return c, false
+}
+
+// ring returns the tiles whose Chebyshev distance to the input tile is exactly
+// the input distance.
+func ring(c utils.MapCoordinate, d int32) []utils.MapCoordinate {
+	if d == 0 {
+		return []utils.MapCoordinate{c}
+	}
+
+	var tiles []utils.MapCoordinate
+	for x := c.X - d; x <= c.X+d; x++ {
+		tiles = append(
+			tiles,
+			utils.MapCoordinate{X: x, Y: c.Y - d},
+			utils.MapCoordinate{X: x, Y: c.Y + d})
+	}
+	for y := c.Y - d + 1; y < c.Y+d; y++ {
+		tiles = append(
+			tiles,
+			utils.MapCoordinate{X: c.X - d, Y: y},
+			utils.MapCoordinate{X: c.X + d, Y: y})
+	}
+	return tiles
+}
+
+// Map blocks and reopens the map tiles covered by structures, and keeps the
+// abstract pathing graph in sync with the blocked tiles.
+type Map struct {
+	// mux guards the tile.Map and graph.Graph, as structures may be
+	// placed and destroyed concurrently.
+	//
+	// Path planning reads the tile.Map and graph.Graph without acquiring
+	// mux, and therefore must not run concurrently with placing or
+	// destroying structures.
+	mux sync.Mutex
+
+	tm *tile.Map
+	g  *graph.Graph
+
+	// terrain tracks the original terrain type of each blocked tile.
+	terrain map[utils.MapCoordinate]mcpb.TerrainType
+
+	// tiles tracks the list of tiles blocked by each structure.
+	tiles map[id.EntityID][]utils.MapCoordinate
+}
+
+// New constructs a new Map instance, which mutates the input tile.Map and
+// graph.Graph as structures are placed and destroyed.
+func New(tm *tile.Map, g *graph.Graph) *Map {
+	// Blocked tiles must be impassable, even if the map does not
+	// explicitly define the cost of blocked terrain.
+	if _, found := tm.C[mcpb.TerrainType_TERRAIN_TYPE_BLOCKED]; !found {
+		tm.C[mcpb.TerrainType_TERRAIN_TYPE_BLOCKED] = math.Inf(0)
+	}
+
+	return &Map{
+		tm:      tm,
+		g:       g,
+		terrain: map[utils.MapCoordinate]mcpb.TerrainType{},
+		tiles:   map[id.EntityID][]utils.MapCoordinate{},
+	}
+}
+
+// Placeable checks if a structure with the input footprint may be placed at
+// the input position, i.e. all tiles covered by the footprint lie within the
+// map and are open.
+func (m *Map) Placeable(p *gdpb.Position, d *gdpb.Coordinate) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return m.placeableUnsafe(p, d)
+}
+
+func (m *Map) placeableUnsafe(p *gdpb.Position, d *gdpb.Coordinate) bool {
+	if p.GetX() < 0 || p.GetY() < 0 {
+		return false
+	}
+	for _, t := range Tiles(p, d) {
+		if !Open(m.tm, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Nearest returns the open tile closest to the input tile.
+func (m *Map) Nearest(c utils.MapCoordinate) (utils.MapCoordinate, bool) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return Nearest(m.tm, c)
+}
+
+// Block marks the tiles covered by the input structure as impassable. Block
+// is a no-op if the structure has already been blocked.
+func (m *Map) Block(s structure.Component, t id.Tick) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if err := m.blockUnsafe(s, t); err != nil {
+		return err
+	}
+	return m.rebuildUnsafe(m.tiles[s.ID()])
+}
+
+func (m *Map) blockUnsafe(s structure.Component, t id.Tick) error {
+	if _, found := m.tiles[s.ID()]; found {
+		return nil
+	}
+
+	p := s.Position(t)
+	if !m.placeableUnsafe(p, s.Footprint()) {
+		return status.Errorf(codes.FailedPrecondition, "structure %v cannot be placed at %v", s.ID(), p)
+	}
+
+	tiles := Tiles(p, s.Footprint())
+	for _, c := range tiles {
+		tl := m.tm.Tile(c.X, c.Y)
+		m.terrain[c] = tl.TerrainType()
+		tl.SetTerrainType(mcpb.TerrainType_TERRAIN_TYPE_BLOCKED)
+	}
+	m.tiles[s.ID()] = tiles
+	return nil
+}
+
+// Unblock restores the original terrain of the tiles covered by the input
+// structure. Unblock is a no-op if the structure is not currently blocking
+// any tiles.
+func (m *Map) Unblock(eid id.EntityID) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	tiles := m.unblockUnsafe(eid)
+	return m.rebuildUnsafe(tiles)
+}
+
+func (m *Map) unblockUnsafe(eid id.EntityID) []utils.MapCoordinate {
+	tiles := m.tiles[eid]
+	for _, c := range tiles {
+		m.tm.Tile(c.X, c.Y).SetTerrainType(m.terrain[c])
+		delete(m.terrain, c)
+	}
+	delete(m.tiles, eid)
+	return tiles
+}
+
+// Sync blocks the tiles of all live structures in the input entity list, and
+// reopens the tiles of all structures which have been destroyed or which no
+// longer exist. Sync is used to restore the blocked tiles after the entity
+// list has been changed outside of the Executor game loop, e.g. when the game
+// is rolled back or loaded from a snapshot.
+func (m *Map) Sync(entities []entity.Entity, t id.Tick) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	live := map[id.EntityID]structure.Component{}
+	for _, e := range entities {
+		if s, ok := e.(structure.Component); ok && e.End() == 0 {
+			live[e.ID()] = s
+		}
+	}
+
+	var tiles []utils.MapCoordinate
+	for eid := range m.tiles {
+		if _, found := live[eid]; !found {
+			tiles = append(tiles, m.unblockUnsafe(eid)...)
+		}
+	}
+
+	// Structures are blocked in a fixed order so that errors are
+	// reported deterministically.
+	var eids []id.EntityID
+	for eid := range live {
+		if _, found := m.tiles[eid]; !found {
+			eids = append(eids, eid)
+		}
+	}
+	sort.Slice(eids, func(i, j int) bool { return eids[i] < eids[j] })
+	for _, eid := range eids {
+		if err := m.blockUnsafe(live[eid], t); err != nil {
+			return err
+		}
+		tiles = append(tiles, m.tiles[eid]...)
+	}
+
+	return m.rebuildUnsafe(tiles)
+}
+
+// rebuildUnsafe rebuilds the clusters of the abstract pathing graph which
+// contain the input tiles. The caller must hold the Map mutex.
+func (m *Map) rebuildUnsafe(tiles []utils.MapCoordinate) error {
+	return m.g.Update(m.tm, tiles)
+}
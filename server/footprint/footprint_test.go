@@ -0,0 +1,153 @@
+package footprint
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/map/utils"
+	"github.com/downflux/game/pathing/hpf/astar"
+	"github.com/downflux/game/pathing/hpf/graph"
+	"github.com/downflux/game/server/entity/refinery"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	mcpb "github.com/downflux/game/map/api/constants_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	tile "github.com/downflux/game/map/map"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+var (
+	refineryPB = &edpb.RefineryDefinition{
+		EntityType: gcpb.EntityType_ENTITY_TYPE_REFINERY,
+		Health:     100,
+		Footprint:  &gdpb.Coordinate{X: 1, Y: 3},
+	}
+)
+
+// newMap constructs an open map of the input dimension.
+func newMap(t *testing.T, x, y int32) (*tile.Map, *graph.Graph) {
+	pb := &mdpb.TileMap{
+		Dimension: &gdpb.Coordinate{X: x, Y: y},
+		TerrainCosts: []*mdpb.TerrainCost{
+			{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS, Cost: 1},
+		},
+	}
+	for i := int32(0); i < x; i++ {
+		for j := int32(0); j < y; j++ {
+			pb.Tiles = append(pb.GetTiles(), &mdpb.Tile{
+				Coordinate:  &gdpb.Coordinate{X: i, Y: j},
+				TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS,
+			})
+		}
+	}
+
+	tm, err := tile.ImportMap(pb)
+	if err != nil {
+		t.Fatalf("ImportMap() = _, %v, want = nil", err)
+	}
+	g, err := graph.BuildGraph(tm, &gdpb.Coordinate{X: 2, Y: 2})
+	if err != nil {
+		t.Fatalf("BuildGraph() = _, %v, want = nil", err)
+	}
+	return tm, g
+}
+
+func newRefinery(t *testing.T, eid id.EntityID, p *gdpb.Position) *refinery.Entity {
+	r, err := refinery.New(refineryPB, eid, 0, p, "client-id")
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	return r
+}
+
+func TestNearest(t *testing.T) {
+	tm, _ := newMap(t, 3, 3)
+	tm.C[mcpb.TerrainType_TERRAIN_TYPE_BLOCKED] = math.Inf(0)
+	for _, c := range []utils.MapCoordinate{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}} {
+		tm.Tile(c.X, c.Y).SetTerrainType(mcpb.TerrainType_TERRAIN_TYPE_BLOCKED)
+	}
+
+	testConfigs := []struct {
+		name string
+		c    utils.MapCoordinate
+		want utils.MapCoordinate
+		ok   bool
+	}{
+		{name: "Open", c: utils.MapCoordinate{X: 2, Y: 2}, want: utils.MapCoordinate{X: 2, Y: 2}, ok: true},
+		{name: "Blocked", c: utils.MapCoordinate{X: 0, Y: 0}, want: utils.MapCoordinate{X: 0, Y: 1}, ok: true},
+		{name: "OutOfBounds", c: utils.MapCoordinate{X: 3, Y: 0}, want: utils.MapCoordinate{X: 3, Y: 0}, ok: false},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if got, ok := Nearest(tm, c.c); got != c.want || ok != c.ok {
+				t.Errorf("Nearest() = %v, %v, want = %v, %v", got, ok, c.want, c.ok)
+			}
+		})
+	}
+}
+
+func TestBlock(t *testing.T) {
+	tm, g := newMap(t, 3, 3)
+	m := New(tm, g)
+
+	src := utils.MapCoordinate{X: 0, Y: 1}
+	dest := utils.MapCoordinate{X: 2, Y: 1}
+
+	r := newRefinery(t, "refinery", &gdpb.Position{X: 1, Y: 0})
+	if !m.Placeable(r.Position(0), r.Footprint()) {
+		t.Fatalf("Placeable() = false, want = true")
+	}
+	if err := m.Block(r, 0); err != nil {
+		t.Fatalf("Block() = %v, want = nil", err)
+	}
+	if m.Placeable(&gdpb.Position{X: 1, Y: 1}, &gdpb.Coordinate{X: 1, Y: 1}) {
+		t.Errorf("Placeable() = true, want = false")
+	}
+
+	// The refinery walls off the east half of the map.
+	if p, _, err := astar.Path(context.Background(), tm, g, src, dest, 0); err != nil || p != nil {
+		t.Errorf("Path() = %v, _, %v, want = nil, _, nil", p, err)
+	}
+
+	if err := m.Unblock(r.ID()); err != nil {
+		t.Fatalf("Unblock() = %v, want = nil", err)
+	}
+	if got := tm.Tile(1, 1).TerrainType(); got != mcpb.TerrainType_TERRAIN_TYPE_PLAINS {
+		t.Errorf("TerrainType() = %v, want = %v", got, mcpb.TerrainType_TERRAIN_TYPE_PLAINS)
+	}
+	if p, _, err := astar.Path(context.Background(), tm, g, src, dest, 0); err != nil || p == nil {
+		t.Errorf("Path() = %v, _, %v, want = _, _, nil", p, err)
+	}
+}
+
+func TestSync(t *testing.T) {
+	tm, g := newMap(t, 3, 3)
+	m := New(tm, g)
+
+	r := newRefinery(t, "refinery", &gdpb.Position{X: 1, Y: 0})
+	destroyed := newRefinery(t, "destroyed", &gdpb.Position{X: 0, Y: 0})
+	destroyed.Delete(1)
+
+	if err := m.Sync([]entity.Entity{r, destroyed}, 0); err != nil {
+		t.Fatalf("Sync() = %v, want = nil", err)
+	}
+	if Open(tm, utils.MapCoordinate{X: 1, Y: 2}) {
+		t.Errorf("Open() = true, want = false")
+	}
+	if !Open(tm, utils.MapCoordinate{X: 0, Y: 0}) {
+		t.Errorf("Open() = false, want = true")
+	}
+
+	// Removing the structure from the entity list reopens its tiles.
+	if err := m.Sync(nil, 0); err != nil {
+		t.Fatalf("Sync() = %v, want = nil", err)
+	}
+	if !Open(tm, utils.MapCoordinate{X: 1, Y: 2}) {
+		t.Errorf("Open() = false, want = true")
+	}
+}
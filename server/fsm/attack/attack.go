@@ -10,6 +10,8 @@
 package attack
 
 import (
+	"context"
+
 	"github.com/downflux/game/engine/fsm/action"
 	"github.com/downflux/game/engine/fsm/fsm"
 	"github.com/downflux/game/engine/id/id"
@@ -67,11 +69,13 @@ func New(
 	}
 }
 
-func (a *Action) Accept(v visitor.Visitor) error         { return v.Visit(a) }
-func (a *Action) ID() id.ActionID                        { return id.ActionID(a.source.ID()) }
-func (a *Action) Target() targetable.Component           { return a.target }
-func (a *Action) Source() attackable.Component           { return a.source }
-func (a *Action) SetProjectileMove(i *projectile.Action) { a.projectileMove = i }
+func (a *Action) Accept(ctx context.Context, v visitor.Visitor) error { return v.Visit(ctx, a) }
+func (a *Action) ID() id.ActionID                                     { return id.ActionID(a.source.ID()) }
+func (a *Action) Target() targetable.Component                        { return a.target }
+func (a *Action) Source() attackable.Component                        { return a.source }
+func (a *Action) SetProjectileMove(i *projectile.Action)              { a.projectileMove = i }
+func (a *Action) Chase() *chase.Action                                { return a.chase }
+func (a *Action) ProjectileMove() *projectile.Action                  { return a.projectileMove }
 
 func (a *Action) Precedence(o action.Action) bool {
 	if a.Type() != fsmType {
@@ -8,6 +8,7 @@ import (
 	"github.com/downflux/game/engine/id/id"
 	"github.com/downflux/game/engine/status/status"
 	"github.com/downflux/game/server/entity/projectile"
+	"github.com/downflux/game/server/entity/registrytest"
 	"github.com/downflux/game/server/entity/tank"
 	"github.com/downflux/game/server/fsm/commonstate"
 	"github.com/downflux/game/server/fsm/move/chase"
@@ -19,6 +20,9 @@ import (
 
 var (
 	_ action.Action = &Action{}
+
+	tankPB       = registrytest.Tank()
+	projectilePB = registrytest.TankProjectile()
 )
 
 func newTank(
@@ -28,7 +32,7 @@ func newTank(
 	p *gdpb.Position,
 	proj *projectile.Entity) *tank.Entity {
 	cid := id.ClientID("client-id")
-	tankEntity, err := tank.New(eid, tick, p, cid, proj)
+	tankEntity, err := tank.New(tankPB, eid, tick, p, cid, proj)
 	if err != nil {
 		t.Fatalf("New() = %v, want = nil", err)
 	}
@@ -80,7 +84,7 @@ func TestPrecedence(t *testing.T) {
 func TestState(t *testing.T) {
 	pendingStatus := status.New(0)
 	pendingSourceShell, err := projectile.New(
-		"sourceShell", 0, &gdpb.Position{X: 0, Y: 0}, id.ClientID("client-id"))
+		projectilePB, "sourceShell", 0, &gdpb.Position{X: 0, Y: 0}, id.ClientID("client-id"))
 	if err != nil {
 		t.Fatalf("New() = %v, want = %v", err)
 	}
@@ -7,6 +7,8 @@
 package projectile
 
 import (
+	"context"
+
 	"github.com/downflux/game/engine/fsm/action"
 	"github.com/downflux/game/engine/fsm/fsm"
 	"github.com/downflux/game/engine/id/id"
@@ -59,11 +61,12 @@ func (n *Action) Cancel() error {
 	return status.Error(codes.Unimplemented, "cannot cancel a projectile in flight")
 }
 
-func (n *Action) Precedence(i action.Action) bool { return false }
-func (n *Action) ID() id.ActionID                 { return n.move.ID() }
-func (n *Action) Source() attackable.Component    { return n.source }
-func (n *Action) Target() targetable.Component    { return n.target }
-func (n *Action) Accept(v visitor.Visitor) error  { return v.Visit(n) }
+func (n *Action) Precedence(i action.Action) bool                     { return false }
+func (n *Action) ID() id.ActionID                                     { return n.move.ID() }
+func (n *Action) Source() attackable.Component                        { return n.source }
+func (n *Action) Target() targetable.Component                        { return n.target }
+func (n *Action) Move() *move.Action                                  { return n.move }
+func (n *Action) Accept(ctx context.Context, v visitor.Visitor) error { return v.Visit(ctx, n) }
 
 func (n *Action) State() (fsm.State, error) {
 	s, err := n.Base.State()
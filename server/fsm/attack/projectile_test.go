@@ -9,6 +9,7 @@ import (
 	"github.com/downflux/game/engine/id/id"
 	"github.com/downflux/game/engine/status/status"
 	"github.com/downflux/game/server/entity/projectile"
+	"github.com/downflux/game/server/entity/registrytest"
 	"github.com/downflux/game/server/entity/tank"
 	"github.com/downflux/game/server/fsm/commonstate"
 	"github.com/downflux/game/server/fsm/move/move"
@@ -18,6 +19,9 @@ import (
 
 var (
 	_ action.Action = &Action{}
+
+	tankPB       = registrytest.Tank()
+	projectilePB = registrytest.TankProjectile()
 )
 
 func newProjectile(
@@ -26,7 +30,7 @@ func newProjectile(
 	tick id.Tick,
 	pos *gdpb.Position,
 	cid id.ClientID) *projectile.Entity {
-	e, err := projectile.New(eid, tick, pos, cid)
+	e, err := projectile.New(projectilePB, eid, tick, pos, cid)
 	if err != nil {
 		t.Fatalf("New() = %v, want = nil", err)
 	}
@@ -40,7 +44,7 @@ func newTank(
 	pos *gdpb.Position,
 	cid id.ClientID,
 	proj *projectile.Entity) *tank.Entity {
-	e, err := tank.New(eid, tick, pos, cid, proj)
+	e, err := tank.New(tankPB, eid, tick, pos, cid, proj)
 	if err != nil {
 		t.Fatalf("New() = %v, want = nil", err)
 	}
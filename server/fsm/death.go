@@ -0,0 +1,110 @@
+// Package death defines the Action used to destroy an entity once its health
+// has been depleted.
+//
+// A Pending state indicates the entity health has not yet been checked.
+//
+// An Executing state indicates the entity health is at or below zero, and the
+// entity should be destroyed.
+//
+// A Finished state indicates the entity has been destroyed, or is still alive.
+package death
+
+import (
+	"context"
+
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/fsm"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/server/entity/component/targetable"
+	"github.com/downflux/game/server/fsm/commonstate"
+
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+)
+
+const (
+	fsmType = fcpb.FSMType_FSM_TYPE_DEATH
+)
+
+var (
+	transitions = []fsm.Transition{
+		{From: commonstate.Pending, To: commonstate.Executing, VirtualOnly: true},
+		{From: commonstate.Pending, To: commonstate.Finished, VirtualOnly: true},
+		{From: commonstate.Pending, To: commonstate.Canceled},
+		{From: commonstate.Executing, To: commonstate.Finished},
+		{From: commonstate.Canceled, To: commonstate.Canceled},
+	}
+
+	FSM = fsm.New(transitions, fsmType)
+)
+
+// Component is the subset of entities which may be destroyed by the Action.
+type Component interface {
+	entity.Entity
+	targetable.Component
+}
+
+type Action struct {
+	*action.Base
+
+	status status.ReadOnlyStatus // Read-only.
+	e      Component             // Read-only.
+}
+
+// New constructs a new death Action, which checks the health of the input
+// entity at the next Visitor pass.
+func New(dfStatus status.ReadOnlyStatus, e Component) *Action {
+	return &Action{
+		Base:   action.New(FSM, commonstate.Pending),
+		status: dfStatus,
+		e:      e,
+	}
+}
+
+func (n *Action) Accept(ctx context.Context, v visitor.Visitor) error { return v.Visit(ctx, n) }
+func (n *Action) ID() id.ActionID                                     { return id.ActionID(n.e.ID()) }
+func (n *Action) Component() Component                                { return n.e }
+
+// Precedence always defers to an existing death Action for the same entity,
+// as both Actions would check the same entity health.
+func (n *Action) Precedence(i action.Action) bool { return false }
+
+func (n *Action) Finish() error {
+	s, err := n.State()
+	if err != nil {
+		return err
+	}
+
+	return n.To(s, commonstate.Finished, false)
+}
+
+func (n *Action) Cancel() error {
+	s, err := n.State()
+	if err != nil {
+		return err
+	}
+
+	return n.To(s, commonstate.Canceled, false)
+}
+
+func (n *Action) State() (fsm.State, error) {
+	tick := n.status.Tick()
+
+	s, err := n.Base.State()
+	if err != nil {
+		return commonstate.Unknown, err
+	}
+
+	switch s {
+	case commonstate.Pending:
+		t := commonstate.Finished
+		if n.e.End() == 0 && n.e.TargetHealth(tick) <= 0 {
+			t = commonstate.Executing
+		}
+		return t, n.To(s, t, true)
+	default:
+		return s, nil
+	}
+}
@@ -0,0 +1,64 @@
+package death
+
+import (
+	"testing"
+
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/fsm"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/server/entity/registrytest"
+	"github.com/downflux/game/server/entity/tank"
+	"github.com/downflux/game/server/fsm/commonstate"
+
+	gdpb "github.com/downflux/game/api/data_go_proto"
+)
+
+var (
+	_ action.Action = &Action{}
+
+	tankPB = registrytest.Tank()
+)
+
+func newTank(t *testing.T, tick id.Tick) *tank.Entity {
+	e, err := tank.New(tankPB, id.EntityID("entity-id"), tick, &gdpb.Position{X: 0, Y: 0}, id.ClientID("client-id"), nil)
+	if err != nil {
+		t.Fatalf("New() = %v, want = nil", err)
+	}
+	return e
+}
+
+func TestState(t *testing.T) {
+	s := status.New(0)
+
+	alive := newTank(t, s.Tick())
+
+	dead := newTank(t, s.Tick())
+	if err := dead.TargetHealthCurve().Add(s.Tick(), -dead.TargetHealth(s.Tick())); err != nil {
+		t.Fatalf("Add() = %v, want = nil", err)
+	}
+
+	deleted := newTank(t, s.Tick())
+	if err := deleted.TargetHealthCurve().Add(s.Tick(), -deleted.TargetHealth(s.Tick())); err != nil {
+		t.Fatalf("Add() = %v, want = nil", err)
+	}
+	deleted.Delete(s.Tick())
+
+	testConfigs := []struct {
+		name string
+		a    *Action
+		want fsm.State
+	}{
+		{name: "Alive", a: New(s, alive), want: commonstate.Finished},
+		{name: "Dead", a: New(s, dead), want: commonstate.Executing},
+		{name: "AlreadyDeleted", a: New(s, deleted), want: commonstate.Finished},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if got, err := c.a.State(); err != nil || got != c.want {
+				t.Errorf("State() = %v, %v, want = %v, nil", got, err, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Package harvest defines the Action used for carrying out the automated
+// harvesting loop of a harvester, i.e. repeatedly collecting resources from a
+// resource field and unloading the collected resources at a refinery.
+//
+// A Pending state indicates the harvester is currently moving towards a
+// resource field or refinery.
+//
+// An Executing state indicates the harvester is idle, and the visitor should
+// decide the next step of the loop.
+package harvest
+
+import (
+	"context"
+
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/fsm"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/server/entity/component/carrier"
+	"github.com/downflux/game/server/fsm/commonstate"
+	"github.com/downflux/game/server/fsm/move/move"
+
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+)
+
+const (
+	fsmType = fcpb.FSMType_FSM_TYPE_HARVEST
+)
+
+var (
+	transitions = []fsm.Transition{
+		{From: commonstate.Pending, To: commonstate.Executing, VirtualOnly: true},
+		{From: commonstate.Pending, To: commonstate.Canceled},
+		{From: commonstate.Canceled, To: commonstate.Canceled},
+	}
+
+	FSM = fsm.New(transitions, fsmType)
+)
+
+type Action struct {
+	*action.Base
+
+	tick   id.Tick               // Read-only.
+	source carrier.Component     // Read-only.
+	status status.ReadOnlyStatus // Read-only.
+
+	move *move.Action
+}
+
+func New(dfStatus status.ReadOnlyStatus, source carrier.Component) *Action {
+	return &Action{
+		Base:   action.New(FSM, commonstate.Pending),
+		source: source,
+		status: dfStatus,
+		tick:   dfStatus.Tick(),
+	}
+}
+
+func (a *Action) Accept(ctx context.Context, v visitor.Visitor) error { return v.Visit(ctx, a) }
+func (a *Action) Source() carrier.Component                           { return a.source }
+func (a *Action) ID() id.ActionID                                     { return id.ActionID(a.source.ID()) }
+func (a *Action) Status() status.ReadOnlyStatus                       { return a.status }
+
+// Move returns the move action currently generated by the harvest action, if
+// any.
+func (a *Action) Move() *move.Action { return a.move }
+
+func (a *Action) SetMove(m *move.Action) error {
+	a.move = m
+	return nil
+}
+
+func (a *Action) Precedence(o action.Action) bool {
+	if o.Type() != fsmType {
+		return false
+	}
+
+	return a.tick > o.(*Action).tick
+}
+
+// State returns the current state of the harvest action. The harvest action
+// is canceled if the generated move is canceled, e.g. when the player issues
+// an explicit move command to the harvester.
+func (a *Action) State() (fsm.State, error) {
+	var err error
+	moveState := commonstate.Finished
+	if a.move != nil {
+		moveState, err = a.move.State()
+		if err != nil {
+			return commonstate.Unknown, err
+		}
+	}
+
+	s, err := a.Base.State()
+	if err != nil {
+		return commonstate.Unknown, err
+	}
+	if moveState == commonstate.Canceled {
+		return moveState, a.To(s, moveState, true)
+	}
+
+	switch s {
+	case commonstate.Pending:
+		if moveState == commonstate.Finished {
+			return commonstate.Executing, a.To(s, commonstate.Executing, true)
+		}
+		return s, nil
+	default:
+		return s, nil
+	}
+}
+
+func (a *Action) Cancel() error {
+	s, err := a.State()
+	if err != nil {
+		return err
+	}
+
+	if a.move != nil {
+		if err := a.move.Cancel(); err != nil {
+			return err
+		}
+	}
+	return a.To(s, commonstate.Canceled, false)
+}
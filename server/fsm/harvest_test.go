@@ -0,0 +1,100 @@
+package harvest
+
+import (
+	"testing"
+
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/fsm"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/server/entity/harvester"
+	"github.com/downflux/game/server/fsm/commonstate"
+	"github.com/downflux/game/server/fsm/move/move"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+var (
+	_ action.Action = &Action{}
+
+	harvesterPB = &edpb.HarvesterDefinition{
+		EntityType:   gcpb.EntityType_ENTITY_TYPE_HARVESTER,
+		MoveVelocity: 1,
+		Health:       100,
+		Capacity:     10,
+		HarvestRate:  1,
+	}
+)
+
+func newHarvester(t *testing.T, p *gdpb.Position) *harvester.Entity {
+	h, err := harvester.New(harvesterPB, "harvester", 0, p, id.ClientID("client-id"))
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	return h
+}
+
+func TestState(t *testing.T) {
+	s := status.New(0)
+	h := newHarvester(t, &gdpb.Position{X: 0, Y: 0})
+
+	idle := New(s, h)
+
+	moving := New(s, h)
+	if err := moving.SetMove(move.New(h, s, &gdpb.Position{X: 5, Y: 5}, move.Default)); err != nil {
+		t.Fatalf("SetMove() = %v, want = nil", err)
+	}
+
+	arrived := New(s, h)
+	if err := arrived.SetMove(move.New(h, s, &gdpb.Position{X: 0, Y: 0}, move.Default)); err != nil {
+		t.Fatalf("SetMove() = %v, want = nil", err)
+	}
+
+	interrupted := New(s, h)
+	m := move.New(h, s, &gdpb.Position{X: 5, Y: 5}, move.Default)
+	if err := interrupted.SetMove(m); err != nil {
+		t.Fatalf("SetMove() = %v, want = nil", err)
+	}
+	if err := m.Cancel(); err != nil {
+		t.Fatalf("Cancel() = %v, want = nil", err)
+	}
+
+	testConfigs := []struct {
+		name string
+		a    *Action
+		want fsm.State
+	}{
+		{name: "Idle", a: idle, want: commonstate.Executing},
+		{name: "Moving", a: moving, want: commonstate.Pending},
+		{name: "Arrived", a: arrived, want: commonstate.Executing},
+		{name: "Interrupted", a: interrupted, want: commonstate.Canceled},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if got, err := c.a.State(); err != nil || got != c.want {
+				t.Errorf("State() = %v, %v, want = %v, nil", got, err, c.want)
+			}
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	s := status.New(0)
+	h := newHarvester(t, &gdpb.Position{X: 0, Y: 0})
+
+	a := New(s, h)
+	m := move.New(h, s, &gdpb.Position{X: 5, Y: 5}, move.Default)
+	if err := a.SetMove(m); err != nil {
+		t.Fatalf("SetMove() = %v, want = nil", err)
+	}
+
+	if err := a.Cancel(); err != nil {
+		t.Fatalf("Cancel() = %v, want = nil", err)
+	}
+	if got, err := m.State(); err != nil || got != commonstate.Canceled {
+		t.Errorf("State() = %v, %v, want = %v, nil", got, err, commonstate.Canceled)
+	}
+}
@@ -7,6 +7,8 @@
 package chase
 
 import (
+	"context"
+
 	"github.com/downflux/game/engine/fsm/action"
 	"github.com/downflux/game/engine/fsm/fsm"
 	"github.com/downflux/game/engine/id/id"
@@ -80,11 +82,15 @@ func GenerateMove(a *Action) *move.Action {
 		a.Destination().Position(a.Status().Tick()),
 		move.Default)
 }
-func (a *Action) Accept(v visitor.Visitor) error    { return v.Visit(a) }
-func (a *Action) Source() moveable.Component        { return a.source }
-func (a *Action) Destination() targetable.Component { return a.destination }
-func (a *Action) ID() id.ActionID                   { return id.ActionID(a.source.ID()) }
-func (a *Action) Status() status.ReadOnlyStatus     { return a.status }
+func (a *Action) Accept(ctx context.Context, v visitor.Visitor) error { return v.Visit(ctx, a) }
+func (a *Action) Source() moveable.Component                          { return a.source }
+func (a *Action) Destination() targetable.Component                   { return a.destination }
+func (a *Action) ID() id.ActionID                                     { return id.ActionID(a.source.ID()) }
+func (a *Action) Status() status.ReadOnlyStatus                       { return a.status }
+
+// Move returns the move action currently generated by the chase action, if
+// any.
+func (a *Action) Move() *move.Action { return a.move }
 
 func (a *Action) SetMove(m *move.Action) error {
 	a.move = m
@@ -1,6 +1,8 @@
 package move
 
 import (
+	"context"
+
 	"github.com/downflux/game/engine/fsm/action"
 	"github.com/downflux/game/engine/fsm/fsm"
 	"github.com/downflux/game/engine/id/id"
@@ -80,10 +82,14 @@ func New(
 	}
 }
 
-func (n *Action) Accept(v visitor.Visitor) error { return v.Visit(n) }
-func (n *Action) Component() moveable.Component  { return n.e }
-func (n *Action) ID() id.ActionID                { return id.ActionID(n.e.ID()) }
-func (n *Action) MoveType() MoveType             { return n.moveType }
+func (n *Action) Accept(ctx context.Context, v visitor.Visitor) error { return v.Visit(ctx, n) }
+func (n *Action) Component() moveable.Component                       { return n.e }
+func (n *Action) ID() id.ActionID                                     { return id.ActionID(n.e.ID()) }
+func (n *Action) MoveType() MoveType                                  { return n.moveType }
+
+// ExecutionTick returns the tick at which the move should next be processed
+// by the move visitor.
+func (n *Action) ExecutionTick() id.Tick { return n.executionTick }
 
 // SchedulePartialMove allows us to mutate the FSM action to deal with
 // partial moves. This allows us to know when the visitor should make the next
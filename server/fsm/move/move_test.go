@@ -7,6 +7,7 @@ import (
 	"github.com/downflux/game/engine/fsm/fsm"
 	"github.com/downflux/game/engine/id/id"
 	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/server/entity/registrytest"
 	"github.com/downflux/game/server/entity/tank"
 	"github.com/downflux/game/server/fsm/commonstate"
 
@@ -15,11 +16,13 @@ import (
 
 var (
 	_ action.Action = &Action{}
+
+	tankPB = registrytest.Tank()
 )
 
 func newTank(t *testing.T, eid id.EntityID, tick id.Tick, p *gdpb.Position) *tank.Entity {
 	cid := id.ClientID("client-id")
-	tankEntity, err := tank.New(eid, tick, p, cid, nil)
+	tankEntity, err := tank.New(tankPB, eid, tick, p, cid, nil)
 	if err != nil {
 		t.Fatalf("New() = %v, want = nil", err)
 	}
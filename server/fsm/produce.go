@@ -1,6 +1,8 @@
 package produce
 
 import (
+	"context"
+
 	"github.com/downflux/game/engine/fsm/action"
 	"github.com/downflux/game/engine/fsm/fsm"
 	"github.com/downflux/game/engine/id/id"
@@ -13,7 +15,20 @@ import (
 	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
 )
 
+// PaymentType indicates if the client should be charged for the produced
+// entity.
+type PaymentType int
+
 const (
+	// Charged actions deduct the cost of the entity from the credits of
+	// the spawning client, and are canceled if the client cannot afford
+	// the entity.
+	Charged PaymentType = iota
+
+	// Free actions do not deduct any credits, e.g. for spawning the
+	// starting units of a client.
+	Free
+
 	fsmType  = fcpb.FSMType_FSM_TYPE_PRODUCE
 	idLength = 16
 )
@@ -23,6 +38,7 @@ var (
 		{From: commonstate.Pending, To: commonstate.Executing, VirtualOnly: true},
 		{From: commonstate.Pending, To: commonstate.Canceled},
 		{From: commonstate.Executing, To: commonstate.Finished},
+		{From: commonstate.Executing, To: commonstate.Canceled},
 		{From: commonstate.Canceled, To: commonstate.Canceled},
 	}
 	FSM = fsm.New(transitions, fsmType)
@@ -38,6 +54,11 @@ type Action struct {
 	entityType    gcpb.EntityType       // Read-only.
 	spawnPosition *gdpb.Position        // Read-only.
 	spawnClientID id.ClientID           // Read-only.
+	paymentType   PaymentType           // Read-only.
+
+	// rallyPoint is the position to which the produced unit moves after
+	// being built, if set.
+	rallyPoint *gdpb.Position
 }
 
 func New(
@@ -45,23 +66,53 @@ func New(
 	executionTick id.Tick,
 	entityType gcpb.EntityType,
 	spawnPosition *gdpb.Position,
-	spawnClientID id.ClientID) *Action {
+	spawnClientID id.ClientID,
+	paymentType PaymentType) *Action {
+	return NewWithID(
+		id.ActionID(id.RandomString(idLength)),
+		dfStatus,
+		executionTick,
+		entityType,
+		spawnPosition,
+		spawnClientID,
+		paymentType)
+}
+
+// NewWithID constructs a new produce Action with a fixed action UUID. The
+// UUID seeds the IDs of the spawned entities, so actions with the same UUID
+// will produce the same entities. This is used to play back recorded games.
+func NewWithID(
+	aid id.ActionID,
+	dfStatus status.ReadOnlyStatus,
+	executionTick id.Tick,
+	entityType gcpb.EntityType,
+	spawnPosition *gdpb.Position,
+	spawnClientID id.ClientID,
+	paymentType PaymentType) *Action {
 	return &Action{
 		Base:          action.New(FSM, commonstate.Pending),
-		id:            id.ActionID(id.RandomString(idLength)),
+		id:            aid,
 		executionTick: executionTick,
 		status:        dfStatus,
 		entityType:    entityType,
 		spawnPosition: spawnPosition,
 		spawnClientID: spawnClientID,
+		paymentType:   paymentType,
 	}
 }
 
-func (n *Action) EntityType() gcpb.EntityType    { return n.entityType }
-func (n *Action) Accept(v visitor.Visitor) error { return v.Visit(n) }
-func (n *Action) ID() id.ActionID                { return n.id }
-func (n *Action) SpawnPosition() *gdpb.Position  { return n.spawnPosition }
-func (n *Action) SpawnClientID() id.ClientID     { return n.spawnClientID }
+func (n *Action) EntityType() gcpb.EntityType                         { return n.entityType }
+func (n *Action) Accept(ctx context.Context, v visitor.Visitor) error { return v.Visit(ctx, n) }
+func (n *Action) ID() id.ActionID                                     { return n.id }
+func (n *Action) SpawnPosition() *gdpb.Position                       { return n.spawnPosition }
+func (n *Action) SpawnClientID() id.ClientID                          { return n.spawnClientID }
+func (n *Action) ExecutionTick() id.Tick                              { return n.executionTick }
+func (n *Action) PaymentType() PaymentType                            { return n.paymentType }
+func (n *Action) RallyPoint() *gdpb.Position                          { return n.rallyPoint }
+
+// SetRallyPoint sets the position to which the produced unit will move after
+// being built. This must be called before the action is scheduled.
+func (n *Action) SetRallyPoint(p *gdpb.Position) { n.rallyPoint = p }
 
 func (n *Action) Precedence(i action.Action) bool {
 	if i.Type() != fsmType {
@@ -28,12 +28,12 @@ func TestConstructor(t *testing.T) {
 	}{
 		{
 			name: "NewPending",
-			i:    New(s, s.Tick()+1, gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid),
+			i:    New(s, s.Tick()+1, gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid, Charged),
 			want: commonstate.Pending,
 		},
 		{
 			name: "NewExecuting",
-			i:    New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid),
+			i:    New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid, Charged),
 			want: commonstate.Executing,
 		},
 	}
@@ -51,7 +51,7 @@ func TestFinish(t *testing.T) {
 	s := status.New(0)
 	cid := id.ClientID("client-id")
 
-	i := New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid)
+	i := New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid, Charged)
 
 	if err := i.Finish(); err != nil {
 		t.Fatalf("Finish() = %v, want = nil", err)
@@ -62,7 +62,7 @@ func TestCancel(t *testing.T) {
 	s := status.New(0)
 	cid := id.ClientID("client-id")
 
-	i := New(s, s.Tick()+1, gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid)
+	i := New(s, s.Tick()+1, gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid, Charged)
 
 	if err := i.Cancel(); err != nil {
 		t.Fatalf("Cancel() = %v, want = nil", err)
@@ -0,0 +1,156 @@
+// Package order defines the Action used for issuing a command to the
+// production queue of a factory, e.g. adding a new item to the queue.
+//
+// Orders are applied by the production queue visitor in the tick at which the
+// order was issued; orders issued in the same tick are applied in a
+// deterministic order, sorted by OrderType and then by ID.
+//
+// A Pending state indicates the order has not yet been applied.
+//
+// A Finished state indicates the order has been applied.
+//
+// A Canceled state indicates the order could not be applied, e.g. if the client
+// could not afford the queued item.
+package order
+
+import (
+	"context"
+
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/fsm"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/server/entity/component/producer"
+	"github.com/downflux/game/server/fsm/commonstate"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+)
+
+// OrderType indicates how the order modifies the production queue.
+type OrderType int
+
+const (
+	// Enqueue orders add a new item to the back of the queue. The cost of
+	// the item is charged when the order is applied.
+	Enqueue OrderType = iota
+
+	// Dequeue orders remove an item from the queue and refund the cost of
+	// the item.
+	Dequeue
+
+	// Reorder orders move an item to a new position in the queue.
+	Reorder
+
+	// Rally orders set the position to which newly produced entities will
+	// move.
+	Rally
+
+	fsmType  = fcpb.FSMType_FSM_TYPE_PRODUCTION_ORDER
+	idLength = 16
+)
+
+var (
+	transitions = []fsm.Transition{
+		{From: commonstate.Pending, To: commonstate.Finished},
+		{From: commonstate.Pending, To: commonstate.Canceled},
+		{From: commonstate.Canceled, To: commonstate.Canceled},
+	}
+
+	FSM = fsm.New(transitions, fsmType)
+)
+
+type Action struct {
+	*action.Base
+
+	id         id.ActionID           // Read-only.
+	status     status.ReadOnlyStatus // Read-only.
+	factory    producer.Component    // Read-only.
+	orderType  OrderType             // Read-only.
+	entityType gcpb.EntityType       // Read-only.
+	itemID     id.ActionID           // Read-only.
+	index      int                   // Read-only.
+	rallyPoint *gdpb.Position        // Read-only.
+}
+
+// NewEnqueue constructs a new order which adds an entity of the input type to
+// the production queue. The ID of the order is also used as the ID of the
+// queued item.
+func NewEnqueue(dfStatus status.ReadOnlyStatus, factory producer.Component, entityType gcpb.EntityType) *Action {
+	return NewWithID(id.ActionID(id.RandomString(idLength)), dfStatus, factory, Enqueue, entityType, "", 0, nil)
+}
+
+// NewDequeue constructs a new order which removes the input item from the
+// production queue.
+func NewDequeue(dfStatus status.ReadOnlyStatus, factory producer.Component, itemID id.ActionID) *Action {
+	return NewWithID(id.ActionID(id.RandomString(idLength)), dfStatus, factory, Dequeue, gcpb.EntityType_ENTITY_TYPE_UNKNOWN, itemID, 0, nil)
+}
+
+// NewReorder constructs a new order which moves the input item to the input
+// position in the production queue.
+func NewReorder(dfStatus status.ReadOnlyStatus, factory producer.Component, itemID id.ActionID, index int) *Action {
+	return NewWithID(id.ActionID(id.RandomString(idLength)), dfStatus, factory, Reorder, gcpb.EntityType_ENTITY_TYPE_UNKNOWN, itemID, index, nil)
+}
+
+// NewRally constructs a new order which sets the rally point of the factory.
+func NewRally(dfStatus status.ReadOnlyStatus, factory producer.Component, rallyPoint *gdpb.Position) *Action {
+	return NewWithID(id.ActionID(id.RandomString(idLength)), dfStatus, factory, Rally, gcpb.EntityType_ENTITY_TYPE_UNKNOWN, "", 0, rallyPoint)
+}
+
+// NewWithID constructs a new order with a fixed action UUID. This is used to
+// play back recorded games, as the UUID determines both the order in which
+// orders are applied and the IDs of the produced entities.
+func NewWithID(
+	aid id.ActionID,
+	dfStatus status.ReadOnlyStatus,
+	factory producer.Component,
+	orderType OrderType,
+	entityType gcpb.EntityType,
+	itemID id.ActionID,
+	index int,
+	rallyPoint *gdpb.Position) *Action {
+	return &Action{
+		Base:       action.New(FSM, commonstate.Pending),
+		id:         aid,
+		status:     dfStatus,
+		factory:    factory,
+		orderType:  orderType,
+		entityType: entityType,
+		itemID:     itemID,
+		index:      index,
+		rallyPoint: rallyPoint,
+	}
+}
+
+func (a *Action) Accept(ctx context.Context, v visitor.Visitor) error { return v.Visit(ctx, a) }
+func (a *Action) ID() id.ActionID                                     { return a.id }
+func (a *Action) Factory() producer.Component                         { return a.factory }
+func (a *Action) OrderType() OrderType                                { return a.orderType }
+func (a *Action) EntityType() gcpb.EntityType                         { return a.entityType }
+func (a *Action) ItemID() id.ActionID                                 { return a.itemID }
+func (a *Action) Index() int                                          { return a.index }
+func (a *Action) RallyPoint() *gdpb.Position                          { return a.rallyPoint }
+
+// Precedence always defers to the existing order, as each order has a unique
+// ID.
+func (a *Action) Precedence(o action.Action) bool { return false }
+
+func (a *Action) Finish() error {
+	s, err := a.State()
+	if err != nil {
+		return err
+	}
+
+	return a.To(s, commonstate.Finished, false)
+}
+
+func (a *Action) Cancel() error {
+	s, err := a.State()
+	if err != nil {
+		return err
+	}
+
+	return a.To(s, commonstate.Canceled, false)
+}
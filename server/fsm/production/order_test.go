@@ -0,0 +1,51 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/server/entity/factory"
+	"github.com/downflux/game/server/fsm/commonstate"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+var (
+	_ action.Action = &Action{}
+)
+
+func TestState(t *testing.T) {
+	f, err := factory.New(
+		&edpb.FactoryDefinition{
+			EntityType: gcpb.EntityType_ENTITY_TYPE_FACTORY,
+			Health:     100,
+		}, "factory", 0, &gdpb.Position{X: 0, Y: 0}, id.ClientID("client-id"))
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+
+	s := status.New(0)
+
+	finished := NewEnqueue(s, f, gcpb.EntityType_ENTITY_TYPE_TANK)
+	if got, err := finished.State(); err != nil || got != commonstate.Pending {
+		t.Errorf("State() = %v, %v, want = %v, nil", got, err, commonstate.Pending)
+	}
+	if err := finished.Finish(); err != nil {
+		t.Fatalf("Finish() = %v, want = nil", err)
+	}
+	if got, err := finished.State(); err != nil || got != commonstate.Finished {
+		t.Errorf("State() = %v, %v, want = %v, nil", got, err, commonstate.Finished)
+	}
+
+	canceled := NewDequeue(s, f, finished.ID())
+	if err := canceled.Cancel(); err != nil {
+		t.Fatalf("Cancel() = %v, want = nil", err)
+	}
+	if got, err := canceled.State(); err != nil || got != commonstate.Canceled {
+		t.Errorf("State() = %v, %v, want = %v, nil", got, err, commonstate.Canceled)
+	}
+}
@@ -0,0 +1,175 @@
+// Package queue defines the Action used for tracking the production queue of a
+// factory. Each factory owns a single queue, which is created when the factory
+// is produced.
+//
+// A Pending state indicates the queue is empty.
+//
+// An Executing state indicates the queue holds at least one item, and the
+// visitor should advance the build progress of the head of the queue.
+package queue
+
+import (
+	"context"
+
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/fsm"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/server/entity/component/producer"
+	"github.com/downflux/game/server/fsm/commonstate"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+)
+
+const (
+	fsmType = fcpb.FSMType_FSM_TYPE_PRODUCTION_QUEUE
+)
+
+var (
+	transitions = []fsm.Transition{
+		{From: commonstate.Pending, To: commonstate.Executing, VirtualOnly: true},
+		{From: commonstate.Pending, To: commonstate.Canceled},
+		{From: commonstate.Executing, To: commonstate.Canceled},
+		{From: commonstate.Canceled, To: commonstate.Canceled},
+	}
+
+	FSM = fsm.New(transitions, fsmType)
+)
+
+// Item is a single entity waiting to be built by the factory.
+type Item struct {
+	// ID is the ID of the production order which added the item, and is
+	// used to seed the IDs of the produced entities.
+	ID         id.ActionID
+	EntityType gcpb.EntityType
+
+	// Cost is the number of credits charged when the item was added to
+	// the queue, and is refunded if the item is removed before
+	// completion.
+	Cost float64
+
+	// BuildTicks is the total number of ticks required to build the item.
+	BuildTicks float64
+
+	// Progress is the number of ticks the item has been built for.
+	Progress float64
+}
+
+// Action tracks the ordered list of items to be built by a factory. The queue
+// is not thread-safe, and is only mutated by the production queue visitor.
+type Action struct {
+	*action.Base
+
+	tick   id.Tick               // Read-only.
+	source producer.Component    // Read-only.
+	status status.ReadOnlyStatus // Read-only.
+
+	items []*Item
+}
+
+func New(dfStatus status.ReadOnlyStatus, source producer.Component) *Action {
+	return &Action{
+		Base:   action.New(FSM, commonstate.Pending),
+		source: source,
+		status: dfStatus,
+		tick:   dfStatus.Tick(),
+	}
+}
+
+func (a *Action) Accept(ctx context.Context, v visitor.Visitor) error { return v.Visit(ctx, a) }
+func (a *Action) Source() producer.Component                          { return a.source }
+func (a *Action) ID() id.ActionID                                     { return id.ActionID(a.source.ID()) }
+func (a *Action) Status() status.ReadOnlyStatus                       { return a.status }
+
+// Items returns the list of items in the queue, in build order.
+func (a *Action) Items() []*Item { return a.items }
+
+// Head returns the item currently being built, or nil if the queue is empty.
+func (a *Action) Head() *Item {
+	if len(a.items) == 0 {
+		return nil
+	}
+	return a.items[0]
+}
+
+// Enqueue appends the input item to the end of the queue.
+func (a *Action) Enqueue(i *Item) { a.items = append(a.items, i) }
+
+// Pop removes and returns the item currently being built, or nil if the queue
+// is empty.
+func (a *Action) Pop() *Item {
+	i := a.Head()
+	if i != nil {
+		a.items = a.items[1:]
+	}
+	return i
+}
+
+// Dequeue removes the item with the input ID from the queue. Dequeue returns
+// nil if no such item exists.
+func (a *Action) Dequeue(aid id.ActionID) *Item {
+	for j, i := range a.items {
+		if i.ID == aid {
+			a.items = append(a.items[:j:j], a.items[j+1:]...)
+			return i
+		}
+	}
+	return nil
+}
+
+// Reorder moves the item with the input ID to the input position in the queue.
+// Indices past the end of the queue move the item to the back. Reorder returns
+// false if no such item exists.
+//
+// Moving a partially built item away from the head of the queue preserves its
+// build progress.
+func (a *Action) Reorder(aid id.ActionID, index int) bool {
+	i := a.Dequeue(aid)
+	if i == nil {
+		return false
+	}
+	if index < 0 {
+		index = 0
+	}
+	if index > len(a.items) {
+		index = len(a.items)
+	}
+	a.items = append(a.items[:index:index], append([]*Item{i}, a.items[index:]...)...)
+	return true
+}
+
+func (a *Action) Precedence(o action.Action) bool {
+	if o.Type() != fsmType {
+		return false
+	}
+
+	return a.tick > o.(*Action).tick
+}
+
+func (a *Action) State() (fsm.State, error) {
+	s, err := a.Base.State()
+	if err != nil {
+		return commonstate.Unknown, err
+	}
+
+	switch s {
+	case commonstate.Pending:
+		if len(a.items) > 0 {
+			return commonstate.Executing, a.To(s, commonstate.Executing, true)
+		}
+		return s, nil
+	default:
+		return s, nil
+	}
+}
+
+func (a *Action) Cancel() error {
+	s, err := a.State()
+	if err != nil {
+		return err
+	}
+
+	return a.To(s, commonstate.Canceled, false)
+}
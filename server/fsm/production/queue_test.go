@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/server/entity/factory"
+	"github.com/downflux/game/server/fsm/commonstate"
+	"github.com/google/go-cmp/cmp"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+var (
+	_ action.Action = &Action{}
+
+	factoryPB = &edpb.FactoryDefinition{
+		EntityType: gcpb.EntityType_ENTITY_TYPE_FACTORY,
+		Health:     100,
+		Produces:   []gcpb.EntityType{gcpb.EntityType_ENTITY_TYPE_TANK},
+	}
+)
+
+func newQueue(t *testing.T) *Action {
+	f, err := factory.New(factoryPB, "factory", 0, &gdpb.Position{X: 0, Y: 0}, id.ClientID("client-id"))
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	return New(status.New(0), f)
+}
+
+func ids(a *Action) []id.ActionID {
+	var aids []id.ActionID
+	for _, i := range a.Items() {
+		aids = append(aids, i.ID)
+	}
+	return aids
+}
+
+func TestState(t *testing.T) {
+	a := newQueue(t)
+	if got, err := a.State(); err != nil || got != commonstate.Pending {
+		t.Errorf("State() = %v, %v, want = %v, nil", got, err, commonstate.Pending)
+	}
+
+	a.Enqueue(&Item{ID: "item-a"})
+	if got, err := a.State(); err != nil || got != commonstate.Executing {
+		t.Errorf("State() = %v, %v, want = %v, nil", got, err, commonstate.Executing)
+	}
+
+	if err := a.Cancel(); err != nil {
+		t.Fatalf("Cancel() = %v, want = nil", err)
+	}
+	if got, err := a.State(); err != nil || got != commonstate.Canceled {
+		t.Errorf("State() = %v, %v, want = %v, nil", got, err, commonstate.Canceled)
+	}
+}
+
+func TestDequeue(t *testing.T) {
+	a := newQueue(t)
+	for _, aid := range []id.ActionID{"item-a", "item-b", "item-c"} {
+		a.Enqueue(&Item{ID: aid})
+	}
+
+	if got := a.Dequeue("item-b"); got == nil || got.ID != "item-b" {
+		t.Errorf("Dequeue() = %v, want = %v", got, "item-b")
+	}
+	if got := a.Dequeue("item-b"); got != nil {
+		t.Errorf("Dequeue() = %v, want = nil", got)
+	}
+
+	want := []id.ActionID{"item-a", "item-c"}
+	if diff := cmp.Diff(want, ids(a)); diff != "" {
+		t.Errorf("Items() mismatch (-want +got):\n%v", diff)
+	}
+
+	if got := a.Pop(); got == nil || got.ID != "item-a" {
+		t.Errorf("Pop() = %v, want = %v", got, "item-a")
+	}
+	if got := a.Head(); got == nil || got.ID != "item-c" {
+		t.Errorf("Head() = %v, want = %v", got, "item-c")
+	}
+}
+
+func TestReorder(t *testing.T) {
+	testConfigs := []struct {
+		name  string
+		aid   id.ActionID
+		index int
+		ok    bool
+		want  []id.ActionID
+	}{
+		{name: "MoveToFront", aid: "item-c", index: 0, ok: true, want: []id.ActionID{"item-c", "item-a", "item-b"}},
+		{name: "MoveToBack", aid: "item-a", index: 2, ok: true, want: []id.ActionID{"item-b", "item-c", "item-a"}},
+		{name: "MoveOutOfRange", aid: "item-a", index: 100, ok: true, want: []id.ActionID{"item-b", "item-c", "item-a"}},
+		{name: "MoveNegative", aid: "item-b", index: -1, ok: true, want: []id.ActionID{"item-b", "item-a", "item-c"}},
+		{name: "NotFound", aid: "item-d", index: 0, ok: false, want: []id.ActionID{"item-a", "item-b", "item-c"}},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			a := newQueue(t)
+			for _, aid := range []id.ActionID{"item-a", "item-b", "item-c"} {
+				a.Enqueue(&Item{ID: aid})
+			}
+
+			if got := a.Reorder(c.aid, c.index); got != c.ok {
+				t.Errorf("Reorder() = %v, want = %v", got, c.ok)
+			}
+			if diff := cmp.Diff(c.want, ids(a)); diff != "" {
+				t.Errorf("Items() mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
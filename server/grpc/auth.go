@@ -0,0 +1,214 @@
+// Package auth implements session token authentication for client requests.
+//
+// Clients are issued a secret session token when added to a game or lobby.
+// Requests which act on behalf of a client (i.e. which specify a client_id)
+// must carry the session token of that client in the gRPC request metadata.
+//
+// Example
+//
+//  ctx = metadata.AppendToOutgoingContext(ctx, auth.TokenKey, token)
+//  client.Move(ctx, &apipb.MoveRequest{ClientId: cid, ...})
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"sync"
+
+	"github.com/downflux/game/engine/id/id"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// TokenKey is the gRPC metadata key under which the client session
+	// token is sent.
+	TokenKey = "x-downflux-session-token"
+
+	// tokenLen is the number of random bytes in a session token.
+	tokenLen = 32
+)
+
+// clientRequest is implemented by all request protobufs which act on behalf
+// of a specific client.
+type clientRequest interface {
+	GetClientId() string
+}
+
+// Sessions tracks the session tokens of all clients known to the server.
+type Sessions struct {
+	// mux guards the digests property.
+	mux sync.RWMutex
+
+	// digests is the SHA-256 digest of the session token of each client,
+	// hashed by the client UUID. The tokens themselves are not stored, so
+	// that the digests may be persisted, e.g. in a game snapshot.
+	digests map[id.ClientID][]byte
+}
+
+// New constructs a new Sessions instance.
+func New() *Sessions {
+	return &Sessions{
+		digests: map[id.ClientID][]byte{},
+	}
+}
+
+func digest(token string) []byte {
+	d := sha256.Sum256([]byte(token))
+	return d[:]
+}
+
+func generate() (string, error) {
+	b := make([]byte, tokenLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", status.Errorf(codes.Internal, "could not generate session token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Add generates a new secret session token for the input client.
+func (s *Sessions) Add(cid id.ClientID) (string, error) {
+	token, err := generate()
+	if err != nil {
+		return "", err
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if _, found := s.digests[cid]; found {
+		return "", status.Errorf(codes.AlreadyExists, "client %v already has a session", cid)
+	}
+	s.digests[cid] = digest(token)
+	return token, nil
+}
+
+// Reclaim replaces the session token of an existing client, e.g. after the
+// client session has been restored from a game snapshot. The previous token
+// is no longer valid after this call. The caller must have already validated
+// the previous token of the client.
+func (s *Sessions) Reclaim(cid id.ClientID) (string, error) {
+	token, err := generate()
+	if err != nil {
+		return "", err
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if _, found := s.digests[cid]; !found {
+		return "", status.Errorf(codes.NotFound, "client %v does not have a session", cid)
+	}
+	s.digests[cid] = digest(token)
+	return token, nil
+}
+
+// Digest returns the SHA-256 digest of the session token of the input client.
+func (s *Sessions) Digest(cid id.ClientID) ([]byte, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	d, found := s.digests[cid]
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "client %v does not have a session", cid)
+	}
+	return append([]byte{}, d...), nil
+}
+
+// Restore adds a session from the digest of its token, e.g. as exported by
+// Digest. The client authenticates with its previous token, and should call
+// Reclaim to be issued a new token.
+func (s *Sessions) Restore(cid id.ClientID, d []byte) error {
+	if len(d) != sha256.Size {
+		return status.Errorf(codes.InvalidArgument, "invalid session token digest for client %v", cid)
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if _, found := s.digests[cid]; found {
+		return status.Errorf(codes.AlreadyExists, "client %v already has a session", cid)
+	}
+	s.digests[cid] = append([]byte{}, d...)
+	return nil
+}
+
+// Validate checks that the input token is the session token of the input
+// client.
+func (s *Sessions) Validate(cid id.ClientID, token string) error {
+	s.mux.RLock()
+	want, found := s.digests[cid]
+	s.mux.RUnlock()
+
+	if token == "" {
+		return status.Error(codes.Unauthenticated, "missing session token")
+	}
+	if !found || subtle.ConstantTimeCompare(want, digest(token)) != 1 {
+		return status.Errorf(codes.PermissionDenied, "invalid session token for client %v", cid)
+	}
+	return nil
+}
+
+// authorize checks the session token in the request metadata against the
+// client specified by the request. Requests which do not act on behalf of a
+// client are always allowed.
+func (s *Sessions) authorize(ctx context.Context, req interface{}) error {
+	r, ok := req.(clientRequest)
+	if !ok {
+		return nil
+	}
+
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(TokenKey); len(v) > 0 {
+			token = v[0]
+		}
+	}
+	return s.Validate(id.ClientID(r.GetClientId()), token)
+}
+
+// UnaryInterceptor returns a gRPC interceptor which authorizes all unary
+// client requests.
+func (s *Sessions) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := s.authorize(ctx, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// stream wraps a server stream and authorizes each received client request.
+type stream struct {
+	grpc.ServerStream
+	sessions *Sessions
+}
+
+func (s *stream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.sessions.authorize(s.Context(), m)
+}
+
+// StreamInterceptor returns a gRPC interceptor which authorizes all streaming
+// client requests.
+func (s *Sessions) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &stream{ServerStream: ss, sessions: s})
+	}
+}
+
+// ServerOptions returns the gRPC server options which install the session
+// interceptors.
+func (s *Sessions) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.UnaryInterceptor()),
+		grpc.StreamInterceptor(s.StreamInterceptor()),
+	}
+}
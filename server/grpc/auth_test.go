@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/downflux/game/engine/id/id"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	apipb "github.com/downflux/game/api/api_go_proto"
+)
+
+func TestAdd(t *testing.T) {
+	s := New()
+	cid := id.ClientID("client-id")
+
+	token, err := s.Add(cid)
+	if err != nil {
+		t.Fatalf("Add() = _, %v, want = nil", err)
+	}
+	if token == "" {
+		t.Fatal("Add() = \"\", _, want a non-empty value")
+	}
+	if _, err := s.Add(cid); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Add() = _, %v, want = %v", err, codes.AlreadyExists)
+	}
+}
+
+func TestReclaim(t *testing.T) {
+	cid := id.ClientID("client-id")
+
+	s := New()
+	token, err := s.Add(cid)
+	if err != nil {
+		t.Fatalf("Add() = _, %v, want = nil", err)
+	}
+	d, err := s.Digest(cid)
+	if err != nil {
+		t.Fatalf("Digest() = _, %v, want = nil", err)
+	}
+
+	// The restored session accepts the previous token until the client
+	// reclaims its identity.
+	r := New()
+	if err := r.Restore(cid, d); err != nil {
+		t.Fatalf("Restore() = %v, want = nil", err)
+	}
+	if err := r.Validate(cid, token); err != nil {
+		t.Fatalf("Validate() = %v, want = nil", err)
+	}
+
+	reclaimed, err := r.Reclaim(cid)
+	if err != nil {
+		t.Fatalf("Reclaim() = _, %v, want = nil", err)
+	}
+	if err := r.Validate(cid, reclaimed); err != nil {
+		t.Errorf("Validate() = %v, want = nil", err)
+	}
+	if err := r.Validate(cid, token); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Validate() = %v, want = %v", err, codes.PermissionDenied)
+	}
+
+	if _, err := r.Reclaim(id.ClientID("unknown-client-id")); status.Code(err) != codes.NotFound {
+		t.Errorf("Reclaim() = _, %v, want = %v", err, codes.NotFound)
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	s := New()
+	cid := id.ClientID("client-id")
+	other := id.ClientID("other-client-id")
+
+	token, err := s.Add(cid)
+	if err != nil {
+		t.Fatalf("Add() = _, %v, want = nil", err)
+	}
+	otherToken, err := s.Add(other)
+	if err != nil {
+		t.Fatalf("Add() = _, %v, want = nil", err)
+	}
+
+	testConfigs := []struct {
+		name  string
+		token string
+		req   interface{}
+		want  codes.Code
+	}{
+		{
+			name:  "Valid",
+			token: token,
+			req:   &apipb.MoveRequest{ClientId: cid.Value()},
+			want:  codes.OK,
+		},
+		{
+			name: "Missing",
+			req:  &apipb.MoveRequest{ClientId: cid.Value()},
+			want: codes.Unauthenticated,
+		},
+		{
+			name:  "OtherClient",
+			token: otherToken,
+			req:   &apipb.MoveRequest{ClientId: cid.Value()},
+			want:  codes.PermissionDenied,
+		},
+		{
+			name:  "UnknownClient",
+			token: token,
+			req:   &apipb.MoveRequest{ClientId: "unknown-client-id"},
+			want:  codes.PermissionDenied,
+		},
+		{
+			name: "NoClient",
+			req:  &apipb.GetStatusRequest{},
+			want: codes.OK,
+		},
+	}
+
+	f := s.UnaryInterceptor()
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			if c.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(TokenKey, c.token))
+			}
+
+			_, err := f(ctx, c.req, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, nil
+			})
+			if got := status.Code(err); got != c.want {
+				t.Errorf("UnaryInterceptor() = %v, want = %v", got, c.want)
+			}
+		})
+	}
+}
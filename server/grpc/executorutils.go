@@ -1,8 +1,10 @@
 package executorutils
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/downflux/game/engine/entity/entity"
 	"github.com/downflux/game/engine/fsm/action"
 	"github.com/downflux/game/engine/fsm/schedule"
 	"github.com/downflux/game/engine/gamestate/dirty"
@@ -11,14 +13,24 @@ import (
 	"github.com/downflux/game/engine/server/executor/executor"
 	"github.com/downflux/game/engine/visitor/visitor"
 	"github.com/downflux/game/pathing/hpf/graph"
+	"github.com/downflux/game/server/acl/acl"
 	"github.com/downflux/game/server/entity/component/attackable"
 	"github.com/downflux/game/server/entity/component/moveable"
+	"github.com/downflux/game/server/entity/component/producer"
 	"github.com/downflux/game/server/entity/component/targetable"
+	"github.com/downflux/game/server/entity/player"
+	"github.com/downflux/game/server/entity/registry"
+	"github.com/downflux/game/server/entity/resource"
+	"github.com/downflux/game/server/footprint/footprint"
 	"github.com/downflux/game/server/visitor/attack/attack"
 	"github.com/downflux/game/server/visitor/attack/projectile"
+	"github.com/downflux/game/server/visitor/death"
+	"github.com/downflux/game/server/visitor/harvest"
+	"github.com/downflux/game/server/visitor/move/avoid"
 	"github.com/downflux/game/server/visitor/move/chase"
 	"github.com/downflux/game/server/visitor/move/move"
 	"github.com/downflux/game/server/visitor/produce"
+	"github.com/downflux/game/server/visitor/production/queue"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -31,10 +43,12 @@ import (
 	visitorlist "github.com/downflux/game/engine/visitor/list"
 	mdpb "github.com/downflux/game/map/api/data_go_proto"
 	tile "github.com/downflux/game/map/map"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
 	attackaction "github.com/downflux/game/server/fsm/attack/attack"
 	chaseaction "github.com/downflux/game/server/fsm/move/chase"
 	moveaction "github.com/downflux/game/server/fsm/move/move"
 	produceaction "github.com/downflux/game/server/fsm/produce"
+	orderaction "github.com/downflux/game/server/fsm/production/order"
 )
 
 type Utils struct {
@@ -44,17 +58,38 @@ type Utils struct {
 	// for linking to FSM action constructors and must not be mutated here.
 	// Making read-only calls is okay.
 	gamestate *gamestate.GameState
+
+	// acl tracks which clients may command the units of other clients.
+	acl *acl.ACL
+
+	// tm is the game map. Tiles are only mutated by the footprints
+	// tracker, and are otherwise read-only.
+	tm *tile.Map
+
+	// footprints tracks the map tiles blocked by structures.
+	footprints *footprint.Map
+
+	// registry is the list of unit archetypes which may be produced. This
+	// is read-only.
+	registry *registry.Registry
 }
 
-func New(pb *mdpb.TileMap, d *gdpb.Coordinate, tickDuration time.Duration, minPathLength int) (*Utils, error) {
+// New constructs a new game instance on the input map. The input registry
+// lists the unit archetypes which may be produced in the game.
+func New(pb *mdpb.TileMap, rpb *edpb.Registry, d *gdpb.Coordinate, tickDuration time.Duration, minPathLength int) (*Utils, error) {
 	tm, err := tile.ImportMap(pb)
 	if err != nil {
 		return nil, err
 	}
+	r, err := registry.New(rpb)
+	if err != nil {
+		return nil, err
+	}
 	g, err := graph.BuildGraph(tm, d)
 	if err != nil {
 		return nil, err
 	}
+	footprints := footprint.New(tm, g)
 
 	fsmSchedule := schedule.New([]fcpb.FSMType{
 		fcpb.FSMType_FSM_TYPE_CHASE,
@@ -62,87 +97,359 @@ func New(pb *mdpb.TileMap, d *gdpb.Coordinate, tickDuration time.Duration, minPa
 		fcpb.FSMType_FSM_TYPE_PRODUCE,
 		fcpb.FSMType_FSM_TYPE_ATTACK,
 		fcpb.FSMType_FSM_TYPE_PROJECTILE_SHOOT,
+		fcpb.FSMType_FSM_TYPE_DEATH,
+		fcpb.FSMType_FSM_TYPE_HARVEST,
+		fcpb.FSMType_FSM_TYPE_PRODUCTION_QUEUE,
+		fcpb.FSMType_FSM_TYPE_PRODUCTION_ORDER,
 	})
 
 	state := gamestate.New(serverstatus.New(tickDuration), entitylist.New())
 	dirtystate := dirty.New()
 	visitors, err := visitorlist.New([]visitor.Visitor{
-		produce.New(state.Status(), state.Entities(), dirtystate),
+		// Production orders must be applied before the produce
+		// visitor runs, so that completed items are built in the same
+		// tick.
+		queue.New(state.Status(), state.Entities(), dirtystate, r, fsmSchedule),
+		produce.New(state.Status(), state.Entities(), dirtystate, r, fsmSchedule, footprints),
 		move.New(tm, g, state.Status(), dirtystate, minPathLength),
-		projectile.New(state.Status(), dirtystate),
+		// Units are steered apart after their paths are planned.
+		avoid.New(tm, state.Status(), state.Entities(), dirtystate),
+		projectile.New(state.Status(), dirtystate, fsmSchedule),
+		death.New(state.Status(), dirtystate, fsmSchedule, footprints),
 		chase.New(state.Status(), fsmSchedule),
 		attack.New(state.Status(), dirtystate, fsmSchedule),
+		harvest.New(state.Status(), state.Entities(), dirtystate, fsmSchedule),
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	if err := spawnResourceFields(pb, state.Entities(), dirtystate); err != nil {
+		return nil, err
+	}
+
 	return &Utils{
-		executor:  executor.New(visitors, state, dirtystate, fsmSchedule),
-		gamestate: state,
+		executor:   executor.New(visitors, state, dirtystate, fsmSchedule),
+		gamestate:  state,
+		acl:        acl.New(),
+		tm:         tm,
+		footprints: footprints,
+		registry:   r,
 	}, nil
 }
 
+// spawnResourceFields adds the resource fields listed in the map to the game
+// state. Resource fields are assigned fixed IDs, as they are not produced
+// through the Executor and therefore are not recorded in replays.
+func spawnResourceFields(pb *mdpb.TileMap, entities *entitylist.List, dirtystate *dirty.List) error {
+	for i, fpb := range pb.GetResourceFields() {
+		e, err := resource.New(id.EntityID(fmt.Sprintf("resource-%v", i)), 0, fpb.GetPosition(), fpb.GetResources())
+		if err != nil {
+			return err
+		}
+		if err := entities.Append(e); err != nil {
+			return err
+		}
+		if err := dirtystate.AddEntity(dirty.Entity{ID: e.ID()}); err != nil {
+			return err
+		}
+		for _, property := range e.Curves().Properties() {
+			if err := dirtystate.AddCurve(dirty.Curve{EntityID: e.ID(), Property: property}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (u *Utils) Executor() *executor.Executor        { return u.executor }
 func (u *Utils) Status() serverstatus.ReadOnlyStatus { return u.gamestate.Status() }
+func (u *Utils) Registry() *registry.Registry        { return u.registry }
 
-// Move transforms the player MoveRequest input into a list of move actions
-// and schedules them to be executed in the next tick.
-func (u *Utils) Move(pb *apipb.MoveRequest) error {
-	// TODO(minkezhang): If tick outside window, return error.
+// Footprints returns the tracker of the map tiles blocked by structures.
+func (u *Utils) Footprints() *footprint.Map { return u.footprints }
 
-	// TODO(minkezhang): Return list of errors instead.
-	for _, eid := range pb.GetEntityIds() {
-		m, ok := u.gamestate.Entities().Get(id.EntityID(eid)).(moveable.Component)
-		if !ok {
-			return status.Error(codes.FailedPrecondition, "specified entity is not moveable")
-		}
+// GameState returns the game state tracked by the Executor. Callers must treat
+// the returned state as read-only.
+func (u *Utils) GameState() *gamestate.GameState { return u.gamestate }
 
-		if err := u.executor.Schedule(
-			[]action.Action{
-				moveaction.New(m, u.Status(), pb.GetDestination(), moveaction.Default),
-			}); err != nil {
-			return err
-		}
+// ACL returns the shared control list of the game.
+func (u *Utils) ACL() *acl.ACL { return u.acl }
+
+// controls checks if the input client may command the input entity.
+func (u *Utils) controls(e entity.Entity, cid id.ClientID) bool {
+	tick := u.Status().Tick()
+	return u.acl.Allowed(e.ClientID(tick), cid, tick)
+}
+
+// Friendly checks if the two input entities are owned by the same client or by
+// allied clients. Entities which are not owned by any client are not friendly
+// to any other entity.
+func (u *Utils) Friendly(a, b entity.Entity) bool {
+	tick := u.Status().Tick()
+	p, q := a.ClientID(tick), b.ClientID(tick)
+	if p == "" || q == "" {
+		return false
 	}
+	return u.executor.Allied(p, q)
+}
 
-	return nil
+// inBounds checks if the input position lies within the game map.
+func (u *Utils) inBounds(p *gdpb.Position) bool {
+	return p.GetX() >= 0 && p.GetY() >= 0 && u.tm.Tile(int32(p.GetX()), int32(p.GetY())) != nil
 }
 
-func (u *Utils) Attack(pb *apipb.AttackRequest) error {
-	t, ok := u.gamestate.Entities().Get(id.EntityID(pb.GetTargetEntityId())).(targetable.Component)
-	if !ok {
-		return status.Error(codes.FailedPrecondition, "specified entity is not targetable")
+func result(eid string, s gcpb.CommandStatus) *apipb.EntityCommandResult {
+	return &apipb.EntityCommandResult{
+		EntityId: eid,
+		Status:   s,
 	}
+}
+
+// Move transforms the player MoveRequest input into a list of move actions.
+// The actions are applied at the tick at which the request was issued -- if
+// the request was issued at an earlier tick, the game is rolled back, and if
+// the request was issued at a later tick, the actions are deferred. Requests
+// issued outside of the Executor command window are rejected.
+//
+// Move returns a result for each requested entity. Entities which may not be
+// moved (e.g. entities which the requesting client may not command) are
+// skipped, and the remaining entities are still moved.
+func (u *Utils) Move(pb *apipb.MoveRequest) ([]*apipb.EntityCommandResult, error) {
+	var results []*apipb.EntityCommandResult
+	err := u.executor.ScheduleAt(id.Tick(pb.GetTick()), func() ([]action.Action, error) {
+		results = nil
+		var actions []action.Action
+
+		for _, eid := range pb.GetEntityIds() {
+			e := u.gamestate.Entities().Get(id.EntityID(eid))
+			if e == nil || e.End() != 0 {
+				results = append(results, result(eid, gcpb.CommandStatus_COMMAND_STATUS_NOT_FOUND))
+				continue
+			}
+			m, ok := e.(moveable.Component)
+			if !ok {
+				results = append(results, result(eid, gcpb.CommandStatus_COMMAND_STATUS_NOT_MOVEABLE))
+				continue
+			}
+			if !u.controls(e, id.ClientID(pb.GetClientId())) {
+				results = append(results, result(eid, gcpb.CommandStatus_COMMAND_STATUS_NOT_OWNED))
+				continue
+			}
+			if !u.inBounds(pb.GetDestination()) {
+				results = append(results, result(eid, gcpb.CommandStatus_COMMAND_STATUS_OUT_OF_BOUNDS))
+				continue
+			}
 
-	// TODO(minkezhang): Return list of errors instead.
-	for _, eid := range pb.GetEntityIds() {
-		e := u.gamestate.Entities().Get(id.EntityID(eid))
-		a, ok := e.(attackable.Component)
-		if !ok {
-			return status.Error(codes.FailedPrecondition, "specified entity is not attackable")
+			results = append(results, result(eid, gcpb.CommandStatus_COMMAND_STATUS_ACCEPTED))
+			actions = append(
+				actions,
+				moveaction.New(m, u.Status(), pb.GetDestination(), moveaction.Default))
 		}
-		m, ok := e.(moveable.Component)
-		if !ok {
-			return status.Error(codes.FailedPrecondition, "specified entity is not moveable")
+		return actions, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Attack transforms the player AttackRequest input into a list of chase and
+// attack actions. As in Move, the actions are applied at the tick at which the
+// request was issued, and a result is returned for each requested entity.
+//
+// Entities may not attack friendly targets unless the request is issued as a
+// force-fire.
+func (u *Utils) Attack(pb *apipb.AttackRequest) ([]*apipb.EntityCommandResult, error) {
+	var results []*apipb.EntityCommandResult
+	err := u.executor.ScheduleAt(id.Tick(pb.GetTick()), func() ([]action.Action, error) {
+		results = nil
+
+		target := u.gamestate.Entities().Get(id.EntityID(pb.GetTargetEntityId()))
+		t, ok := target.(targetable.Component)
+		if !ok || target.End() != 0 {
+			return nil, status.Error(codes.FailedPrecondition, "specified entity is not targetable")
 		}
 
-		chaseAction := chaseaction.New(u.Status(), m, t)
-		attackAction := attackaction.New(u.Status(), a, t, chaseAction)
+		var actions []action.Action
 
-		if err := u.executor.Schedule(
-			[]action.Action{chaseAction, attackAction},
-		); err != nil {
-			return err
+		for _, eid := range pb.GetEntityIds() {
+			e := u.gamestate.Entities().Get(id.EntityID(eid))
+			if e == nil || e.End() != 0 {
+				results = append(results, result(eid, gcpb.CommandStatus_COMMAND_STATUS_NOT_FOUND))
+				continue
+			}
+			a, ok := e.(attackable.Component)
+			if !ok {
+				results = append(results, result(eid, gcpb.CommandStatus_COMMAND_STATUS_NOT_ATTACKABLE))
+				continue
+			}
+			m, ok := e.(moveable.Component)
+			if !ok {
+				results = append(results, result(eid, gcpb.CommandStatus_COMMAND_STATUS_NOT_MOVEABLE))
+				continue
+			}
+			if !u.controls(e, id.ClientID(pb.GetClientId())) {
+				results = append(results, result(eid, gcpb.CommandStatus_COMMAND_STATUS_NOT_OWNED))
+				continue
+			}
+			if !pb.GetForceFire() && u.Friendly(e, target) {
+				results = append(results, result(eid, gcpb.CommandStatus_COMMAND_STATUS_FRIENDLY_TARGET))
+				continue
+			}
+
+			chaseAction := chaseaction.New(u.Status(), m, t)
+			attackAction := attackaction.New(u.Status(), a, t, chaseAction)
+
+			results = append(results, result(eid, gcpb.CommandStatus_COMMAND_STATUS_ACCEPTED))
+			actions = append(actions, chaseAction, attackAction)
 		}
+		return actions, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return results, nil
 }
 
-// ProduceDebug schedules adding a new entity in the next game tick.
+// ProduceDebug schedules adding a new entity in the next game tick. The entity
+// is not charged to or owned by any client, and therefore may not be
+// commanded by any client.
 func (u *Utils) ProduceDebug(entityType gcpb.EntityType, spawnPosition *gdpb.Position) error {
 	// TODO(minkezhang): Use arbitrary client-id after implementing
 	// per-instance ACLs and setting to PublicWritable here.
+	return u.produce(entityType, spawnPosition, id.ClientID(""), produceaction.Free)
+}
+
+// ProduceFree schedules adding a new entity owned by the input client in the
+// next game tick without charging the client, e.g. for the starting units of
+// the client.
+func (u *Utils) ProduceFree(entityType gcpb.EntityType, spawnPosition *gdpb.Position, cid id.ClientID) error {
+	return u.produce(entityType, spawnPosition, cid, produceaction.Free)
+}
+
+// AddMember registers an existing client with the game on the input team, and
+// schedules creating the account of the client. This is called when setting up
+// a new game, before the game starts.
+func (u *Utils) AddMember(cid id.ClientID, team int32) error {
+	if err := u.Executor().AddClientWithID(cid); err != nil {
+		return err
+	}
+	if err := u.Executor().SetClientTeam(cid, team); err != nil {
+		return err
+	}
+	return u.AddAccount(cid)
+}
+
+// AddAccount schedules creating the player entity of the input client, which
+// tracks the credits of the client. The client is granted the starting credits
+// listed in the registry.
+func (u *Utils) AddAccount(cid id.ClientID) error {
+	return u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_PLAYER, nil, cid)
+}
+
+// producer returns the factory with the input ID, if the factory may be
+// commanded by the input client.
+func (u *Utils) producer(eid string, cid id.ClientID) (producer.Component, error) {
+	e := u.gamestate.Entities().Get(id.EntityID(eid))
+	if e == nil || e.End() != 0 {
+		return nil, status.Errorf(codes.NotFound, "entity %v does not exist", eid)
+	}
+	f, ok := e.(producer.Component)
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "entity %v cannot produce entities", eid)
+	}
+	if !u.controls(e, cid) {
+		return nil, status.Errorf(codes.PermissionDenied, "client %v may not command entity %v", cid, eid)
+	}
+	return f, nil
+}
+
+// Produce adds a new entity to the back of the production queue of the
+// specified factory. The cost of the entity is deducted from the credits of
+// the owner of the factory when the order is applied; if the owner cannot
+// afford the entity at that time, the entity is not added to the queue.
+//
+// As in Move, the order is applied at the tick at which the request was
+// issued. Produce returns the production ID of the queued entity.
+func (u *Utils) Produce(pb *apipb.ProduceRequest) (id.ActionID, error) {
+	var aid id.ActionID
+	err := u.executor.ScheduleAt(id.Tick(pb.GetTick()), func() ([]action.Action, error) {
+		f, err := u.producer(pb.GetEntityId(), id.ClientID(pb.GetClientId()))
+		if err != nil {
+			return nil, err
+		}
+		if !f.CanProduce(pb.GetEntityType()) {
+			return nil, status.Errorf(codes.FailedPrecondition, "entity %v cannot produce %v entities", pb.GetEntityId(), pb.GetEntityType())
+		}
+
+		tick := u.Status().Tick()
+		cost, _ := u.registry.Cost(pb.GetEntityType())
+		if cost > 0 {
+			owner := u.gamestate.Entities().Get(f.ID()).ClientID(tick)
+			p, ok := u.gamestate.Entities().Get(player.ID(owner)).(*player.Entity)
+			if !ok || p.Credits(tick) < cost {
+				return nil, status.Errorf(codes.FailedPrecondition, "client %v cannot afford a %v entity", owner, pb.GetEntityType())
+			}
+		}
+
+		o := orderaction.NewEnqueue(u.Status(), f, pb.GetEntityType())
+		aid = o.ID()
+		return []action.Action{o}, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return aid, nil
+}
+
+// CancelProduce removes the specified entity from the production queue of the
+// specified factory, and refunds the cost of the entity. Entities which have
+// already been built may not be canceled.
+func (u *Utils) CancelProduce(pb *apipb.CancelProduceRequest) error {
+	return u.executor.ScheduleAt(id.Tick(pb.GetTick()), func() ([]action.Action, error) {
+		f, err := u.producer(pb.GetEntityId(), id.ClientID(pb.GetClientId()))
+		if err != nil {
+			return nil, err
+		}
+		return []action.Action{
+			orderaction.NewDequeue(u.Status(), f, id.ActionID(pb.GetProductionId())),
+		}, nil
+	})
+}
+
+// ReorderProduce moves the specified entity to a new position in the
+// production queue of the specified factory.
+func (u *Utils) ReorderProduce(pb *apipb.ReorderProduceRequest) error {
+	return u.executor.ScheduleAt(id.Tick(pb.GetTick()), func() ([]action.Action, error) {
+		f, err := u.producer(pb.GetEntityId(), id.ClientID(pb.GetClientId()))
+		if err != nil {
+			return nil, err
+		}
+		return []action.Action{
+			orderaction.NewReorder(u.Status(), f, id.ActionID(pb.GetProductionId()), int(pb.GetIndex())),
+		}, nil
+	})
+}
+
+// SetRallyPoint sets the position to which entities produced by the specified
+// factory move after being built.
+func (u *Utils) SetRallyPoint(pb *apipb.SetRallyPointRequest) error {
+	return u.executor.ScheduleAt(id.Tick(pb.GetTick()), func() ([]action.Action, error) {
+		f, err := u.producer(pb.GetEntityId(), id.ClientID(pb.GetClientId()))
+		if err != nil {
+			return nil, err
+		}
+		if !u.inBounds(pb.GetRallyPoint()) {
+			return nil, status.Errorf(codes.OutOfRange, "rally point %v is out of bounds", pb.GetRallyPoint())
+		}
+		return []action.Action{
+			orderaction.NewRally(u.Status(), f, pb.GetRallyPoint()),
+		}, nil
+	})
+}
+
+func (u *Utils) produce(entityType gcpb.EntityType, spawnPosition *gdpb.Position, cid id.ClientID, p produceaction.PaymentType) error {
 	return u.executor.Schedule(
 		[]action.Action{
 			produceaction.New(
@@ -150,6 +457,7 @@ func (u *Utils) ProduceDebug(entityType gcpb.EntityType, spawnPosition *gdpb.Pos
 				u.Status().Tick(),
 				entityType,
 				spawnPosition,
-				id.ClientID("")),
+				cid,
+				p),
 		})
 }
@@ -0,0 +1,310 @@
+package executorutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/registrytest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apipb "github.com/downflux/game/api/api_go_proto"
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	mcpb "github.com/downflux/game/map/api/constants_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+const (
+	minPathLength = 8
+)
+
+var (
+	tickDuration = 100 * time.Millisecond
+
+	/**
+	 * Y = 0 - - - -
+	 *   X = 0
+	 */
+	simpleLinearMapProto = &mdpb.TileMap{
+		Dimension: &gdpb.Coordinate{X: 4, Y: 1},
+		Tiles: []*mdpb.Tile{
+			{Coordinate: &gdpb.Coordinate{X: 0, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
+			{Coordinate: &gdpb.Coordinate{X: 1, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
+			{Coordinate: &gdpb.Coordinate{X: 2, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
+			{Coordinate: &gdpb.Coordinate{X: 3, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
+		},
+	}
+
+	registryPB = func() *edpb.Registry {
+		pb := registrytest.Registry()
+		pb.Factories = []*edpb.FactoryDefinition{
+			{
+				EntityType: gcpb.EntityType_ENTITY_TYPE_FACTORY,
+				Health:     100,
+				Produces:   []gcpb.EntityType{gcpb.EntityType_ENTITY_TYPE_TANK},
+			},
+		}
+		return pb
+	}()
+)
+
+// newTank constructs a new game with a single tank owned by the input client.
+func newTank(t *testing.T, cid id.ClientID) (*Utils, id.EntityID) {
+	u, err := New(simpleLinearMapProto, registryPB, &gdpb.Coordinate{X: 2, Y: 1}, tickDuration, minPathLength)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	if err := u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid); err != nil {
+		t.Fatalf("ProduceFree() = %v, want = nil", err)
+	}
+	if err := u.Executor().Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	var eid id.EntityID
+	for _, e := range u.GameState().Entities().Iter() {
+		if e.Type() == gcpb.EntityType_ENTITY_TYPE_TANK {
+			eid = e.ID()
+		}
+	}
+	return u, eid
+}
+
+func TestMoveOwnership(t *testing.T) {
+	owner := id.ClientID("owner")
+	ally := id.ClientID("ally")
+
+	u, eid := newTank(t, owner)
+
+	move := func(cid id.ClientID) gcpb.CommandStatus {
+		results, err := u.Move(&apipb.MoveRequest{
+			Tick:        u.Status().Tick().Value(),
+			ClientId:    cid.Value(),
+			EntityIds:   []string{eid.Value()},
+			Destination: &gdpb.Position{X: 3, Y: 0},
+		})
+		if err != nil {
+			t.Fatalf("Move() = _, %v, want = nil", err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("len() = %v, want = %v", len(results), 1)
+		}
+		return results[0].GetStatus()
+	}
+
+	testConfigs := []struct {
+		name string
+		f    func()
+		cid  id.ClientID
+		want gcpb.CommandStatus
+	}{
+		{name: "Owner", cid: owner, want: gcpb.CommandStatus_COMMAND_STATUS_ACCEPTED},
+		{name: "NotGranted", cid: ally, want: gcpb.CommandStatus_COMMAND_STATUS_NOT_OWNED},
+		{name: "Granted", f: func() { u.ACL().Grant(owner, ally, u.Status().Tick()) }, cid: ally, want: gcpb.CommandStatus_COMMAND_STATUS_ACCEPTED},
+		{name: "Revoked", f: func() { u.ACL().Revoke(owner, ally, u.Status().Tick()) }, cid: ally, want: gcpb.CommandStatus_COMMAND_STATUS_NOT_OWNED},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if c.f != nil {
+				c.f()
+			}
+			if got := move(c.cid); got != c.want {
+				t.Errorf("Move() = %v, want = %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestMoveResults(t *testing.T) {
+	cid := id.ClientID("client-id")
+	u, eid := newTank(t, cid)
+
+	testConfigs := []struct {
+		name        string
+		eids        []string
+		destination *gdpb.Position
+		want        []gcpb.CommandStatus
+	}{
+		{
+			name:        "Stale",
+			eids:        []string{"stale-entity-id", eid.Value()},
+			destination: &gdpb.Position{X: 3, Y: 0},
+			want: []gcpb.CommandStatus{
+				gcpb.CommandStatus_COMMAND_STATUS_NOT_FOUND,
+				gcpb.CommandStatus_COMMAND_STATUS_ACCEPTED,
+			},
+		},
+		{
+			name:        "OutOfBounds",
+			eids:        []string{eid.Value()},
+			destination: &gdpb.Position{X: 3, Y: 1},
+			want:        []gcpb.CommandStatus{gcpb.CommandStatus_COMMAND_STATUS_OUT_OF_BOUNDS},
+		},
+		{
+			name:        "Negative",
+			eids:        []string{eid.Value()},
+			destination: &gdpb.Position{X: -0.5, Y: 0},
+			want:        []gcpb.CommandStatus{gcpb.CommandStatus_COMMAND_STATUS_OUT_OF_BOUNDS},
+		},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			results, err := u.Move(&apipb.MoveRequest{
+				Tick:        u.Status().Tick().Value(),
+				ClientId:    cid.Value(),
+				EntityIds:   c.eids,
+				Destination: c.destination,
+			})
+			if err != nil {
+				t.Fatalf("Move() = _, %v, want = nil", err)
+			}
+			if len(results) != len(c.want) {
+				t.Fatalf("len() = %v, want = %v", len(results), len(c.want))
+			}
+
+			for i, r := range results {
+				if r.GetEntityId() != c.eids[i] {
+					t.Errorf("GetEntityId() = %v, want = %v", r.GetEntityId(), c.eids[i])
+				}
+				if r.GetStatus() != c.want[i] {
+					t.Errorf("GetStatus() = %v, want = %v", r.GetStatus(), c.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAttackFriendly(t *testing.T) {
+	u, err := New(simpleLinearMapProto, registryPB, &gdpb.Coordinate{X: 2, Y: 1}, tickDuration, minPathLength)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+
+	teams := map[id.ClientID]int32{
+		"client-a": 1,
+		"client-b": 1,
+		"client-c": 2,
+	}
+	var x float64
+	for cid, team := range teams {
+		if err := u.Executor().AddClientWithID(cid); err != nil {
+			t.Fatalf("AddClientWithID() = %v, want = nil", err)
+		}
+		if err := u.Executor().SetClientTeam(cid, team); err != nil {
+			t.Fatalf("SetClientTeam() = %v, want = nil", err)
+		}
+		if err := u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: x, Y: 0}, cid); err != nil {
+			t.Fatalf("ProduceFree() = %v, want = nil", err)
+		}
+		x++
+	}
+	if err := u.Executor().Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	tanks := map[id.ClientID]id.EntityID{}
+	for _, e := range u.GameState().Entities().Iter() {
+		if e.Type() == gcpb.EntityType_ENTITY_TYPE_TANK {
+			tanks[e.ClientID(u.Status().Tick())] = e.ID()
+		}
+	}
+
+	testConfigs := []struct {
+		name      string
+		target    id.ClientID
+		forceFire bool
+		want      gcpb.CommandStatus
+	}{
+		{name: "Ally", target: "client-b", want: gcpb.CommandStatus_COMMAND_STATUS_FRIENDLY_TARGET},
+		{name: "Self", target: "client-a", want: gcpb.CommandStatus_COMMAND_STATUS_FRIENDLY_TARGET},
+		{name: "AllyForceFire", target: "client-b", forceFire: true, want: gcpb.CommandStatus_COMMAND_STATUS_ACCEPTED},
+		{name: "Enemy", target: "client-c", want: gcpb.CommandStatus_COMMAND_STATUS_ACCEPTED},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			results, err := u.Attack(&apipb.AttackRequest{
+				Tick:           u.Status().Tick().Value(),
+				ClientId:       "client-a",
+				EntityIds:      []string{tanks["client-a"].Value()},
+				TargetEntityId: tanks[c.target].Value(),
+				ForceFire:      c.forceFire,
+			})
+			if err != nil {
+				t.Fatalf("Attack() = _, %v, want = nil", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("len() = %v, want = %v", len(results), 1)
+			}
+			if got := results[0].GetStatus(); got != c.want {
+				t.Errorf("GetStatus() = %v, want = %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestProduce(t *testing.T) {
+	owner := id.ClientID("owner")
+
+	u, tid := newTank(t, owner)
+	if err := u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_FACTORY, &gdpb.Position{X: 1, Y: 0}, owner); err != nil {
+		t.Fatalf("ProduceFree() = %v, want = nil", err)
+	}
+	if err := u.Executor().Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	var fid id.EntityID
+	for _, e := range u.GameState().Entities().Iter() {
+		if e.Type() == gcpb.EntityType_ENTITY_TYPE_FACTORY {
+			fid = e.ID()
+		}
+	}
+
+	testConfigs := []struct {
+		name       string
+		eid        id.EntityID
+		cid        id.ClientID
+		entityType gcpb.EntityType
+		want       codes.Code
+	}{
+		{name: "NotFound", eid: "stale-entity-id", cid: owner, entityType: gcpb.EntityType_ENTITY_TYPE_TANK, want: codes.NotFound},
+		{name: "NotProducer", eid: tid, cid: owner, entityType: gcpb.EntityType_ENTITY_TYPE_TANK, want: codes.FailedPrecondition},
+		{name: "NotProduced", eid: fid, cid: owner, entityType: gcpb.EntityType_ENTITY_TYPE_FACTORY, want: codes.FailedPrecondition},
+		{name: "NotOwned", eid: fid, cid: "other", entityType: gcpb.EntityType_ENTITY_TYPE_TANK, want: codes.PermissionDenied},
+		{name: "Accepted", eid: fid, cid: owner, entityType: gcpb.EntityType_ENTITY_TYPE_TANK, want: codes.OK},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := u.Produce(&apipb.ProduceRequest{
+				Tick:       u.Status().Tick().Value(),
+				ClientId:   c.cid.Value(),
+				EntityId:   c.eid.Value(),
+				EntityType: c.entityType,
+			})
+			if got := status.Code(err); got != c.want {
+				t.Errorf("Produce() = _, %v, want = %v", err, c.want)
+			}
+		})
+	}
+
+	if err := u.Executor().Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	var tanks int
+	for _, e := range u.GameState().Entities().Iter() {
+		if e.Type() == gcpb.EntityType_ENTITY_TYPE_TANK {
+			tanks++
+		}
+	}
+	if tanks != 2 {
+		t.Errorf("len(tanks) = %v, want = %v", tanks, 2)
+	}
+}
@@ -0,0 +1,327 @@
+// Package lobby implements pre-game lobbies, in which players gather and pick
+// their starting positions before the game is created.
+package lobby
+
+import (
+	"sync"
+	"time"
+
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/grpc/executorutils"
+	"github.com/downflux/game/server/grpc/manager"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apipb "github.com/downflux/game/api/api_go_proto"
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+)
+
+const (
+	// idLen represents the length of generated LobbyID and ClientID
+	// values.
+	idLen = 8
+)
+
+var (
+	// defaultStartingUnits is the list of units each player starts with
+	// if the lobby does not specify a list.
+	defaultStartingUnits = []gcpb.EntityType{gcpb.EntityType_ENTITY_TYPE_TANK}
+)
+
+// player represents a single player in the lobby.
+type player struct {
+	cid   id.ClientID // Read-only.
+	team  int32
+	slot  int
+	ready bool
+}
+
+// Lobby tracks the game settings and player list of a single pre-game lobby.
+type Lobby struct {
+	id            id.LobbyID        // Read-only.
+	mapName       string            // Read-only.
+	tickDuration  time.Duration     // Read-only.
+	startingUnits []gcpb.EntityType // Read-only.
+	slots         []*mdpb.SpawnSlot // Read-only.
+
+	// mux guards the players and gameID properties.
+	mux sync.Mutex
+
+	// players is the list of players, in the order in which the players
+	// joined the lobby. The first player is the lobby host.
+	players []*player
+
+	// gameID is the ID of the game created from the lobby, and is set
+	// once the lobby has started.
+	gameID id.GameID
+}
+
+// PB exports the Lobby instance into an associated protobuf.
+func (l *Lobby) PB() *apipb.Lobby {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	pb := &apipb.Lobby{
+		LobbyId:     l.id.Value(),
+		MapName:     l.mapName,
+		NSpawnSlots: int32(len(l.slots)),
+		GameId:      l.gameID.Value(),
+	}
+	for _, p := range l.players {
+		pb.Players = append(pb.GetPlayers(), &apipb.LobbyPlayer{
+			ClientId:  p.cid.Value(),
+			Team:      p.team,
+			SpawnSlot: int32(p.slot),
+			Ready:     p.ready,
+		})
+	}
+	return pb
+}
+
+// playerUnsafe returns the specified player. The caller must hold the lobby
+// mutex.
+func (l *Lobby) playerUnsafe(cid id.ClientID) (*player, error) {
+	for _, p := range l.players {
+		if p.cid == cid {
+			return p, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "client %v not found in lobby %v", cid, l.id)
+}
+
+// update applies the input function to the specified player. Lobbies may not
+// be modified after the game has started.
+func (l *Lobby) update(cid id.ClientID, f func(p *player) error) error {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.gameID != "" {
+		return status.Errorf(codes.FailedPrecondition, "lobby %v has already started", l.id)
+	}
+	p, err := l.playerUnsafe(cid)
+	if err != nil {
+		return err
+	}
+	return f(p)
+}
+
+// freeSlotUnsafe returns the first spawn slot which is not claimed by any
+// player, or -1 if all slots are claimed. The caller must hold the lobby
+// mutex.
+func (l *Lobby) freeSlotUnsafe() int {
+	claimed := map[int]bool{}
+	for _, p := range l.players {
+		claimed[p.slot] = true
+	}
+	for i := range l.slots {
+		if !claimed[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+// join adds a new player with the input UUID to the lobby, and returns the
+// spawn slot assigned to the player.
+func (l *Lobby) join(cid id.ClientID) (int, error) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.gameID != "" {
+		return 0, status.Errorf(codes.FailedPrecondition, "lobby %v has already started", l.id)
+	}
+	if _, err := l.playerUnsafe(cid); err == nil {
+		return 0, status.Errorf(codes.AlreadyExists, "client %v already exists in lobby %v", cid, l.id)
+	}
+	slot := l.freeSlotUnsafe()
+	if slot < 0 {
+		return 0, status.Errorf(codes.ResourceExhausted, "lobby %v is full", l.id)
+	}
+	l.players = append(l.players, &player{
+		cid:  cid,
+		slot: slot,
+	})
+	return slot, nil
+}
+
+// SelectTeam sets the team of the specified player.
+func (l *Lobby) SelectTeam(cid id.ClientID, team int32) error {
+	return l.update(cid, func(p *player) error {
+		p.team = team
+		p.ready = false
+		return nil
+	})
+}
+
+// SelectSpawnSlot moves the specified player to the input spawn slot, which
+// must not be claimed by another player.
+func (l *Lobby) SelectSpawnSlot(cid id.ClientID, slot int) error {
+	return l.update(cid, func(p *player) error {
+		if slot < 0 || slot >= len(l.slots) {
+			return status.Errorf(codes.OutOfRange, "spawn slot %v is outside of the range [0, %v)", slot, len(l.slots))
+		}
+		for _, q := range l.players {
+			if q != p && q.slot == slot {
+				return status.Errorf(codes.FailedPrecondition, "spawn slot %v is claimed by client %v", slot, q.cid)
+			}
+		}
+		p.slot = slot
+		p.ready = false
+		return nil
+	})
+}
+
+// SetReady marks the specified player as ready to start the game. Changing
+// the team or spawn slot of the player resets the ready state.
+func (l *Lobby) SetReady(cid id.ClientID, ready bool) error {
+	return l.update(cid, func(p *player) error {
+		p.ready = ready
+		return nil
+	})
+}
+
+// setupUnsafe registers all lobby players and their teams with the newly
+// created game, and spawns the account and starting units of each player at
+// the claimed spawn slot. Starting units are not charged to the player.
+//
+// The caller must hold the lobby mutex.
+func (l *Lobby) setupUnsafe(u *executorutils.Utils) error {
+	for _, p := range l.players {
+		if err := u.AddMember(p.cid, p.team); err != nil {
+			return err
+		}
+		positions := l.slots[p.slot].GetPositions()
+		for i, t := range l.startingUnits {
+			if err := u.ProduceFree(t, positions[i%len(positions)], p.cid); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// start creates a new game from the lobby settings. The game may only be
+// started by the lobby host, once all players are ready.
+func (l *Lobby) start(m *manager.Manager, cid id.ClientID) (id.GameID, error) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.gameID != "" {
+		return "", status.Errorf(codes.FailedPrecondition, "lobby %v has already started", l.id)
+	}
+	if len(l.players) == 0 || l.players[0].cid != cid {
+		return "", status.Errorf(codes.PermissionDenied, "only the lobby host may start lobby %v", l.id)
+	}
+	for _, p := range l.players {
+		if !p.ready {
+			return "", status.Errorf(codes.FailedPrecondition, "client %v is not ready", p.cid)
+		}
+	}
+
+	gid, err := m.Create(cid, l.mapName, l.tickDuration, l.setupUnsafe)
+	if err != nil {
+		return "", err
+	}
+	l.gameID = gid
+	return gid, nil
+}
+
+// List tracks all lobbies hosted by the server.
+type List struct {
+	manager *manager.Manager // Read-only.
+
+	// mux guards the lobbies property.
+	mux     sync.Mutex
+	lobbies map[id.LobbyID]*Lobby
+}
+
+// New constructs a new List instance. Games are created via the input
+// Manager when a lobby is started.
+func New(m *manager.Manager) *List {
+	return &List{
+		manager: m,
+		lobbies: map[id.LobbyID]*Lobby{},
+	}
+}
+
+// Create constructs a new lobby on the specified map. Each spawn slot on the
+// map must specify at least one position. The default tick duration of the
+// Manager is used if the input tick duration is zero.
+func (l *List) Create(mapName string, tickDuration time.Duration, startingUnits []gcpb.EntityType) (id.LobbyID, error) {
+	pb, err := l.manager.Map(mapName)
+	if err != nil {
+		return "", err
+	}
+	if len(pb.GetSpawnSlots()) == 0 {
+		return "", status.Errorf(codes.FailedPrecondition, "map %v does not specify any spawn slots", mapName)
+	}
+	for i, s := range pb.GetSpawnSlots() {
+		if len(s.GetPositions()) == 0 {
+			return "", status.Errorf(codes.FailedPrecondition, "spawn slot %v of map %v does not specify any positions", i, mapName)
+		}
+	}
+	if tickDuration < 0 {
+		return "", status.Errorf(codes.InvalidArgument, "invalid tick duration %v", tickDuration)
+	}
+	if tickDuration == 0 {
+		tickDuration = l.manager.TickDuration()
+	}
+	if len(startingUnits) == 0 {
+		startingUnits = defaultStartingUnits
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	lid := id.LobbyID(id.RandomString(idLen))
+	for _, found := l.lobbies[lid]; found; _, found = l.lobbies[lid] {
+		lid = id.LobbyID(id.RandomString(idLen))
+	}
+	l.lobbies[lid] = &Lobby{
+		id:            lid,
+		mapName:       mapName,
+		tickDuration:  tickDuration,
+		startingUnits: append([]gcpb.EntityType{}, startingUnits...),
+		slots:         pb.GetSpawnSlots(),
+	}
+	return lid, nil
+}
+
+// Get returns the specified lobby.
+func (l *List) Get(lid id.LobbyID) (*Lobby, error) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	lobby, found := l.lobbies[lid]
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "lobby %v not found", lid)
+	}
+	return lobby, nil
+}
+
+// Join adds a new player to the specified lobby. The returned client ID is
+// used by the player both in the lobby and in the game created from the lobby.
+func (l *List) Join(lid id.LobbyID) (id.ClientID, int, error) {
+	lobby, err := l.Get(lid)
+	if err != nil {
+		return "", 0, err
+	}
+
+	cid := id.ClientID(id.RandomString(idLen))
+	slot, err := lobby.join(cid)
+	if err != nil {
+		return "", 0, err
+	}
+	return cid, slot, nil
+}
+
+// Start creates a new game from the specified lobby, and returns the ID of the
+// created game.
+func (l *List) Start(lid id.LobbyID, cid id.ClientID) (id.GameID, error) {
+	lobby, err := l.Get(lid)
+	if err != nil {
+		return "", err
+	}
+	return lobby.start(l.manager, cid)
+}
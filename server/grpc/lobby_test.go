@@ -0,0 +1,246 @@
+package lobby
+
+import (
+	"testing"
+	"time"
+
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/registrytest"
+	"github.com/downflux/game/server/grpc/executorutils"
+	"github.com/downflux/game/server/grpc/manager"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	mcpb "github.com/downflux/game/map/api/constants_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+)
+
+const (
+	minPathLength = 8
+)
+
+var (
+	tickDuration     = 10 * time.Millisecond
+	clusterDimension = &gdpb.Coordinate{X: 2, Y: 1}
+
+	/**
+	 * Y = 0 - - - -
+	 *   X = 0
+	 */
+	simpleLinearMapProto = &mdpb.TileMap{
+		Dimension: &gdpb.Coordinate{X: 4, Y: 1},
+		Tiles: []*mdpb.Tile{
+			{Coordinate: &gdpb.Coordinate{X: 0, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
+			{Coordinate: &gdpb.Coordinate{X: 1, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
+			{Coordinate: &gdpb.Coordinate{X: 2, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
+			{Coordinate: &gdpb.Coordinate{X: 3, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
+		},
+		SpawnSlots: []*mdpb.SpawnSlot{
+			{Positions: []*gdpb.Position{{X: 0, Y: 0}}},
+			{Positions: []*gdpb.Position{{X: 3, Y: 0}}},
+		},
+	}
+
+	registryPB = registrytest.Registry()
+)
+
+func newList() (*manager.Manager, *List) {
+	m := manager.New(registryPB, clusterDimension, tickDuration, minPathLength)
+	m.AddMap("linear", simpleLinearMapProto)
+	m.AddMap("empty", &mdpb.TileMap{Dimension: &gdpb.Coordinate{X: 1, Y: 1}})
+	return m, New(m)
+}
+
+func TestCreate(t *testing.T) {
+	_, l := newList()
+
+	if _, err := l.Create("missing", 0, nil); status.Code(err) != codes.NotFound {
+		t.Errorf("Create() = _, %v, want = %v", err, codes.NotFound)
+	}
+	if _, err := l.Create("empty", 0, nil); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Create() = _, %v, want = %v", err, codes.FailedPrecondition)
+	}
+	if _, err := l.Create("linear", -1, nil); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Create() = _, %v, want = %v", err, codes.InvalidArgument)
+	}
+
+	lid, err := l.Create("linear", 0, nil)
+	if err != nil {
+		t.Fatalf("Create() = _, %v, want = nil", err)
+	}
+	lobby, err := l.Get(lid)
+	if err != nil {
+		t.Fatalf("Get() = _, %v, want = nil", err)
+	}
+	if lobby.tickDuration != tickDuration {
+		t.Errorf("tickDuration = %v, want = %v", lobby.tickDuration, tickDuration)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	_, l := newList()
+
+	lid, err := l.Create("linear", 0, nil)
+	if err != nil {
+		t.Fatalf("Create() = _, %v, want = nil", err)
+	}
+
+	for i := 0; i < len(simpleLinearMapProto.GetSpawnSlots()); i++ {
+		_, slot, err := l.Join(lid)
+		if err != nil {
+			t.Fatalf("Join() = _, _, %v, want = nil", err)
+		}
+		if slot != i {
+			t.Errorf("Join() = _, %v, _, want = %v", slot, i)
+		}
+	}
+	if _, _, err := l.Join(lid); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Join() = _, _, %v, want = %v", err, codes.ResourceExhausted)
+	}
+}
+
+func TestSelectSpawnSlot(t *testing.T) {
+	_, l := newList()
+
+	lid, err := l.Create("linear", 0, nil)
+	if err != nil {
+		t.Fatalf("Create() = _, %v, want = nil", err)
+	}
+	lobby, err := l.Get(lid)
+	if err != nil {
+		t.Fatalf("Get() = _, %v, want = nil", err)
+	}
+
+	cid, _, err := l.Join(lid)
+	if err != nil {
+		t.Fatalf("Join() = _, _, %v, want = nil", err)
+	}
+	if err := lobby.SelectSpawnSlot(cid, 1); err != nil {
+		t.Fatalf("SelectSpawnSlot() = %v, want = nil", err)
+	}
+	if err := lobby.SelectSpawnSlot(cid, 2); status.Code(err) != codes.OutOfRange {
+		t.Errorf("SelectSpawnSlot() = %v, want = %v", err, codes.OutOfRange)
+	}
+
+	// The next player is assigned the first free slot.
+	other, slot, err := l.Join(lid)
+	if err != nil {
+		t.Fatalf("Join() = _, _, %v, want = nil", err)
+	}
+	if slot != 0 {
+		t.Errorf("Join() = _, %v, _, want = %v", slot, 0)
+	}
+	if err := lobby.SelectSpawnSlot(other, 1); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("SelectSpawnSlot() = %v, want = %v", err, codes.FailedPrecondition)
+	}
+	if err := lobby.SelectSpawnSlot(id.ClientID("missing"), 0); status.Code(err) != codes.NotFound {
+		t.Errorf("SelectSpawnSlot() = %v, want = %v", err, codes.NotFound)
+	}
+}
+
+func TestStart(t *testing.T) {
+	m, l := newList()
+	defer m.Stop()
+
+	lid, err := l.Create("linear", 0, []gcpb.EntityType{gcpb.EntityType_ENTITY_TYPE_TANK})
+	if err != nil {
+		t.Fatalf("Create() = _, %v, want = nil", err)
+	}
+	lobby, err := l.Get(lid)
+	if err != nil {
+		t.Fatalf("Get() = _, %v, want = nil", err)
+	}
+
+	var cids []id.ClientID
+	for i := 0; i < 2; i++ {
+		cid, _, err := l.Join(lid)
+		if err != nil {
+			t.Fatalf("Join() = _, _, %v, want = nil", err)
+		}
+		cids = append(cids, cid)
+	}
+
+	for _, cid := range cids {
+		if err := lobby.SelectTeam(cid, 1); err != nil {
+			t.Fatalf("SelectTeam() = %v, want = nil", err)
+		}
+	}
+
+	if _, err := l.Start(lid, cids[1]); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Start() = _, %v, want = %v", err, codes.PermissionDenied)
+	}
+
+	if err := lobby.SetReady(cids[0], true); err != nil {
+		t.Fatalf("SetReady() = %v, want = nil", err)
+	}
+	if _, err := l.Start(lid, cids[0]); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Start() = _, %v, want = %v", err, codes.FailedPrecondition)
+	}
+
+	if err := lobby.SetReady(cids[1], true); err != nil {
+		t.Fatalf("SetReady() = %v, want = nil", err)
+	}
+	gid, err := l.Start(lid, cids[0])
+	if err != nil {
+		t.Fatalf("Start() = _, %v, want = nil", err)
+	}
+	if got := lobby.PB().GetGameId(); got != gid.Value() {
+		t.Errorf("GetGameId() = %v, want = %v", got, gid)
+	}
+
+	u, err := m.Game(gid)
+	if err != nil {
+		t.Fatalf("Game() = _, %v, want = nil", err)
+	}
+	for _, cid := range cids {
+		if !u.Executor().ClientExists(cid) {
+			t.Errorf("ClientExists() = %v, want = %v", false, true)
+		}
+		if got, err := u.Executor().ClientTeam(cid); err != nil || got != 1 {
+			t.Errorf("ClientTeam() = %v, %v, want = %v, nil", got, err, 1)
+		}
+	}
+
+	if err := lobby.SetReady(cids[0], false); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("SetReady() = %v, want = %v", err, codes.FailedPrecondition)
+	}
+	if _, err := l.Start(lid, cids[0]); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Start() = _, %v, want = %v", err, codes.FailedPrecondition)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	_, l := newList()
+
+	lid, err := l.Create("linear", 0, []gcpb.EntityType{
+		gcpb.EntityType_ENTITY_TYPE_TANK,
+		gcpb.EntityType_ENTITY_TYPE_TANK,
+	})
+	if err != nil {
+		t.Fatalf("Create() = _, %v, want = nil", err)
+	}
+	lobby, err := l.Get(lid)
+	if err != nil {
+		t.Fatalf("Get() = _, %v, want = nil", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := l.Join(lid); err != nil {
+			t.Fatalf("Join() = _, _, %v, want = nil", err)
+		}
+	}
+
+	u, err := executorutils.New(simpleLinearMapProto, registryPB, clusterDimension, tickDuration, minPathLength)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	if err := lobby.setupUnsafe(u); err != nil {
+		t.Fatalf("setupUnsafe() = %v, want = nil", err)
+	}
+
+	// Each player is spawned an account and two tanks.
+	if got := len(u.Executor().Pending()); got != 6 {
+		t.Errorf("len() = %v, want = %v", got, 6)
+	}
+}
@@ -6,21 +6,33 @@ import (
 	"io/ioutil"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/metrics/metrics"
+	"github.com/downflux/game/engine/server/executor/executor"
+	"github.com/downflux/game/server/grpc/executorutils"
+	"github.com/downflux/game/server/grpc/manager"
 	"github.com/downflux/game/server/grpc/server"
+	"github.com/downflux/game/server/referee/referee"
+	"github.com/downflux/game/server/replay/replay"
+	"github.com/downflux/game/server/snapshot/snapshot"
 	"github.com/golang/protobuf/proto"
 	"google.golang.org/grpc"
 
 	apipb "github.com/downflux/game/api/api_go_proto"
-	gcpb "github.com/downflux/game/api/constants_go_proto"
 	gdpb "github.com/downflux/game/api/data_go_proto"
 	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+	sdpb "github.com/downflux/game/server/snapshot/api/data_go_proto"
 )
 
 var (
@@ -28,25 +40,80 @@ var (
 	mapFile        = flag.String("map_file", "data/map/demo.textproto", "game map textproto file")
 	tickDurationMS = flag.Int("tick_ms", 100, "maximum loop time duration")
 
+	// registryFile lists the unit archetypes, e.g. the movement speed and
+	// health of each unit, which may be produced in all games.
+	registryFile = flag.String("registry_file", "data/entity/registry.textproto", "unit definition registry textproto file")
+
+	// mapDir is the directory of maps from which additional games may be
+	// created via the CreateGame API. Each map is referenced by its file
+	// name without the .textproto extension.
+	mapDir = flag.String("map_dir", "data/map", "directory of game map textproto files available to new games")
+
 	// minPathLength represents the minimum lookahead path length to
 	// calculate, where the path is a list of tile.Map coordinates.
 	minPathLength = flag.Int("path_length", 8, "target lookahead path length for partial moves")
 
+	// replayFile is the output file to which the game will be recorded
+	// on exit. The game is not recorded if this is unset.
+	replayFile = flag.String("replay_file", "", "recorded game textproto output file")
+
+	// snapshotFile is the file from which the game is resumed on startup,
+	// if the file exists. The game state is written back to this file on
+	// exit. The game is not checkpointed if this is unset.
+	snapshotFile = flag.String("snapshot_file", "", "game state checkpoint textproto file")
+
+	// rollbackTicks is the maximum number of ticks by which the game is
+	// rolled back in order to apply a command at the tick at which it was
+	// issued by the client. Late commands are applied at the current tick
+	// if this is unset.
+	rollbackTicks = flag.Int("rollback_ticks", 10, "maximum number of ticks to roll back the game for late commands")
+
+	// commandWindowPast and commandWindowFuture are the number of ticks
+	// before and after the current tick for which client commands are
+	// accepted. Commands outside of this window are rejected. Commands
+	// are accepted for any tick if either is negative.
+	commandWindowPast   = flag.Int("command_window_past", 10, "maximum number of ticks by which client commands may be late")
+	commandWindowFuture = flag.Int("command_window_future", 10, "maximum number of ticks by which client commands may be early")
+
+	// timeLimitTicks is the number of ticks after which a game ends in a
+	// draw. Games do not have a time limit if this is unset.
+	timeLimitTicks = flag.Int("time_limit_ticks", 0, "number of ticks after which the game ends in a draw")
+
+	// metricsPort is the HTTP listener port on which the Prometheus-style
+	// server metrics are exported. Metrics are not collected if this is
+	// unset.
+	metricsPort = flag.Int("metrics_port", 0, "HTTP metrics listener port")
+
 	cpuProfile    = flag.String("cpuprofile", "", "CPU profiler output file")
 	cpuSampleFreq = flag.Int("cpusamplefreq", 100, "how often (Hz) CPU profiler samples stack")
-
-	// tickDuration is the targeted loop iteration time delta. If a tick
-	// loop exceeds this time, it should delay commands until the next
-	// cycle and ensure the dirty curves are being broadcasted instead.
-	//
-	// TODO(minkezhang): Ensure tick timeout actually occurs.
-	tickDuration = time.Duration(*tickDurationMS) * time.Millisecond
 )
 
 func main() {
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 	flag.Parse()
 
+	// tickDuration is the targeted loop iteration time delta. If a tick
+	// loop exceeds this time, the Executor will defer unprocessed actions
+	// until the next cycle and ensure the dirty curves are being
+	// broadcasted instead.
+	tickDuration := time.Duration(*tickDurationMS) * time.Millisecond
+
+	// teardown is a list of functions which are run when the server
+	// receives an exit signal.
+	var teardown []func()
+
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM) // subscribe to system signals
+	h := func(c chan os.Signal) {
+		select {
+		case <-c:
+			for _, f := range teardown {
+				f()
+			}
+			os.Exit(0)
+		}
+	}
+
 	if *cpuProfile != "" {
 		f, err := os.Create(*cpuProfile)
 		if err != nil {
@@ -54,17 +121,10 @@ func main() {
 		}
 		defer f.Close()
 
-		c := make(chan os.Signal, 2)
-		signal.Notify(c, os.Interrupt, syscall.SIGTERM) // subscribe to system signals
-		h := func(c chan os.Signal) {
-			select {
-			case <-c:
-				pprof.StopCPUProfile()
-				f.Close()
-				os.Exit(0)
-			}
-		}
-		go h(c)
+		teardown = append(teardown, func() {
+			pprof.StopCPUProfile()
+			f.Close()
+		})
 
 		runtime.SetCPUProfileRate(*cpuSampleFreq)
 		if err := pprof.StartCPUProfile(f); err != nil {
@@ -80,30 +140,166 @@ func main() {
 		log.Fatalf("could not open host addr %s: %v", addr, err)
 	}
 
-	d, err := ioutil.ReadFile(*mapFile)
+	mapPB, err := readMap(*mapFile)
 	if err != nil {
-		log.Fatalf("could not open map file %s: %v", *mapFile, err)
+		log.Fatalf("could not read map file %s: %v", *mapFile, err)
 	}
 
-	mapPB := &mdpb.TileMap{}
-
-	if err := proto.UnmarshalText(string(d), mapPB); err != nil {
-		log.Fatalf("could not parse map file: %v", err)
+	registryPB, err := readRegistry(*registryFile)
+	if err != nil {
+		log.Fatalf("could not read registry file %s: %v", *registryFile, err)
 	}
 
-	downFluxServer, err := server.NewDownFluxServer(mapPB, &gdpb.Coordinate{X: 5, Y: 5}, tickDuration, *minPathLength)
+	clusterDimension := &gdpb.Coordinate{X: 5, Y: 5}
+	downFluxServer, err := server.NewDownFluxServer(mapPB, registryPB, clusterDimension, tickDuration, *minPathLength)
 	if err != nil {
 		log.Fatal("could not construct DownFlux server instance: %v", err)
 	}
 
+	var restored bool
+	if *snapshotFile != "" {
+		if d, err := ioutil.ReadFile(*snapshotFile); err == nil {
+			pb := &sdpb.Snapshot{}
+			if err := proto.UnmarshalText(string(d), pb); err != nil {
+				log.Fatalf("could not parse snapshot file: %v", err)
+			}
+			if err := downFluxServer.LoadSnapshot(pb); err != nil {
+				log.Fatalf("could not restore game from snapshot: %v", err)
+			}
+			restored = true
+		} else if !os.IsNotExist(err) {
+			log.Fatalf("could not open snapshot file %s: %v", *snapshotFile, err)
+		}
+
+		teardown = append(teardown, func() {
+			pb, err := downFluxServer.SaveSnapshot()
+			if err != nil {
+				log.Printf("could not save snapshot: %v", err)
+				return
+			}
+			if err := ioutil.WriteFile(*snapshotFile, []byte(proto.MarshalTextString(pb)), 0644); err != nil {
+				log.Printf("could not write snapshot file %s: %v", *snapshotFile, err)
+			}
+		})
+	}
+
+	if *replayFile != "" {
+		// Replays are played back from an empty game, and cannot
+		// reconstruct the state of a restored game.
+		if restored {
+			log.Fatal("cannot record a replay of a game restored from a snapshot")
+		}
+		if *rollbackTicks > 0 {
+			log.Println("late commands will not be rolled back while recording a replay")
+		}
+
+		r := replay.NewRecorder(mapPB, registryPB, clusterDimension, tickDuration, *minPathLength)
+		downFluxServer.Utils().Executor().SetRecorder(r)
+
+		teardown = append(teardown, func() {
+			if err := ioutil.WriteFile(*replayFile, []byte(proto.MarshalTextString(r.Replay())), 0644); err != nil {
+				log.Printf("could not write replay file %s: %v", *replayFile, err)
+			}
+		})
+	}
+
+	// setup configures each game hosted by the server, including games
+	// created via the CreateGame API.
+	setup := func(u *executorutils.Utils) error {
+		if *rollbackTicks > 0 {
+			u.Executor().SetCheckpointer(
+				snapshot.NewCheckpointer(u),
+				id.Tick(*rollbackTicks))
+		}
+		if *commandWindowPast >= 0 && *commandWindowFuture >= 0 {
+			u.Executor().SetCommandWindow(
+				id.Tick(*commandWindowPast),
+				id.Tick(*commandWindowFuture))
+		}
+
+		conditions := []referee.Condition{referee.LastTeamStanding()}
+		if *timeLimitTicks > 0 {
+			conditions = append(conditions, referee.TimeLimit(id.Tick(*timeLimitTicks)))
+		}
+		u.Executor().SetReferee(referee.New(u.GameState().Entities(), u.Executor().Teams, conditions))
+		return nil
+	}
+	if err := setup(downFluxServer.Utils()); err != nil {
+		log.Fatalf("could not configure game: %v", err)
+	}
+	downFluxServer.Manager().SetSetup(setup)
+
+	if *mapDir != "" {
+		fns, err := filepath.Glob(filepath.Join(*mapDir, "*.textproto"))
+		if err != nil {
+			log.Fatalf("could not list map directory %s: %v", *mapDir, err)
+		}
+		for _, fn := range fns {
+			pb, err := readMap(fn)
+			if err != nil {
+				log.Fatalf("could not read map file %s: %v", fn, err)
+			}
+			downFluxServer.Manager().AddMap(strings.TrimSuffix(filepath.Base(fn), ".textproto"), pb)
+		}
+	}
+
+	if *metricsPort != 0 {
+		r := metrics.New()
+		downFluxServer.Manager().SetMetrics(executor.NewMetrics(r))
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", r)
+
+		metricsAddr := fmt.Sprintf("localhost:%d", *metricsPort)
+		go func() {
+			log.Printf("serving metrics on %s/metrics", metricsAddr)
+			if err := http.ListenAndServe(metricsAddr, mux); err != nil {
+				log.Printf("could not serve metrics on %s: %v", metricsAddr, err)
+			}
+		}()
+	}
+
 	log.Printf("serving on %s", addr)
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(downFluxServer.Sessions().ServerOptions()...)
 	apipb.RegisterDownFluxServer(s, downFluxServer)
 
-	downFluxServer.Utils().ProduceDebug(gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 1, Y: 1})
-	downFluxServer.Utils().ProduceDebug(gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 2, Y: 1})
+	teardown = append(teardown, func() {
+		if err := downFluxServer.Manager().Stop(); err != nil {
+			log.Printf("could not stop games: %v", err)
+		}
+	})
 
 	go s.Serve(lis)
-	log.Println(downFluxServer.Utils().Executor().Run())
+	if err := downFluxServer.Manager().Start(manager.DefaultGameID); err != nil {
+		log.Fatalf("could not start default game: %v", err)
+	}
+
+	h(c)
+}
+
+func readMap(fn string) (*mdpb.TileMap, error) {
+	d, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	pb := &mdpb.TileMap{}
+	if err := proto.UnmarshalText(string(d), pb); err != nil {
+		return nil, err
+	}
+	return pb, nil
+}
+
+func readRegistry(fn string) (*edpb.Registry, error) {
+	d, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	pb := &edpb.Registry{}
+	if err := proto.UnmarshalText(string(d), pb); err != nil {
+		return nil, err
+	}
+	return pb, nil
 }
@@ -0,0 +1,375 @@
+// Package manager hosts multiple independent game instances in a single server
+// process.
+package manager
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/server/executor/executor"
+	"github.com/downflux/game/server/grpc/executorutils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apipb "github.com/downflux/game/api/api_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+const (
+	// DefaultGameID is the ID of the game to which requests without an
+	// explicit game ID are routed.
+	DefaultGameID = id.GameID("default")
+
+	// idLen represents the length of a generated GameID.
+	idLen = 8
+
+	// finishedRetention is the duration for which finished games are still
+	// reported by List after the game ends.
+	finishedRetention = 10 * time.Minute
+)
+
+// SetupFunc configures a newly created game before the game starts executing
+// ticks, e.g. by setting the command window or checkpointer of the Executor.
+type SetupFunc func(u *executorutils.Utils) error
+
+// game tracks a single hosted game instance.
+type game struct {
+	utils   *executorutils.Utils // Read-only.
+	mapName string               // Read-only.
+
+	// host is the client which created the game, or empty if the game was
+	// not created on behalf of a client. This is read-only.
+	host id.ClientID
+
+	// done is closed when the Executor core loop exits. This is nil if
+	// the game was never started by the Manager.
+	done chan struct{}
+}
+
+// export generates a summary of the game.
+func (g *game) export(gid id.GameID) *apipb.Game {
+	return &apipb.Game{
+		GameId:   gid.Value(),
+		MapName:  g.mapName,
+		Status:   g.utils.Executor().Status(),
+		GameOver: g.utils.Executor().GameOver(),
+	}
+}
+
+// summary is the final state of a finished game.
+type summary struct {
+	pb  *apipb.Game
+	end time.Time
+}
+
+// Manager creates, tracks, and tears down independent game instances.
+type Manager struct {
+	// registry, clusterDimension, tickDuration, and minPathLength are the
+	// default game parameters. These are read-only.
+	registry         *edpb.Registry
+	clusterDimension *gdpb.Coordinate
+	tickDuration     time.Duration
+	minPathLength    int
+
+	// setup is an optional game configuration function which is called
+	// on all games created by the Manager. This is read-only after the
+	// Manager starts accepting requests.
+	setup SetupFunc
+
+	// metrics is an optional set of instruments shared by all games hosted
+	// by the Manager. This is read-only after the Manager starts accepting
+	// requests.
+	metrics *executor.Metrics
+
+	// mapsMux guards the maps property.
+	mapsMux sync.Mutex
+
+	// maps is the list of maps from which new games may be created,
+	// indexed by the map name.
+	maps map[string]*mdpb.TileMap
+
+	// gamesMux guards the games and finished properties.
+	gamesMux sync.Mutex
+	games    map[id.GameID]*game
+
+	// finished is the list of games which have stopped on their own (e.g.
+	// because a victory condition was met), indexed by the game ID.
+	// Finished games are removed from the games property, and only a
+	// summary of the game is kept for finishedRetention.
+	finished map[id.GameID]*summary
+}
+
+// New constructs a new Manager instance. The input parameters are used for
+// all games created by the Manager.
+func New(rpb *edpb.Registry, d *gdpb.Coordinate, tickDuration time.Duration, minPathLength int) *Manager {
+	return &Manager{
+		registry:         rpb,
+		clusterDimension: d,
+		tickDuration:     tickDuration,
+		minPathLength:    minPathLength,
+		maps:             map[string]*mdpb.TileMap{},
+		games:            map[id.GameID]*game{},
+		finished:         map[id.GameID]*summary{},
+	}
+}
+
+// SetSetup sets the configuration function which is called on all games
+// created by the Manager.
+func (m *Manager) SetSetup(f SetupFunc) { m.setup = f }
+
+// SetMetrics reports the performance of all games hosted by the Manager to the
+// input set of metrics, labeled with the game ID. This includes games which
+// have already been added to the Manager, which must not have been started
+// yet.
+func (m *Manager) SetMetrics(mt *executor.Metrics) {
+	m.gamesMux.Lock()
+	defer m.gamesMux.Unlock()
+
+	m.metrics = mt
+	for gid, g := range m.games {
+		g.utils.Executor().SetMetrics(mt, gid)
+	}
+}
+
+// TickDuration returns the default tick duration of games created by the
+// Manager.
+func (m *Manager) TickDuration() time.Duration { return m.tickDuration }
+
+// AddMap makes the input map available to new games under the input name.
+func (m *Manager) AddMap(name string, pb *mdpb.TileMap) {
+	m.mapsMux.Lock()
+	defer m.mapsMux.Unlock()
+
+	m.maps[name] = pb
+}
+
+// Add registers an existing game with the Manager. The game is not started --
+// the caller may further configure the game before calling Start.
+func (m *Manager) Add(gid id.GameID, mapName string, u *executorutils.Utils) error {
+	m.gamesMux.Lock()
+	defer m.gamesMux.Unlock()
+
+	if m.existsUnsafe(gid) {
+		return status.Errorf(codes.AlreadyExists, "game %v already exists", gid)
+	}
+	m.games[gid] = &game{
+		utils:   u,
+		mapName: mapName,
+	}
+	if m.metrics != nil {
+		u.Executor().SetMetrics(m.metrics, gid)
+	}
+	return nil
+}
+
+// Map returns the specified map.
+func (m *Manager) Map(name string) (*mdpb.TileMap, error) {
+	m.mapsMux.Lock()
+	defer m.mapsMux.Unlock()
+
+	pb, found := m.maps[name]
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "map %v not found", name)
+	}
+	return pb, nil
+}
+
+// Create constructs, configures, and starts a new game on the specified map on
+// behalf of the input host client. The default tick duration is used if the
+// input tick duration is zero. The optional input setup function is called
+// after the Manager setup function, and may be used to e.g. add the starting
+// units of the game.
+func (m *Manager) Create(host id.ClientID, mapName string, tickDuration time.Duration, setup SetupFunc) (id.GameID, error) {
+	if tickDuration < 0 {
+		return "", status.Errorf(codes.InvalidArgument, "invalid tick duration %v", tickDuration)
+	}
+	if tickDuration == 0 {
+		tickDuration = m.tickDuration
+	}
+
+	pb, err := m.Map(mapName)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := executorutils.New(pb, m.registry, m.clusterDimension, tickDuration, m.minPathLength)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range []SetupFunc{m.setup, setup} {
+		if f == nil {
+			continue
+		}
+		if err := f(u); err != nil {
+			return "", err
+		}
+	}
+
+	gid, err := m.add(host, mapName, u)
+	if err != nil {
+		return "", err
+	}
+	return gid, m.Start(gid)
+}
+
+// add registers the input game under a newly generated game ID.
+func (m *Manager) add(host id.ClientID, mapName string, u *executorutils.Utils) (id.GameID, error) {
+	m.gamesMux.Lock()
+	defer m.gamesMux.Unlock()
+
+	gid := id.GameID(id.RandomString(idLen))
+	for m.existsUnsafe(gid) {
+		gid = id.GameID(id.RandomString(idLen))
+	}
+	m.games[gid] = &game{
+		utils:   u,
+		mapName: mapName,
+		host:    host,
+	}
+	if m.metrics != nil {
+		u.Executor().SetMetrics(m.metrics, gid)
+	}
+	return gid, nil
+}
+
+// existsUnsafe checks if the input game ID is in use by a running or
+// finished game.
+func (m *Manager) existsUnsafe(gid id.GameID) bool {
+	_, running := m.games[gid]
+	_, finished := m.finished[gid]
+	return running || finished
+}
+
+// Start executes the core game loop of the specified game in the background.
+// Once the core game loop exits, the game is reaped.
+func (m *Manager) Start(gid id.GameID) error {
+	m.gamesMux.Lock()
+	defer m.gamesMux.Unlock()
+
+	g, found := m.games[gid]
+	if !found {
+		return status.Errorf(codes.NotFound, "game %v not found", gid)
+	}
+	if g.done != nil {
+		return status.Errorf(codes.FailedPrecondition, "game %v has already started", gid)
+	}
+
+	g.done = make(chan struct{})
+	go func(g *game) {
+		defer close(g.done)
+		if err := g.utils.Executor().Run(); err != nil {
+			log.Printf("game %v exited with error: %v", gid, err)
+		}
+		m.reap(gid, g)
+	}(g)
+	return nil
+}
+
+// reap removes a game which has stopped on its own from the Manager, and
+// keeps a summary of the game to be reported by List. Games which have been
+// explicitly deleted are skipped.
+func (m *Manager) reap(gid id.GameID, g *game) {
+	m.gamesMux.Lock()
+	defer m.gamesMux.Unlock()
+
+	if m.games[gid] != g {
+		return
+	}
+	delete(m.games, gid)
+
+	pb := g.export(gid)
+	pb.Finished = true
+	m.finished[gid] = &summary{
+		pb:  pb,
+		end: time.Now(),
+	}
+}
+
+// Game returns the specified game.
+func (m *Manager) Game(gid id.GameID) (*executorutils.Utils, error) {
+	m.gamesMux.Lock()
+	defer m.gamesMux.Unlock()
+
+	g, found := m.games[gid]
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "game %v not found", gid)
+	}
+	return g.utils, nil
+}
+
+// Host returns the client which created the specified game. Games which were
+// not created on behalf of a client (e.g. the default game) have no host.
+func (m *Manager) Host(gid id.GameID) (id.ClientID, error) {
+	m.gamesMux.Lock()
+	defer m.gamesMux.Unlock()
+
+	g, found := m.games[gid]
+	if !found {
+		return "", status.Errorf(codes.NotFound, "game %v not found", gid)
+	}
+	return g.host, nil
+}
+
+// List returns a summary of all games hosted by the Manager, ordered by the
+// game ID. Games which finished within the last finishedRetention are included
+// and marked as finished.
+func (m *Manager) List() []*apipb.Game {
+	m.gamesMux.Lock()
+	defer m.gamesMux.Unlock()
+
+	var games []*apipb.Game
+	for gid, g := range m.games {
+		games = append(games, g.export(gid))
+	}
+	for gid, f := range m.finished {
+		if time.Since(f.end) > finishedRetention {
+			delete(m.finished, gid)
+			continue
+		}
+		games = append(games, f.pb)
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].GetGameId() < games[j].GetGameId() })
+	return games
+}
+
+// Delete stops the specified game and removes it from the Manager. If the game
+// was started by the Manager, Delete blocks until the core game loop exits.
+func (m *Manager) Delete(gid id.GameID) error {
+	m.gamesMux.Lock()
+	g, found := m.games[gid]
+	delete(m.games, gid)
+	m.gamesMux.Unlock()
+
+	if !found {
+		return status.Errorf(codes.NotFound, "game %v not found", gid)
+	}
+	if err := g.utils.Executor().Stop(); err != nil {
+		return err
+	}
+	if g.done != nil {
+		<-g.done
+	}
+	return nil
+}
+
+// Stop stops all games hosted by the Manager.
+func (m *Manager) Stop() error {
+	m.gamesMux.Lock()
+	var gids []id.GameID
+	for gid := range m.games {
+		gids = append(gids, gid)
+	}
+	m.gamesMux.Unlock()
+
+	for _, gid := range gids {
+		if err := m.Delete(gid); err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+	}
+	return nil
+}
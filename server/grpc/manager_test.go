@@ -0,0 +1,246 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/metrics/metrics"
+	"github.com/downflux/game/engine/server/executor/executor"
+	"github.com/downflux/game/server/entity/registrytest"
+	"github.com/downflux/game/server/grpc/executorutils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	mcpb "github.com/downflux/game/map/api/constants_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+)
+
+const (
+	minPathLength = 8
+)
+
+var (
+	tickDuration     = 10 * time.Millisecond
+	clusterDimension = &gdpb.Coordinate{X: 2, Y: 1}
+
+	/**
+	 * Y = 0 - - - -
+	 *   X = 0
+	 */
+	simpleLinearMapProto = &mdpb.TileMap{
+		Dimension: &gdpb.Coordinate{X: 4, Y: 1},
+		Tiles: []*mdpb.Tile{
+			{Coordinate: &gdpb.Coordinate{X: 0, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
+			{Coordinate: &gdpb.Coordinate{X: 1, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
+			{Coordinate: &gdpb.Coordinate{X: 2, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
+			{Coordinate: &gdpb.Coordinate{X: 3, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
+		},
+	}
+
+	registryPB = registrytest.Registry()
+)
+
+func TestCreate(t *testing.T) {
+	const host = id.ClientID("host-id")
+
+	m := New(registryPB, clusterDimension, tickDuration, minPathLength)
+	m.AddMap("linear", simpleLinearMapProto)
+
+	var setup int
+	m.SetSetup(func(u *executorutils.Utils) error {
+		setup++
+		return nil
+	})
+
+	if _, err := m.Create(host, "missing", 0, nil); status.Code(err) != codes.NotFound {
+		t.Fatalf("Create() = _, %v, want = %v", err, codes.NotFound)
+	}
+
+	var gids []id.GameID
+	for i := 0; i < 2; i++ {
+		gid, err := m.Create(host, "linear", 0, nil)
+		if err != nil {
+			t.Fatalf("Create() = _, %v, want = nil", err)
+		}
+		gids = append(gids, gid)
+	}
+	if gids[0] == gids[1] {
+		t.Fatalf("Create() = %v, want a unique game ID", gids[1])
+	}
+	if got, err := m.Host(gids[0]); err != nil || got != host {
+		t.Errorf("Host() = %v, %v, want = %v, nil", got, err, host)
+	}
+	if setup != 2 {
+		t.Errorf("setup = %v, want = %v", setup, 2)
+	}
+
+	if got := len(m.List()); got != 2 {
+		t.Fatalf("len() = %v, want = %v", got, 2)
+	}
+
+	// Each game has an independent Executor.
+	u, err := m.Game(gids[0])
+	if err != nil {
+		t.Fatalf("Game() = _, %v, want = nil", err)
+	}
+	v, err := m.Game(gids[1])
+	if err != nil {
+		t.Fatalf("Game() = _, %v, want = nil", err)
+	}
+	if u.Executor() == v.Executor() {
+		t.Errorf("Executor() = %v, want a distinct Executor", v.Executor())
+	}
+
+	if err := m.Delete(gids[0]); err != nil {
+		t.Fatalf("Delete() = %v, want = nil", err)
+	}
+	if !u.Status().IsStopped() {
+		t.Errorf("IsStopped() = %v, want = %v", false, true)
+	}
+	if v.Status().IsStopped() {
+		t.Errorf("IsStopped() = %v, want = %v", true, false)
+	}
+	if _, err := m.Game(gids[0]); status.Code(err) != codes.NotFound {
+		t.Errorf("Game() = _, %v, want = %v", err, codes.NotFound)
+	}
+	if err := m.Delete(gids[0]); status.Code(err) != codes.NotFound {
+		t.Errorf("Delete() = %v, want = %v", err, codes.NotFound)
+	}
+	if _, err := m.Host(gids[0]); status.Code(err) != codes.NotFound {
+		t.Errorf("Host() = _, %v, want = %v", err, codes.NotFound)
+	}
+
+	if err := m.Stop(); err != nil {
+		t.Fatalf("Stop() = %v, want = nil", err)
+	}
+	if got := len(m.List()); got != 0 {
+		t.Errorf("len() = %v, want = %v", got, 0)
+	}
+}
+
+// referee is a mock Referee which ends the game immediately.
+type referee struct{}
+
+func (r referee) Evaluate(tick id.Tick) (*gdpb.GameOver, error) {
+	return &gdpb.GameOver{Tick: tick.Value()}, nil
+}
+
+func TestReap(t *testing.T) {
+	m := New(registryPB, clusterDimension, tickDuration, minPathLength)
+	m.AddMap("linear", simpleLinearMapProto)
+
+	gid, err := m.Create("", "linear", 0, func(u *executorutils.Utils) error {
+		u.Executor().SetReferee(referee{})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Create() = _, %v, want = nil", err)
+	}
+
+	for deadline := time.Now().Add(time.Second); ; {
+		if _, err := m.Game(gid); status.Code(err) == codes.NotFound {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Game() = _, nil, want = %v", codes.NotFound)
+		}
+		time.Sleep(tickDuration)
+	}
+
+	games := m.List()
+	if got := len(games); got != 1 {
+		t.Fatalf("len() = %v, want = %v", got, 1)
+	}
+	if !games[0].GetFinished() {
+		t.Errorf("GetFinished() = %v, want = %v", false, true)
+	}
+	if games[0].GetGameOver() == nil {
+		t.Error("GetGameOver() = nil, want a non-nil value")
+	}
+	if err := m.Delete(gid); status.Code(err) != codes.NotFound {
+		t.Errorf("Delete() = %v, want = %v", err, codes.NotFound)
+	}
+
+	// Finished games are only listed for a limited time.
+	m.finished[gid].end = time.Now().Add(-2 * finishedRetention)
+	if got := len(m.List()); got != 0 {
+		t.Errorf("len() = %v, want = %v", got, 0)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	m := New(registryPB, clusterDimension, tickDuration, minPathLength)
+	m.AddMap("linear", simpleLinearMapProto)
+	defer m.Stop()
+
+	u, err := executorutils.New(simpleLinearMapProto, registryPB, clusterDimension, tickDuration, minPathLength)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	if err := m.Add(DefaultGameID, "linear", u); err != nil {
+		t.Fatalf("Add() = %v, want = nil", err)
+	}
+
+	r := metrics.New()
+	m.SetMetrics(executor.NewMetrics(r))
+
+	if err := m.Start(DefaultGameID); err != nil {
+		t.Fatalf("Start() = %v, want = nil", err)
+	}
+	gid, err := m.Create("", "linear", 0, nil)
+	if err != nil {
+		t.Fatalf("Create() = _, %v, want = nil", err)
+	}
+
+	// Both the existing and the newly created game report metrics.
+	for _, g := range []id.GameID{DefaultGameID, gid} {
+		want := fmt.Sprintf("executor_tick_duration_seconds_count{game_id=%q}", g)
+		for deadline := time.Now().Add(time.Second); ; {
+			var b bytes.Buffer
+			if err := r.Write(&b); err != nil {
+				t.Fatalf("Write() = %v, want = nil", err)
+			}
+			if strings.Contains(b.String(), want) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Write() = %v, want a series containing %v", b.String(), want)
+			}
+			time.Sleep(tickDuration)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	m := New(registryPB, clusterDimension, tickDuration, minPathLength)
+
+	u, err := executorutils.New(simpleLinearMapProto, registryPB, clusterDimension, tickDuration, minPathLength)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+
+	if err := m.Add(DefaultGameID, "linear", u); err != nil {
+		t.Fatalf("Add() = %v, want = nil", err)
+	}
+	if err := m.Add(DefaultGameID, "linear", u); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Add() = %v, want = %v", err, codes.AlreadyExists)
+	}
+
+	if err := m.Start(DefaultGameID); err != nil {
+		t.Fatalf("Start() = %v, want = nil", err)
+	}
+	if err := m.Start(DefaultGameID); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Start() = %v, want = %v", err, codes.FailedPrecondition)
+	}
+	if got, err := m.Host(DefaultGameID); err != nil || got != "" {
+		t.Errorf("Host() = %v, %v, want = %v, nil", got, err, "")
+	}
+	if err := m.Delete(DefaultGameID); err != nil {
+		t.Fatalf("Delete() = %v, want = nil", err)
+	}
+}
@@ -13,8 +13,12 @@ import (
 	"time"
 
 	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/grpc/auth"
 	"github.com/downflux/game/server/grpc/client"
 	"github.com/downflux/game/server/grpc/executorutils"
+	"github.com/downflux/game/server/grpc/lobby"
+	"github.com/downflux/game/server/grpc/manager"
+	"github.com/downflux/game/server/snapshot/snapshot"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -23,6 +27,8 @@ import (
 	apipb "github.com/downflux/game/api/api_go_proto"
 	gdpb "github.com/downflux/game/api/data_go_proto"
 	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+	sdpb "github.com/downflux/game/server/snapshot/api/data_go_proto"
 )
 
 var (
@@ -39,18 +45,19 @@ type ServerWrapper struct {
 func NewServerWrapper(
 	serverOptions []grpc.ServerOption,
 	pb *mdpb.TileMap,
+	rpb *edpb.Registry,
 	d *gdpb.Coordinate,
 	tickDuration time.Duration,
 	minPathLength int) (*ServerWrapper, error) {
 	sw := &ServerWrapper{}
 
-	gRPCServerImpl, err := NewDownFluxServer(pb, d, tickDuration, minPathLength)
+	gRPCServerImpl, err := NewDownFluxServer(pb, rpb, d, tickDuration, minPathLength)
 	if err != nil {
 		return nil, err
 	}
 
 	sw.gRPCServerImpl = gRPCServerImpl
-	sw.gRPCServer = grpc.NewServer(serverOptions...)
+	sw.gRPCServer = grpc.NewServer(append(serverOptions, gRPCServerImpl.Sessions().ServerOptions()...)...)
 	apipb.RegisterDownFluxServer(sw.gRPCServer, sw.gRPCServerImpl)
 
 	return sw, nil
@@ -73,7 +80,7 @@ func (s *ServerWrapper) Start(addr string) error {
 }
 
 func (s *ServerWrapper) Stop() error {
-	if err := s.gRPCServerImpl.utils.Executor().Stop(); err != nil {
+	if err := s.gRPCServerImpl.manager.Stop(); err != nil {
 		return err
 	}
 	s.gRPCServer.GracefulStop()
@@ -81,52 +88,378 @@ func (s *ServerWrapper) Stop() error {
 	return s.eg.Wait()
 }
 
-func NewDownFluxServer(pb *mdpb.TileMap, d *gdpb.Coordinate, tickDuration time.Duration, minPathLength int) (*DownFluxServer, error) {
-	utils, err := executorutils.New(pb, d, tickDuration, minPathLength)
+// NewDownFluxServer constructs a new server instance hosting a single default
+// game on the input map. The default game is not started. Additional games may
+// be created via the CreateGame API once the maps are added to the Manager.
+func NewDownFluxServer(pb *mdpb.TileMap, rpb *edpb.Registry, d *gdpb.Coordinate, tickDuration time.Duration, minPathLength int) (*DownFluxServer, error) {
+	utils, err := executorutils.New(pb, rpb, d, tickDuration, minPathLength)
 	if err != nil {
 		return nil, err
 	}
+
+	m := manager.New(rpb, d, tickDuration, minPathLength)
+	if err := m.Add(manager.DefaultGameID, "", utils); err != nil {
+		return nil, err
+	}
+
 	return &DownFluxServer{
-		utils: utils,
+		manager:  m,
+		lobbies:  lobby.New(m),
+		sessions: auth.New(),
+		utils:    utils,
 	}, nil
 }
 
 type DownFluxServer struct {
+	manager  *manager.Manager
+	lobbies  *lobby.List
+	sessions *auth.Sessions
+
+	// utils is the default game instance.
 	utils *executorutils.Utils
 }
 
-func (s *DownFluxServer) validateClient(cid id.ClientID) error {
-	if !s.utils.Executor().ClientExists(cid) {
+// game returns the game instance to which a request with the input game ID is
+// routed.
+func (s *DownFluxServer) game(gid string) (*executorutils.Utils, error) {
+	if gid == "" {
+		return s.manager.Game(manager.DefaultGameID)
+	}
+	return s.manager.Game(id.GameID(gid))
+}
+
+func validateClient(u *executorutils.Utils, cid id.ClientID) error {
+	if !u.Executor().ClientExists(cid) {
 		return status.Errorf(codes.NotFound, "client %v not found", cid)
 	}
 	return nil
 }
 
+// Utils returns the default game instance.
 func (s *DownFluxServer) Utils() *executorutils.Utils { return s.utils }
 
+func (s *DownFluxServer) Manager() *manager.Manager { return s.manager }
+
+// Sessions returns the client session tokens. The server must be registered
+// with the session interceptors, i.e.
+//
+//  grpc.NewServer(s.Sessions().ServerOptions()...)
+func (s *DownFluxServer) Sessions() *auth.Sessions { return s.sessions }
+
+// SaveSnapshot exports the default game, along with the sessions of its
+// clients.
+func (s *DownFluxServer) SaveSnapshot() (*sdpb.Snapshot, error) {
+	pb, err := snapshot.Save(s.utils)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range pb.GetClients() {
+		// Clients which were added directly to the game, e.g. in
+		// tests, do not have a session to reclaim.
+		d, err := s.sessions.Digest(id.ClientID(c.GetClientId()))
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		pb.Sessions = append(pb.GetSessions(), &sdpb.Session{
+			ClientId:    c.GetClientId(),
+			TokenDigest: d,
+		})
+	}
+	return pb, nil
+}
+
+// LoadSnapshot restores the default game from a snapshot. Clients of the
+// restored game authenticate with their previous session token, and may call
+// ReclaimClient to be issued a new token.
+func (s *DownFluxServer) LoadSnapshot(pb *sdpb.Snapshot) error {
+	if err := snapshot.Load(s.utils, pb); err != nil {
+		return err
+	}
+	for _, spb := range pb.GetSessions() {
+		if err := s.sessions.Restore(id.ClientID(spb.GetClientId()), spb.GetTokenDigest()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *DownFluxServer) GetStatus(ctx context.Context, req *apipb.GetStatusRequest) (*apipb.GetStatusResponse, error) {
+	u, err := s.game(req.GetGameId())
+	if err != nil {
+		return nil, err
+	}
 	return &apipb.GetStatusResponse{
-		Status: s.utils.Executor().Status(),
+		Status: u.Executor().Status(),
+	}, nil
+}
+
+// CreateGame starts a new game hosted by the requesting client. The clients
+// of the game and their teams are chosen by the host, and may not change once
+// the game has started.
+func (s *DownFluxServer) CreateGame(ctx context.Context, req *apipb.CreateGameRequest) (*apipb.CreateGameResponse, error) {
+	log.Println("new CreateGame request")
+	gid, err := s.manager.Create(
+		id.ClientID(req.GetClientId()),
+		req.GetMapName(),
+		req.GetTickDuration().AsDuration(),
+		func(u *executorutils.Utils) error {
+			for _, m := range req.GetMembers() {
+				if err := u.AddMember(id.ClientID(m.GetClientId()), m.GetTeam()); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return &apipb.CreateGameResponse{
+		GameId: gid.Value(),
+	}, nil
+}
+
+func (s *DownFluxServer) ListGames(ctx context.Context, req *apipb.ListGamesRequest) (*apipb.ListGamesResponse, error) {
+	return &apipb.ListGamesResponse{
+		Games: s.manager.List(),
+	}, nil
+}
+
+// DeleteGame stops the specified game. Games may only be deleted by the host
+// of the game. Games without a host (e.g. the default game, which is shared by
+// all clients which do not explicitly join a game) may not be deleted.
+func (s *DownFluxServer) DeleteGame(ctx context.Context, req *apipb.DeleteGameRequest) (*apipb.DeleteGameResponse, error) {
+	log.Println("new DeleteGame request")
+	gid := id.GameID(req.GetGameId())
+	cid := id.ClientID(req.GetClientId())
+
+	host, err := s.manager.Host(gid)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" || cid != host {
+		return nil, status.Errorf(codes.PermissionDenied, "client %v may not delete game %v", cid, gid)
+	}
+	return &apipb.DeleteGameResponse{}, s.manager.Delete(gid)
+}
+
+func (s *DownFluxServer) CreateLobby(ctx context.Context, req *apipb.CreateLobbyRequest) (*apipb.CreateLobbyResponse, error) {
+	log.Println("new CreateLobby request")
+	lid, err := s.lobbies.Create(req.GetMapName(), req.GetTickDuration().AsDuration(), req.GetStartingUnits())
+	if err != nil {
+		return nil, err
+	}
+	return &apipb.CreateLobbyResponse{
+		LobbyId: lid.Value(),
 	}, nil
 }
 
+func (s *DownFluxServer) JoinLobby(ctx context.Context, req *apipb.JoinLobbyRequest) (*apipb.JoinLobbyResponse, error) {
+	cid, slot, err := s.lobbies.Join(id.LobbyID(req.GetLobbyId()))
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.sessions.Add(cid)
+	if err != nil {
+		return nil, err
+	}
+	return &apipb.JoinLobbyResponse{
+		ClientId: &gdpb.ClientID{
+			ClientId: cid.Value(),
+		},
+		SpawnSlot:    int32(slot),
+		SessionToken: token,
+	}, nil
+}
+
+func (s *DownFluxServer) GetLobby(ctx context.Context, req *apipb.GetLobbyRequest) (*apipb.GetLobbyResponse, error) {
+	l, err := s.lobbies.Get(id.LobbyID(req.GetLobbyId()))
+	if err != nil {
+		return nil, err
+	}
+	return &apipb.GetLobbyResponse{
+		Lobby: l.PB(),
+	}, nil
+}
+
+func (s *DownFluxServer) SelectTeam(ctx context.Context, req *apipb.SelectTeamRequest) (*apipb.SelectTeamResponse, error) {
+	l, err := s.lobbies.Get(id.LobbyID(req.GetLobbyId()))
+	if err != nil {
+		return nil, err
+	}
+	return &apipb.SelectTeamResponse{}, l.SelectTeam(id.ClientID(req.GetClientId()), req.GetTeam())
+}
+
+func (s *DownFluxServer) SelectSpawnSlot(ctx context.Context, req *apipb.SelectSpawnSlotRequest) (*apipb.SelectSpawnSlotResponse, error) {
+	l, err := s.lobbies.Get(id.LobbyID(req.GetLobbyId()))
+	if err != nil {
+		return nil, err
+	}
+	return &apipb.SelectSpawnSlotResponse{}, l.SelectSpawnSlot(id.ClientID(req.GetClientId()), int(req.GetSpawnSlot()))
+}
+
+func (s *DownFluxServer) SetReady(ctx context.Context, req *apipb.SetReadyRequest) (*apipb.SetReadyResponse, error) {
+	l, err := s.lobbies.Get(id.LobbyID(req.GetLobbyId()))
+	if err != nil {
+		return nil, err
+	}
+	return &apipb.SetReadyResponse{}, l.SetReady(id.ClientID(req.GetClientId()), req.GetReady())
+}
+
+func (s *DownFluxServer) StartLobby(ctx context.Context, req *apipb.StartLobbyRequest) (*apipb.StartLobbyResponse, error) {
+	log.Println("new StartLobby request")
+	gid, err := s.lobbies.Start(id.LobbyID(req.GetLobbyId()), id.ClientID(req.GetClientId()))
+	if err != nil {
+		return nil, err
+	}
+	return &apipb.StartLobbyResponse{
+		GameId: gid.Value(),
+	}, nil
+}
+
+func (s *DownFluxServer) GrantControl(ctx context.Context, req *apipb.GrantControlRequest) (*apipb.GrantControlResponse, error) {
+	u, err := s.game(req.GetGameId())
+	if err != nil {
+		return nil, err
+	}
+	for _, cid := range []string{req.GetClientId(), req.GetGranteeClientId()} {
+		if err := validateClient(u, id.ClientID(cid)); err != nil {
+			return nil, err
+		}
+	}
+	u.ACL().Grant(id.ClientID(req.GetClientId()), id.ClientID(req.GetGranteeClientId()), u.Status().Tick())
+	return &apipb.GrantControlResponse{}, nil
+}
+
+func (s *DownFluxServer) RevokeControl(ctx context.Context, req *apipb.RevokeControlRequest) (*apipb.RevokeControlResponse, error) {
+	u, err := s.game(req.GetGameId())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateClient(u, id.ClientID(req.GetClientId())); err != nil {
+		return nil, err
+	}
+	u.ACL().Revoke(id.ClientID(req.GetClientId()), id.ClientID(req.GetGranteeClientId()), u.Status().Tick())
+	return &apipb.RevokeControlResponse{}, nil
+}
+
 func (s *DownFluxServer) Attack(ctx context.Context, req *apipb.AttackRequest) (*apipb.AttackResponse, error) {
-	if err := s.validateClient(id.ClientID(req.GetClientId())); err != nil {
+	u, err := s.game(req.GetGameId())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateClient(u, id.ClientID(req.GetClientId())); err != nil {
 		return nil, err
 	}
-	return &apipb.AttackResponse{}, s.utils.Attack(req)
+	results, err := u.Attack(req)
+	if err != nil {
+		return nil, err
+	}
+	return &apipb.AttackResponse{Results: results}, nil
 }
 
 func (s *DownFluxServer) Move(ctx context.Context, req *apipb.MoveRequest) (*apipb.MoveResponse, error) {
-	if err := s.validateClient(id.ClientID(req.GetClientId())); err != nil {
+	u, err := s.game(req.GetGameId())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateClient(u, id.ClientID(req.GetClientId())); err != nil {
+		return nil, err
+	}
+	results, err := u.Move(req)
+	if err != nil {
+		return nil, err
+	}
+	return &apipb.MoveResponse{Results: results}, nil
+}
+
+func (s *DownFluxServer) Produce(ctx context.Context, req *apipb.ProduceRequest) (*apipb.ProduceResponse, error) {
+	u, err := s.game(req.GetGameId())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateClient(u, id.ClientID(req.GetClientId())); err != nil {
+		return nil, err
+	}
+	aid, err := u.Produce(req)
+	if err != nil {
 		return nil, err
 	}
-	return &apipb.MoveResponse{}, s.utils.Move(req)
+	return &apipb.ProduceResponse{ProductionId: aid.Value()}, nil
 }
 
+func (s *DownFluxServer) CancelProduce(ctx context.Context, req *apipb.CancelProduceRequest) (*apipb.CancelProduceResponse, error) {
+	u, err := s.game(req.GetGameId())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateClient(u, id.ClientID(req.GetClientId())); err != nil {
+		return nil, err
+	}
+	if err := u.CancelProduce(req); err != nil {
+		return nil, err
+	}
+	return &apipb.CancelProduceResponse{}, nil
+}
+
+func (s *DownFluxServer) ReorderProduce(ctx context.Context, req *apipb.ReorderProduceRequest) (*apipb.ReorderProduceResponse, error) {
+	u, err := s.game(req.GetGameId())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateClient(u, id.ClientID(req.GetClientId())); err != nil {
+		return nil, err
+	}
+	if err := u.ReorderProduce(req); err != nil {
+		return nil, err
+	}
+	return &apipb.ReorderProduceResponse{}, nil
+}
+
+func (s *DownFluxServer) SetRallyPoint(ctx context.Context, req *apipb.SetRallyPointRequest) (*apipb.SetRallyPointResponse, error) {
+	u, err := s.game(req.GetGameId())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateClient(u, id.ClientID(req.GetClientId())); err != nil {
+		return nil, err
+	}
+	if err := u.SetRallyPoint(req); err != nil {
+		return nil, err
+	}
+	return &apipb.SetRallyPointResponse{}, nil
+}
+
+// AddClient creates a new client in a game without a host, e.g. the default
+// game. Games created on behalf of a host (i.e. via CreateGame or a lobby)
+// only admit the clients chosen by the host when the game was created.
 func (s *DownFluxServer) AddClient(ctx context.Context, req *apipb.AddClientRequest) (*apipb.AddClientResponse, error) {
 	log.Println("new Client request")
-	cid, err := s.utils.Executor().AddClient()
+	gid := manager.DefaultGameID
+	if req.GetGameId() != "" {
+		gid = id.GameID(req.GetGameId())
+	}
+	u, err := s.manager.Game(gid)
+	if err != nil {
+		return nil, err
+	}
+	host, err := s.manager.Host(gid)
+	if err != nil {
+		return nil, err
+	}
+	if host != "" {
+		return nil, status.Errorf(codes.PermissionDenied, "game %v only admits clients chosen by its host", gid)
+	}
+	cid, err := u.Executor().AddClient()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.AddAccount(cid); err != nil {
+		return nil, err
+	}
+	token, err := s.sessions.Add(cid)
 	if err != nil {
 		return nil, err
 	}
@@ -135,30 +468,53 @@ func (s *DownFluxServer) AddClient(ctx context.Context, req *apipb.AddClientRequ
 		ClientId: &gdpb.ClientID{
 			ClientId: cid.Value(),
 		},
+		SessionToken: token,
 	}
 	return resp, nil
 }
 
+// ReclaimClient issues a new session token to the requesting client. The
+// previous token of the client has already been validated by the session
+// interceptor.
+func (s *DownFluxServer) ReclaimClient(ctx context.Context, req *apipb.ReclaimClientRequest) (*apipb.ReclaimClientResponse, error) {
+	log.Println("new ReclaimClient request")
+	token, err := s.sessions.Reclaim(id.ClientID(req.GetClientId()))
+	if err != nil {
+		return nil, err
+	}
+	return &apipb.ReclaimClientResponse{
+		SessionToken: token,
+	}, nil
+}
+
 func (s *DownFluxServer) StreamData(req *apipb.StreamDataRequest, stream apipb.DownFlux_StreamDataServer) error {
 	log.Println("new StreamData request")
 	cid := id.ClientID(req.GetClientId())
 
-	if err := s.validateClient(cid); err != nil {
+	u, err := s.game(req.GetGameId())
+	if err != nil {
+		return err
+	}
+	if err := validateClient(u, cid); err != nil {
 		return err
 	}
 
+	// The client is always sent the full game state when the stream is
+	// (re)started, so the requested tick is not checked against the
+	// command window -- a client which reconnects after a long outage must
+	// still be able to resync.
 	md := client.New()
 	defer func() {
-		s.utils.Executor().StopClientStreamError(cid)
+		u.Executor().StopClientStreamError(cid)
 		md.Close()
 		log.Println("closing StreamData request")
 	}()
 
-	if err := s.utils.Executor().StartClientStream(cid); err != nil {
+	if err := u.Executor().StartClientStream(cid); err != nil {
 		return err
 	}
 
-	ch, err := s.utils.Executor().ClientChannel(cid)
+	ch, err := u.Executor().ClientChannel(cid)
 	if err != nil {
 		return err
 	}
@@ -197,7 +553,7 @@ func (s *DownFluxServer) StreamData(req *apipb.StreamDataRequest, stream apipb.D
 
 		// We don't need to broadcast data faster than the executor can
 		// produce it.
-		time.Sleep(s.utils.Status().TickDuration() / 2)
+		time.Sleep(u.Status().TickDuration() / 2)
 	}
 	return nil
 }
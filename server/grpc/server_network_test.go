@@ -9,12 +9,14 @@ import (
 	"time"
 
 	"github.com/Shopify/toxiproxy"
+	"github.com/downflux/game/server/grpc/auth"
 	"github.com/downflux/game/server/grpc/handler"
 	"github.com/downflux/game/server/grpc/option"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	_ "google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	tpc "github.com/Shopify/toxiproxy/client"
@@ -155,6 +157,7 @@ func TestServerCloseStream(t *testing.T) {
 					grpc.StatsHandler(&handler.DownFluxHandler{})),
 				nil,
 				nil,
+				nil,
 				tickDuration,
 				minPathLength)
 			if err != nil {
@@ -175,7 +178,8 @@ func TestServerCloseStream(t *testing.T) {
 				t.Fatalf("AddClient() = _, %v, want = nil", err)
 			}
 
-			stream, err := client.StreamData(context.Background(), &apipb.StreamDataRequest{
+			ctx := metadata.AppendToOutgoingContext(context.Background(), auth.TokenKey, clientResp.GetSessionToken())
+			stream, err := client.StreamData(ctx, &apipb.StreamDataRequest{
 				ClientId: clientResp.GetClientId().GetClientId(),
 			})
 			if err != nil {
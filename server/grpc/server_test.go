@@ -9,10 +9,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/registrytest"
+	"github.com/downflux/game/server/grpc/auth"
+	"github.com/downflux/game/server/grpc/manager"
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/testing/protocmp"
@@ -51,6 +56,8 @@ var (
 			{Coordinate: &gdpb.Coordinate{X: 3, Y: 0}, TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS},
 		},
 	}
+
+	registryPB = registrytest.Registry()
 )
 
 type sut struct {
@@ -74,11 +81,11 @@ func newConn(s *sut) (*grpc.ClientConn, error) {
 }
 
 func newSUT() (*sut, error) {
-	gRPCServer := grpc.NewServer()
-	gRPCServerImpl, err := NewDownFluxServer(simpleLinearMapProto, &gdpb.Coordinate{X: 2, Y: 1}, tickDuration, minPathLength)
+	gRPCServerImpl, err := NewDownFluxServer(simpleLinearMapProto, registryPB, &gdpb.Coordinate{X: 2, Y: 1}, tickDuration, minPathLength)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "could not create SUT: %v", err)
 	}
+	gRPCServer := grpc.NewServer(gRPCServerImpl.Sessions().ServerOptions()...)
 	apipb.RegisterDownFluxServer(gRPCServer, gRPCServerImpl)
 	listener := bufconn.Listen(bufSize)
 
@@ -104,13 +111,8 @@ func TestSendMoveCommand(t *testing.T) {
 	}
 	defer conn.Close()
 
-	// TODO(minkezhang): This is a hack -- clients should get the entities
-	// via broadcast.
-	s.gRPCServerImpl.Utils().ProduceDebug(gcpb.EntityType_ENTITY_TYPE_TANK, src)
-
 	var eg errgroup.Group
 	eg.Go(func() error { return s.gRPCServer.Serve(s.listener) })
-	eg.Go(func() error { return s.gRPCServerImpl.Utils().Executor().Run() })
 
 	client := apipb.NewDownFluxClient(conn)
 	resp, err := client.AddClient(s.ctx, &apipb.AddClientRequest{})
@@ -118,7 +120,16 @@ func TestSendMoveCommand(t *testing.T) {
 		t.Fatalf("AddPlayer() = _, %v, want = nil", err)
 	}
 	cid := resp.GetClientId().GetClientId()
-	stream, err := client.StreamData(s.ctx, &apipb.StreamDataRequest{
+
+	// TODO(minkezhang): This is a hack -- clients should get the entities
+	// via broadcast.
+	if err := s.gRPCServerImpl.Utils().ProduceFree(gcpb.EntityType_ENTITY_TYPE_TANK, src, id.ClientID(cid)); err != nil {
+		t.Fatalf("ProduceFree() = %v, want = nil", err)
+	}
+	eg.Go(func() error { return s.gRPCServerImpl.Utils().Executor().Run() })
+
+	ctx := metadata.AppendToOutgoingContext(s.ctx, auth.TokenKey, resp.GetSessionToken())
+	stream, err := client.StreamData(ctx, &apipb.StreamDataRequest{
 		ClientId: cid,
 	})
 	if err != nil {
@@ -145,7 +156,7 @@ func TestSendMoveCommand(t *testing.T) {
 
 	eid := m.GetState().GetEntities()[0].GetEntityId()
 
-	if _, err := client.Move(s.ctx, &apipb.MoveRequest{
+	if _, err := client.Move(ctx, &apipb.MoveRequest{
 		ClientId:    cid,
 		EntityIds:   []string{eid},
 		Tick:        tick,
@@ -230,6 +241,74 @@ func TestSendMoveCommand(t *testing.T) {
 	}
 }
 
+func TestStreamDataStaleTick(t *testing.T) {
+	s, err := newSUT()
+	if err != nil {
+		t.Fatalf("newSUT() = _, %v, want = nil", err)
+	}
+	conn, err := newConn(s)
+	if err != nil {
+		t.Fatalf("newConn() = _, %v, want = nil", err)
+	}
+	defer conn.Close()
+
+	var eg errgroup.Group
+	eg.Go(func() error { return s.gRPCServer.Serve(s.listener) })
+
+	client := apipb.NewDownFluxClient(conn)
+	resp, err := client.AddClient(s.ctx, &apipb.AddClientRequest{})
+	if err != nil {
+		t.Fatalf("AddClient() = _, %v, want = nil", err)
+	}
+
+	// Advance the game past the command window of the reconnecting
+	// client.
+	e := s.gRPCServerImpl.Utils().Executor()
+	e.SetCommandWindow(1, 1)
+	for i := 0; i < 5; i++ {
+		if err := e.Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+	eg.Go(func() error { return e.Run() })
+
+	ctx := metadata.AppendToOutgoingContext(s.ctx, auth.TokenKey, resp.GetSessionToken())
+	stream, err := client.StreamData(ctx, &apipb.StreamDataRequest{
+		ClientId: resp.GetClientId().GetClientId(),
+		Tick:     0,
+	})
+	if err != nil {
+		t.Fatalf("StreamData() = _, %v, want = nil", err)
+	}
+
+	// The client is sent the full game state instead of being rejected.
+	m, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() = _, %v, want = nil", err)
+	}
+	if got := m.GetTick(); got < 5 {
+		t.Errorf("GetTick() = %v, want >= %v", got, 5)
+	}
+
+	if err := e.Stop(); err != nil {
+		t.Fatalf("Stop() = %v, want = nil", err)
+	}
+	eg.Go(func() error {
+		for {
+			if _, err := stream.Recv(); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+	})
+	s.gRPCServer.GracefulStop()
+
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("Wait() = %v, want = nil", err)
+	}
+}
+
 func TestAddClient(t *testing.T) {
 	s, err := newSUT()
 	if err != nil {
@@ -252,6 +331,193 @@ func TestAddClient(t *testing.T) {
 	if resp.GetClientId().GetClientId() == "" {
 		t.Fatalf("GetClientId() = %v, want a non-empty value", err)
 	}
+	if resp.GetSessionToken() == "" {
+		t.Fatalf("GetSessionToken() = %v, want a non-empty value", err)
+	}
+
+	// Requests on behalf of the client must carry the session token.
+	if _, err := client.Move(s.ctx, &apipb.MoveRequest{
+		ClientId: resp.GetClientId().GetClientId(),
+	}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Move() = _, %v, want = %v", err, codes.Unauthenticated)
+	}
+
+	s.gRPCServer.GracefulStop()
+	if err := eg.Wait(); err != nil {
+		t.Errorf("Wait() = %v, want = nil", err)
+	}
+}
+
+func TestReclaimClient(t *testing.T) {
+	s, err := newSUT()
+	if err != nil {
+		t.Fatalf("newSUT() = _, %v, want = nil", err)
+	}
+	resp, err := s.gRPCServerImpl.AddClient(s.ctx, &apipb.AddClientRequest{})
+	if err != nil {
+		t.Fatalf("AddClient() = _, %v, want = nil", err)
+	}
+	cid := resp.GetClientId().GetClientId()
+
+	pb, err := s.gRPCServerImpl.SaveSnapshot()
+	if err != nil {
+		t.Fatalf("SaveSnapshot() = _, %v, want = nil", err)
+	}
+
+	// The restored server recognizes the client by its previous session
+	// token.
+	r, err := newSUT()
+	if err != nil {
+		t.Fatalf("newSUT() = _, %v, want = nil", err)
+	}
+	if err := r.gRPCServerImpl.LoadSnapshot(pb); err != nil {
+		t.Fatalf("LoadSnapshot() = %v, want = nil", err)
+	}
+	if !r.gRPCServerImpl.Utils().Executor().ClientExists(id.ClientID(cid)) {
+		t.Fatalf("ClientExists() = false, want = true")
+	}
+
+	conn, err := newConn(r)
+	if err != nil {
+		t.Fatalf("newConn() = _, %v, want = nil", err)
+	}
+	defer conn.Close()
+	var eg errgroup.Group
+	eg.Go(func() error { return r.gRPCServer.Serve(r.listener) })
+
+	client := apipb.NewDownFluxClient(conn)
+	reclaimed, err := client.ReclaimClient(
+		metadata.AppendToOutgoingContext(r.ctx, auth.TokenKey, resp.GetSessionToken()),
+		&apipb.ReclaimClientRequest{ClientId: cid})
+	if err != nil {
+		t.Fatalf("ReclaimClient() = _, %v, want = nil", err)
+	}
+	if reclaimed.GetSessionToken() == "" {
+		t.Fatalf("GetSessionToken() = \"\", want a non-empty value")
+	}
+
+	// The previous session token is no longer valid.
+	if _, err := client.ReclaimClient(
+		metadata.AppendToOutgoingContext(r.ctx, auth.TokenKey, resp.GetSessionToken()),
+		&apipb.ReclaimClientRequest{ClientId: cid}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("ReclaimClient() = _, %v, want = %v", err, codes.PermissionDenied)
+	}
+	if _, err := client.ReclaimClient(
+		metadata.AppendToOutgoingContext(r.ctx, auth.TokenKey, reclaimed.GetSessionToken()),
+		&apipb.ReclaimClientRequest{ClientId: cid}); err != nil {
+		t.Errorf("ReclaimClient() = _, %v, want = nil", err)
+	}
+
+	r.gRPCServer.GracefulStop()
+	if err := eg.Wait(); err != nil {
+		t.Errorf("Wait() = %v, want = nil", err)
+	}
+}
+
+func TestCreateGame(t *testing.T) {
+	s, err := newSUT()
+	if err != nil {
+		t.Fatalf("newSut() = _, %v, want = nil", err)
+	}
+	s.gRPCServerImpl.Manager().AddMap("linear", simpleLinearMapProto)
+
+	conn, err := newConn(s)
+	if err != nil {
+		t.Fatalf("newConn() = _, %v, want = nil", err)
+	}
+	defer conn.Close()
+	var eg errgroup.Group
+	eg.Go(func() error { return s.gRPCServer.Serve(s.listener) })
+
+	client := apipb.NewDownFluxClient(conn)
+
+	// Games and lobbies may only be created on behalf of an existing
+	// client.
+	if _, err := client.CreateGame(s.ctx, &apipb.CreateGameRequest{MapName: "linear"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("CreateGame() = _, %v, want = %v", err, codes.Unauthenticated)
+	}
+	if _, err := client.CreateLobby(s.ctx, &apipb.CreateLobbyRequest{MapName: "linear"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("CreateLobby() = _, %v, want = %v", err, codes.Unauthenticated)
+	}
+
+	h, err := client.AddClient(s.ctx, &apipb.AddClientRequest{})
+	if err != nil {
+		t.Fatalf("AddClient() = _, %v, want = nil", err)
+	}
+	host := h.GetClientId().GetClientId()
+	hostCtx := metadata.AppendToOutgoingContext(s.ctx, auth.TokenKey, h.GetSessionToken())
+
+	m, err := client.AddClient(s.ctx, &apipb.AddClientRequest{})
+	if err != nil {
+		t.Fatalf("AddClient() = _, %v, want = nil", err)
+	}
+	member := m.GetClientId().GetClientId()
+
+	if _, err := client.CreateGame(hostCtx, &apipb.CreateGameRequest{ClientId: host, MapName: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("CreateGame() = _, %v, want = %v", err, codes.NotFound)
+	}
+
+	resp, err := client.CreateGame(hostCtx, &apipb.CreateGameRequest{
+		ClientId: host,
+		MapName:  "linear",
+		Members: []*gdpb.TeamMembership{
+			{ClientId: host, Team: 1},
+			{ClientId: member, Team: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateGame() = _, %v, want = nil", err)
+	}
+	gid := resp.GetGameId()
+
+	games, err := client.ListGames(s.ctx, &apipb.ListGamesRequest{})
+	if err != nil {
+		t.Fatalf("ListGames() = _, %v, want = nil", err)
+	}
+	if got := len(games.GetGames()); got != 2 {
+		t.Fatalf("len() = %v, want = %v", got, 2)
+	}
+
+	// The clients of a hosted game are chosen by the host.
+	u, err := s.gRPCServerImpl.Manager().Game(id.GameID(gid))
+	if err != nil {
+		t.Fatalf("Game() = _, %v, want = nil", err)
+	}
+	if got, err := u.Executor().ClientTeam(id.ClientID(member)); err != nil || got != 2 {
+		t.Errorf("ClientTeam() = %v, %v, want = %v, nil", got, err, 2)
+	}
+	if _, err := client.AddClient(s.ctx, &apipb.AddClientRequest{GameId: gid}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("AddClient() = _, %v, want = %v", err, codes.PermissionDenied)
+	}
+
+	// Games may only be deleted by the host of the game, and not by other
+	// clients in the game.
+	if _, err := client.DeleteGame(s.ctx, &apipb.DeleteGameRequest{GameId: gid}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("DeleteGame() = _, %v, want = %v", err, codes.Unauthenticated)
+	}
+	memberCtx := metadata.AppendToOutgoingContext(s.ctx, auth.TokenKey, m.GetSessionToken())
+	if _, err := client.DeleteGame(memberCtx, &apipb.DeleteGameRequest{
+		ClientId: member,
+		GameId:   gid,
+	}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("DeleteGame() = _, %v, want = %v", err, codes.PermissionDenied)
+	}
+	if _, err := client.DeleteGame(hostCtx, &apipb.DeleteGameRequest{
+		ClientId: host,
+		GameId:   manager.DefaultGameID.Value(),
+	}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("DeleteGame() = _, %v, want = %v", err, codes.PermissionDenied)
+	}
+
+	if _, err := client.DeleteGame(hostCtx, &apipb.DeleteGameRequest{
+		ClientId: host,
+		GameId:   gid,
+	}); err != nil {
+		t.Fatalf("DeleteGame() = _, %v, want = nil", err)
+	}
+	if _, err := client.GetStatus(s.ctx, &apipb.GetStatusRequest{GameId: gid}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetStatus() = _, %v, want = %v", err, codes.NotFound)
+	}
 
 	s.gRPCServer.GracefulStop()
 	if err := eg.Wait(); err != nil {
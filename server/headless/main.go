@@ -0,0 +1,121 @@
+// Package main runs a game without a gRPC server or connected clients,
+// executing ticks as fast as possible. This is used for running simulated
+// battles in CI and balance experiments.
+//
+// Example
+//
+//  bazel run -c opt //server/headless:main -- \
+//    --snapshot_file=${F} \
+//    --end_tick=10000 \
+//    --output_file=${G}
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"time"
+
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/grpc/executorutils"
+	"github.com/downflux/game/server/snapshot/snapshot"
+	"github.com/golang/protobuf/proto"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+	sdpb "github.com/downflux/game/server/snapshot/api/data_go_proto"
+)
+
+var (
+	mapFile      = flag.String("map_file", "data/map/demo.textproto", "game map textproto file")
+	registryFile = flag.String("registry_file", "data/entity/registry.textproto", "unit definition registry textproto file")
+
+	// tickDurationMS is the simulated tick duration. This affects e.g.
+	// the number of ticks necessary for an entity to travel between two
+	// tiles, but does not affect how fast the simulation runs.
+	tickDurationMS = flag.Int("tick_ms", 100, "simulated loop time duration")
+
+	minPathLength = flag.Int("path_length", 8, "target lookahead path length for partial moves")
+
+	// snapshotFile is the initial state of the simulated game. If unset,
+	// the simulation starts with the same entities as the debug server.
+	snapshotFile = flag.String("snapshot_file", "", "initial game state textproto file")
+
+	endTick    = flag.Float64("end_tick", 1000, "last tick to simulate")
+	outputFile = flag.String("output_file", "", "final game state textproto output file")
+)
+
+func main() {
+	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+	flag.Parse()
+
+	d, err := ioutil.ReadFile(*mapFile)
+	if err != nil {
+		log.Fatalf("could not open map file %s: %v", *mapFile, err)
+	}
+	mapPB := &mdpb.TileMap{}
+	if err := proto.UnmarshalText(string(d), mapPB); err != nil {
+		log.Fatalf("could not parse map file: %v", err)
+	}
+
+	d, err = ioutil.ReadFile(*registryFile)
+	if err != nil {
+		log.Fatalf("could not open registry file %s: %v", *registryFile, err)
+	}
+	registryPB := &edpb.Registry{}
+	if err := proto.UnmarshalText(string(d), registryPB); err != nil {
+		log.Fatalf("could not parse registry file: %v", err)
+	}
+
+	u, err := executorutils.New(
+		mapPB,
+		registryPB,
+		&gdpb.Coordinate{X: 5, Y: 5},
+		time.Duration(*tickDurationMS)*time.Millisecond,
+		*minPathLength)
+	if err != nil {
+		log.Fatalf("could not construct game instance: %v", err)
+	}
+
+	if *snapshotFile != "" {
+		d, err := ioutil.ReadFile(*snapshotFile)
+		if err != nil {
+			log.Fatalf("could not open snapshot file %s: %v", *snapshotFile, err)
+		}
+		pb := &sdpb.Snapshot{}
+		if err := proto.UnmarshalText(string(d), pb); err != nil {
+			log.Fatalf("could not parse snapshot file: %v", err)
+		}
+		if err := snapshot.Load(u, pb); err != nil {
+			log.Fatalf("could not restore game from snapshot: %v", err)
+		}
+	} else {
+		u.ProduceDebug(gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 1, Y: 1})
+		u.ProduceDebug(gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 2, Y: 1})
+	}
+
+	start := u.Status().Tick()
+
+	u.Executor().SetFastForward(true)
+	u.Executor().SetEndTick(id.Tick(*endTick))
+	if err := u.Executor().Run(); err != nil {
+		log.Fatalf("could not run simulation: %v", err)
+	}
+
+	log.Printf(
+		"simulated %.f ticks (%.f ticks/s)",
+		u.Status().Tick()-start,
+		u.Executor().TicksPerSecond())
+
+	if *outputFile != "" {
+		pb, err := snapshot.Save(u)
+		if err != nil {
+			log.Fatalf("could not save snapshot: %v", err)
+		}
+		if err := ioutil.WriteFile(*outputFile, []byte(proto.MarshalTextString(pb)), 0644); err != nil {
+			log.Fatalf("could not write output file %s: %v", *outputFile, err)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+// Package referee implements pluggable victory conditions, which decide when a
+// game has ended and which clients have won.
+//
+// Example
+//
+//  r := referee.New(u.GameState().Entities(), u.Executor().Teams, []referee.Condition{
+//    referee.LastTeamStanding(),
+//    referee.TimeLimit(18000),
+//  })
+//  u.Executor().SetReferee(r)
+package referee
+
+import (
+	"fmt"
+
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/component/targetable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	entitylist "github.com/downflux/game/engine/entity/list"
+)
+
+// State is a read-only view of the game state evaluated by each Condition.
+type State struct {
+	Tick     id.Tick
+	Entities []entity.Entity
+
+	// Teams is the team membership of all clients in the game.
+	Teams []*gdpb.TeamMembership
+}
+
+// alliance returns a key which is shared by all clients allied with the input
+// client. Clients on team 0 are allied only with themselves.
+func (s *State) alliance(cid id.ClientID) string {
+	for _, t := range s.Teams {
+		if t.GetClientId() == cid.Value() && t.GetTeam() != 0 {
+			return fmt.Sprintf("team:%v", t.GetTeam())
+		}
+	}
+	return fmt.Sprintf("client:%v", cid)
+}
+
+// Outcome represents the result of a triggered Condition.
+type Outcome struct {
+	Reason gcpb.GameOverReason
+
+	// Winners is the set of clients which have won the game. The game is a
+	// draw if this is empty.
+	Winners map[id.ClientID]bool
+}
+
+// Condition is a single victory condition.
+type Condition interface {
+	// Evaluate returns the outcome of the game if the condition has been
+	// met, and nil otherwise.
+	Evaluate(s *State) (*Outcome, error)
+}
+
+// Stateful is implemented by Condition instances which track state across
+// ticks. The state is rewound along with the game when the game is rolled
+// back. See executor.Stateful.
+type Stateful interface {
+	// Save exports the current state of the Condition.
+	Save() interface{}
+
+	// Load replaces the current state of the Condition with an exported
+	// state.
+	Load(state interface{}) error
+}
+
+// unit checks if the input entity is a unit, i.e. may be targeted and
+// destroyed, and is owned by a client.
+func unit(e entity.Entity, tick id.Tick) bool {
+	_, ok := e.(targetable.Component)
+	return ok && e.ClientID(tick) != ""
+}
+
+// alive checks if the input entity has not been destroyed as of the input
+// tick.
+func alive(e entity.Entity, tick id.Tick) bool {
+	if e.End() != 0 && e.End() <= tick {
+		return false
+	}
+	if t, ok := e.(targetable.Component); ok && t.TargetHealth(tick) <= 0 {
+		return false
+	}
+	return true
+}
+
+// Referee evaluates a list of victory conditions at the end of each tick, and
+// implements the executor.Referee interface.
+type Referee struct {
+	entities   *entitylist.List              // Read-only.
+	teams      func() []*gdpb.TeamMembership // Read-only.
+	conditions []Condition                   // Read-only.
+}
+
+// New constructs a new Referee instance. The game ends when the first of the
+// input conditions is met.
+func New(entities *entitylist.List, teams func() []*gdpb.TeamMembership, conditions []Condition) *Referee {
+	return &Referee{
+		entities:   entities,
+		teams:      teams,
+		conditions: conditions,
+	}
+}
+
+// Evaluate returns the final results of the game if any victory condition has
+// been met as of the input tick, and nil otherwise.
+func (r *Referee) Evaluate(tick id.Tick) (*gdpb.GameOver, error) {
+	s := &State{
+		Tick:     tick,
+		Entities: r.entities.Iter(),
+		Teams:    r.teams(),
+	}
+	for _, c := range r.conditions {
+		o, err := c.Evaluate(s)
+		if err != nil {
+			return nil, err
+		}
+		if o != nil {
+			return export(s, o), nil
+		}
+	}
+	return nil, nil
+}
+
+// Save exports the state of all conditions. This implements the
+// executor.Stateful interface.
+func (r *Referee) Save() interface{} {
+	states := make([]interface{}, len(r.conditions))
+	for i, c := range r.conditions {
+		if s, ok := c.(Stateful); ok {
+			states[i] = s.Save()
+		}
+	}
+	return states
+}
+
+// Load restores the state of all conditions from the output of Save.
+func (r *Referee) Load(state interface{}) error {
+	states, ok := state.([]interface{})
+	if !ok || len(states) != len(r.conditions) {
+		return status.Errorf(codes.InvalidArgument, "invalid referee state %v", state)
+	}
+	for i, c := range r.conditions {
+		if s, ok := c.(Stateful); ok {
+			if err := s.Load(states[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// export generates the final results of the game, including the end-of-game
+// statistics of each client.
+func export(s *State, o *Outcome) *gdpb.GameOver {
+	pb := &gdpb.GameOver{
+		Tick:   s.Tick.Value(),
+		Reason: o.Reason,
+	}
+
+	results := map[id.ClientID]*gdpb.ClientResult{}
+	for _, t := range s.Teams {
+		cid := id.ClientID(t.GetClientId())
+
+		r := &gdpb.ClientResult{
+			ClientId: cid.Value(),
+			Team:     t.GetTeam(),
+			Result:   gcpb.GameResult_GAME_RESULT_LOSS,
+		}
+		if len(o.Winners) == 0 {
+			r.Result = gcpb.GameResult_GAME_RESULT_DRAW
+		} else if o.Winners[cid] {
+			r.Result = gcpb.GameResult_GAME_RESULT_WIN
+		}
+
+		results[cid] = r
+		pb.Results = append(pb.GetResults(), r)
+	}
+
+	for _, e := range s.Entities {
+		if !unit(e, s.Tick) {
+			continue
+		}
+		r, found := results[e.ClientID(s.Tick)]
+		if !found {
+			continue
+		}
+		if alive(e, s.Tick) {
+			r.UnitsAlive++
+		} else {
+			r.UnitsLost++
+		}
+	}
+	return pb
+}
+
+// winners returns the set of clients which belong to any of the input
+// alliances.
+func winners(s *State, alliances map[string]bool) map[id.ClientID]bool {
+	cids := map[id.ClientID]bool{}
+	for _, t := range s.Teams {
+		cid := id.ClientID(t.GetClientId())
+		if alliances[s.alliance(cid)] {
+			cids[cid] = true
+		}
+	}
+	return cids
+}
+
+// lastTeamStanding implements the LastTeamStanding Condition.
+type lastTeamStanding struct {
+	// fielded is the set of alliances which have had at least one unit
+	// during the game.
+	fielded map[string]bool
+}
+
+// LastTeamStanding returns a Condition which is met once at most one alliance
+// has any units left. Only alliances which have fielded units during the game
+// are considered, and the Condition is not met until at least two alliances
+// have fielded units.
+func LastTeamStanding() Condition {
+	return &lastTeamStanding{
+		fielded: map[string]bool{},
+	}
+}
+
+func (c *lastTeamStanding) Evaluate(s *State) (*Outcome, error) {
+	standing := map[string]bool{}
+	for _, e := range s.Entities {
+		if !unit(e, s.Tick) {
+			continue
+		}
+		a := s.alliance(e.ClientID(s.Tick))
+		c.fielded[a] = true
+		if alive(e, s.Tick) {
+			standing[a] = true
+		}
+	}
+
+	if len(c.fielded) < 2 || len(standing) > 1 {
+		return nil, nil
+	}
+	return &Outcome{
+		Reason:  gcpb.GameOverReason_GAME_OVER_REASON_LAST_TEAM_STANDING,
+		Winners: winners(s, standing),
+	}, nil
+}
+
+func (c *lastTeamStanding) Save() interface{} {
+	fielded := map[string]bool{}
+	for a := range c.fielded {
+		fielded[a] = true
+	}
+	return fielded
+}
+
+func (c *lastTeamStanding) Load(state interface{}) error {
+	fielded, ok := state.(map[string]bool)
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "invalid condition state %v", state)
+	}
+	c.fielded = map[string]bool{}
+	for a := range fielded {
+		c.fielded[a] = true
+	}
+	return nil
+}
+
+// timeLimit implements the TimeLimit Condition.
+type timeLimit struct {
+	end id.Tick // Read-only.
+}
+
+// TimeLimit returns a Condition which is met once the game reaches the input
+// tick. The game ends in a draw.
+func TimeLimit(end id.Tick) Condition { return timeLimit{end: end} }
+
+func (c timeLimit) Evaluate(s *State) (*Outcome, error) {
+	if s.Tick < c.end {
+		return nil, nil
+	}
+	return &Outcome{
+		Reason: gcpb.GameOverReason_GAME_OVER_REASON_TIME_LIMIT,
+	}, nil
+}
+
+// keyStructure implements the KeyStructure Condition.
+type keyStructure struct {
+	eid id.EntityID // Read-only.
+}
+
+// KeyStructure returns a Condition which is met once the input entity (e.g. a
+// headquarters) is destroyed. All clients which are not allied with the owner
+// of the entity win the game.
+func KeyStructure(eid id.EntityID) Condition { return keyStructure{eid: eid} }
+
+func (c keyStructure) Evaluate(s *State) (*Outcome, error) {
+	for _, e := range s.Entities {
+		if e.ID() != c.eid {
+			continue
+		}
+		if alive(e, s.Tick) {
+			return nil, nil
+		}
+
+		loser := s.alliance(e.ClientID(s.Tick))
+		alliances := map[string]bool{}
+		for _, t := range s.Teams {
+			if a := s.alliance(id.ClientID(t.GetClientId())); a != loser {
+				alliances[a] = true
+			}
+		}
+		return &Outcome{
+			Reason:  gcpb.GameOverReason_GAME_OVER_REASON_KEY_STRUCTURE_DESTROYED,
+			Winners: winners(s, alliances),
+		}, nil
+	}
+	return nil, nil
+}
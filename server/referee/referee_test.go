@@ -0,0 +1,191 @@
+package referee
+
+import (
+	"testing"
+
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/registrytest"
+	"github.com/downflux/game/server/entity/tank"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	entitylist "github.com/downflux/game/engine/entity/list"
+)
+
+var (
+	tankPB = registrytest.Tank()
+)
+
+// newGame constructs a game with a single tank for each client in the input
+// team membership list.
+func newGame(t *testing.T, teams []*gdpb.TeamMembership) (*entitylist.List, map[id.ClientID]*tank.Entity) {
+	entities := entitylist.New()
+	tanks := map[id.ClientID]*tank.Entity{}
+	for i, m := range teams {
+		cid := id.ClientID(m.GetClientId())
+		e, err := tank.New(tankPB, id.EntityID(cid.Value()), 0, &gdpb.Position{X: float64(i), Y: 0}, cid, nil)
+		if err != nil {
+			t.Fatalf("New() = _, %v, want = nil", err)
+		}
+		if err := entities.Append(e); err != nil {
+			t.Fatalf("Append() = %v, want = nil", err)
+		}
+		tanks[cid] = e
+	}
+	return entities, tanks
+}
+
+// destroy sets the health of the input tank to zero at the input tick.
+func destroy(t *testing.T, e *tank.Entity, tick id.Tick) {
+	if err := e.TargetHealthCurve().Add(tick, -e.TargetHealth(tick)); err != nil {
+		t.Fatalf("Add() = %v, want = nil", err)
+	}
+}
+
+func results(pb *gdpb.GameOver) map[string]gcpb.GameResult {
+	r := map[string]gcpb.GameResult{}
+	for _, c := range pb.GetResults() {
+		r[c.GetClientId()] = c.GetResult()
+	}
+	return r
+}
+
+func TestLastTeamStanding(t *testing.T) {
+	teams := []*gdpb.TeamMembership{
+		{ClientId: "client-a", Team: 1},
+		{ClientId: "client-b", Team: 1},
+		{ClientId: "client-c", Team: 2},
+	}
+	entities, tanks := newGame(t, teams)
+	r := New(entities, func() []*gdpb.TeamMembership { return teams }, []Condition{LastTeamStanding()})
+
+	if pb, err := r.Evaluate(1); err != nil || pb != nil {
+		t.Fatalf("Evaluate() = %v, %v, want = nil, nil", pb, err)
+	}
+
+	// Team 1 is still standing while any of its units are alive.
+	destroy(t, tanks["client-a"], 2)
+	if pb, err := r.Evaluate(2); err != nil || pb != nil {
+		t.Fatalf("Evaluate() = %v, %v, want = nil, nil", pb, err)
+	}
+
+	destroy(t, tanks["client-c"], 3)
+	pb, err := r.Evaluate(3)
+	if err != nil || pb == nil {
+		t.Fatalf("Evaluate() = %v, %v, want a non-nil value, nil", pb, err)
+	}
+	if got := pb.GetReason(); got != gcpb.GameOverReason_GAME_OVER_REASON_LAST_TEAM_STANDING {
+		t.Errorf("GetReason() = %v, want = %v", got, gcpb.GameOverReason_GAME_OVER_REASON_LAST_TEAM_STANDING)
+	}
+
+	want := map[string]gcpb.GameResult{
+		"client-a": gcpb.GameResult_GAME_RESULT_WIN,
+		"client-b": gcpb.GameResult_GAME_RESULT_WIN,
+		"client-c": gcpb.GameResult_GAME_RESULT_LOSS,
+	}
+	got := results(pb)
+	for cid, w := range want {
+		if got[cid] != w {
+			t.Errorf("GetResult() = %v, want = %v", got[cid], w)
+		}
+	}
+
+	for _, c := range pb.GetResults() {
+		if c.GetClientId() == "client-a" && (c.GetUnitsAlive() != 0 || c.GetUnitsLost() != 1) {
+			t.Errorf("GetUnitsAlive(), GetUnitsLost() = %v, %v, want = %v, %v", c.GetUnitsAlive(), c.GetUnitsLost(), 0, 1)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	teams := []*gdpb.TeamMembership{{ClientId: "client-a"}, {ClientId: "client-b"}}
+	entities, _ := newGame(t, teams)
+	c := LastTeamStanding().(*lastTeamStanding)
+	r := New(entities, func() []*gdpb.TeamMembership { return teams }, []Condition{c, TimeLimit(100)})
+
+	before := r.Save()
+	if _, err := r.Evaluate(1); err != nil {
+		t.Fatalf("Evaluate() = _, %v, want = nil", err)
+	}
+	after := r.Save()
+
+	if err := r.Load(before); err != nil {
+		t.Fatalf("Load() = %v, want = nil", err)
+	}
+	if got := len(c.fielded); got != 0 {
+		t.Errorf("len() = %v, want = %v", got, 0)
+	}
+
+	if err := r.Load(after); err != nil {
+		t.Fatalf("Load() = %v, want = nil", err)
+	}
+	if got := len(c.fielded); got != 2 {
+		t.Errorf("len() = %v, want = %v", got, 2)
+	}
+
+	if err := r.Load(nil); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Load() = %v, want = %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestLastTeamStandingSingleTeam(t *testing.T) {
+	teams := []*gdpb.TeamMembership{
+		{ClientId: "client-a", Team: 1},
+		{ClientId: "client-b", Team: 1},
+	}
+	entities, _ := newGame(t, teams)
+	r := New(entities, func() []*gdpb.TeamMembership { return teams }, []Condition{LastTeamStanding()})
+
+	if pb, err := r.Evaluate(1); err != nil || pb != nil {
+		t.Errorf("Evaluate() = %v, %v, want = nil, nil", pb, err)
+	}
+}
+
+func TestTimeLimit(t *testing.T) {
+	const end = 10
+
+	teams := []*gdpb.TeamMembership{{ClientId: "client-a"}, {ClientId: "client-b"}}
+	entities, _ := newGame(t, teams)
+	r := New(entities, func() []*gdpb.TeamMembership { return teams }, []Condition{TimeLimit(end)})
+
+	if pb, err := r.Evaluate(end - 1); err != nil || pb != nil {
+		t.Fatalf("Evaluate() = %v, %v, want = nil, nil", pb, err)
+	}
+	pb, err := r.Evaluate(end)
+	if err != nil || pb == nil {
+		t.Fatalf("Evaluate() = %v, %v, want a non-nil value, nil", pb, err)
+	}
+	for cid, got := range results(pb) {
+		if got != gcpb.GameResult_GAME_RESULT_DRAW {
+			t.Errorf("GetResult() = %v, want = %v for client %v", got, gcpb.GameResult_GAME_RESULT_DRAW, cid)
+		}
+	}
+}
+
+func TestKeyStructure(t *testing.T) {
+	teams := []*gdpb.TeamMembership{{ClientId: "client-a"}, {ClientId: "client-b"}}
+	entities, tanks := newGame(t, teams)
+	r := New(entities, func() []*gdpb.TeamMembership { return teams }, []Condition{KeyStructure(tanks["client-a"].ID())})
+
+	if pb, err := r.Evaluate(1); err != nil || pb != nil {
+		t.Fatalf("Evaluate() = %v, %v, want = nil, nil", pb, err)
+	}
+
+	destroy(t, tanks["client-a"], 2)
+	pb, err := r.Evaluate(2)
+	if err != nil || pb == nil {
+		t.Fatalf("Evaluate() = %v, %v, want a non-nil value, nil", pb, err)
+	}
+	want := map[string]gcpb.GameResult{
+		"client-a": gcpb.GameResult_GAME_RESULT_LOSS,
+		"client-b": gcpb.GameResult_GAME_RESULT_WIN,
+	}
+	got := results(pb)
+	for cid, w := range want {
+		if got[cid] != w {
+			t.Errorf("GetResult() = %v, want = %v", got[cid], w)
+		}
+	}
+}
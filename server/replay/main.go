@@ -0,0 +1,69 @@
+// Package main plays back a recorded game and prints the game state at each
+// tick.
+//
+// Example
+//
+//  bazel run //server/replay:main -- \
+//    --replay_file=${F} \
+//    --start_tick=10 \
+//    --end_tick=20
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/replay/replay"
+	"github.com/golang/protobuf/proto"
+
+	apipb "github.com/downflux/game/api/api_go_proto"
+	rdpb "github.com/downflux/game/server/replay/api/data_go_proto"
+)
+
+var (
+	replayFile = flag.String("replay_file", "", "recorded game textproto file")
+	startTick  = flag.Float64("start_tick", 0, "first tick at which to print the game state")
+
+	// endTick is the last tick at which the game state is printed. If
+	// unset, the game is played back until all recorded actions have
+	// been applied.
+	endTick = flag.Float64("end_tick", 0, "last tick at which to print the game state")
+)
+
+func main() {
+	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+	flag.Parse()
+
+	d, err := ioutil.ReadFile(*replayFile)
+	if err != nil {
+		log.Fatalf("could not open replay file %s: %v", *replayFile, err)
+	}
+
+	pb := &rdpb.Replay{}
+	if err := proto.UnmarshalText(string(d), pb); err != nil {
+		log.Fatalf("could not parse replay file: %v", err)
+	}
+
+	p, err := replay.NewPlayer(pb)
+	if err != nil {
+		log.Fatalf("could not construct replay player: %v", err)
+	}
+
+	for (*endTick == 0 && !p.Done()) || p.Tick() < id.Tick(*endTick) {
+		if err := p.Step(); err != nil {
+			log.Fatalf("could not play back tick %v: %v", p.Tick()+1, err)
+		}
+		if p.Tick() < id.Tick(*startTick) {
+			continue
+		}
+
+		state := p.Utils().GameState()
+		fmt.Println(proto.MarshalTextString(&apipb.StreamDataResponse{
+			Tick:  p.Tick().Value(),
+			State: state.Export(0, state.NoFilter()),
+		}))
+	}
+}
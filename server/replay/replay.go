@@ -0,0 +1,350 @@
+// Package replay records the actions scheduled by the Executor and plays them
+// back into a fresh Executor instance.
+//
+// A recorded game is deterministic -- playing back a Replay will reproduce
+// the same game state at every tick as the original game. This allows us to
+// attach replays to bug reports and step through desyncs.
+package replay
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/component/attackable"
+	"github.com/downflux/game/server/entity/component/moveable"
+	"github.com/downflux/game/server/entity/component/producer"
+	"github.com/downflux/game/server/entity/component/targetable"
+	"github.com/downflux/game/server/grpc/executorutils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+	attackaction "github.com/downflux/game/server/fsm/attack/attack"
+	chaseaction "github.com/downflux/game/server/fsm/move/chase"
+	moveaction "github.com/downflux/game/server/fsm/move/move"
+	produceaction "github.com/downflux/game/server/fsm/produce"
+	orderaction "github.com/downflux/game/server/fsm/production/order"
+	rdpb "github.com/downflux/game/server/replay/api/data_go_proto"
+)
+
+var (
+	orderTypes = map[orderaction.OrderType]rdpb.ProductionOrderType{
+		orderaction.Enqueue: rdpb.ProductionOrderType_PRODUCTION_ORDER_TYPE_ENQUEUE,
+		orderaction.Dequeue: rdpb.ProductionOrderType_PRODUCTION_ORDER_TYPE_DEQUEUE,
+		orderaction.Reorder: rdpb.ProductionOrderType_PRODUCTION_ORDER_TYPE_REORDER,
+		orderaction.Rally:   rdpb.ProductionOrderType_PRODUCTION_ORDER_TYPE_RALLY,
+	}
+)
+
+// Export converts an action scheduled via Executor.Schedule into its
+// serialized form.
+//
+// Only actions which may be issued by a client are exported. Chase actions are
+// not exported on their own, as the chase action created for an attack
+// command is reconstructed from the attack during playback.
+func Export(tick id.Tick, a action.Action) (*rdpb.Action, error) {
+	pb := &rdpb.Action{Tick: tick.Value()}
+
+	switch i := a.(type) {
+	case *moveaction.Action:
+		if i.MoveType() != moveaction.Default {
+			return nil, status.Errorf(codes.Unimplemented, "cannot export move action of type %v", i.MoveType())
+		}
+		pb.Action = &rdpb.Action_Move{
+			Move: &rdpb.Move{
+				EntityId:    i.Component().ID().Value(),
+				Destination: i.Destination(),
+			},
+		}
+	case *attackaction.Action:
+		pb.Action = &rdpb.Action_Attack{
+			Attack: &rdpb.Attack{
+				EntityId:       i.Source().ID().Value(),
+				TargetEntityId: i.Target().ID().Value(),
+			},
+		}
+	case *chaseaction.Action:
+		return nil, status.Error(codes.Unimplemented, "cannot export chase action outside of an attack action")
+	case *produceaction.Action:
+		pb.Action = &rdpb.Action_Produce{
+			Produce: &rdpb.Produce{
+				ActionId:      i.ID().Value(),
+				ExecutionTick: i.ExecutionTick().Value(),
+				EntityType:    i.EntityType(),
+				SpawnPosition: i.SpawnPosition(),
+				ClientId:      i.SpawnClientID().Value(),
+				Free:          i.PaymentType() == produceaction.Free,
+				RallyPoint:    i.RallyPoint(),
+			},
+		}
+	case *orderaction.Action:
+		pb.Action = &rdpb.Action_ProductionOrder{
+			ProductionOrder: &rdpb.ProductionOrder{
+				ActionId:     i.ID().Value(),
+				EntityId:     i.Factory().ID().Value(),
+				OrderType:    orderTypes[i.OrderType()],
+				EntityType:   i.EntityType(),
+				ProductionId: i.ItemID().Value(),
+				Index:        int32(i.Index()),
+				RallyPoint:   i.RallyPoint(),
+			},
+		}
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "cannot export action of type %v", a.Type())
+	}
+
+	return pb, nil
+}
+
+// Import reconstructs the list of actions represented by the serialized
+// action. Entities referenced by the action must already exist in the game
+// state.
+func Import(u *executorutils.Utils, pb *rdpb.Action) ([]action.Action, error) {
+	entities := u.GameState().Entities()
+
+	switch pb.GetAction().(type) {
+	case *rdpb.Action_Move:
+		m, ok := entities.Get(id.EntityID(pb.GetMove().GetEntityId())).(moveable.Component)
+		if !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "entity %v is not moveable", pb.GetMove().GetEntityId())
+		}
+		return []action.Action{
+			moveaction.New(m, u.Status(), pb.GetMove().GetDestination(), moveaction.Default),
+		}, nil
+	case *rdpb.Action_Attack:
+		t, ok := entities.Get(id.EntityID(pb.GetAttack().GetTargetEntityId())).(targetable.Component)
+		if !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "entity %v is not targetable", pb.GetAttack().GetTargetEntityId())
+		}
+		e := entities.Get(id.EntityID(pb.GetAttack().GetEntityId()))
+		a, ok := e.(attackable.Component)
+		if !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "entity %v is not attackable", pb.GetAttack().GetEntityId())
+		}
+		m, ok := e.(moveable.Component)
+		if !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "entity %v is not moveable", pb.GetAttack().GetEntityId())
+		}
+
+		chaseAction := chaseaction.New(u.Status(), m, t)
+		return []action.Action{
+			chaseAction,
+			attackaction.New(u.Status(), a, t, chaseAction),
+		}, nil
+	case *rdpb.Action_Produce:
+		p := produceaction.Charged
+		if pb.GetProduce().GetFree() {
+			p = produceaction.Free
+		}
+		a := produceaction.NewWithID(
+			id.ActionID(pb.GetProduce().GetActionId()),
+			u.Status(),
+			id.Tick(pb.GetProduce().GetExecutionTick()),
+			pb.GetProduce().GetEntityType(),
+			pb.GetProduce().GetSpawnPosition(),
+			id.ClientID(pb.GetProduce().GetClientId()),
+			p)
+		if r := pb.GetProduce().GetRallyPoint(); r != nil {
+			a.SetRallyPoint(r)
+		}
+		return []action.Action{a}, nil
+	case *rdpb.Action_ProductionOrder:
+		o := pb.GetProductionOrder()
+		f, ok := entities.Get(id.EntityID(o.GetEntityId())).(producer.Component)
+		if !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "entity %v cannot produce entities", o.GetEntityId())
+		}
+		var t orderaction.OrderType
+		found := false
+		for k, v := range orderTypes {
+			if v == o.GetOrderType() {
+				t, found = k, true
+			}
+		}
+		if !found {
+			return nil, status.Errorf(codes.InvalidArgument, "cannot import production order of type %v", o.GetOrderType())
+		}
+		return []action.Action{
+			orderaction.NewWithID(
+				id.ActionID(o.GetActionId()),
+				u.Status(),
+				f,
+				t,
+				o.GetEntityType(),
+				id.ActionID(o.GetProductionId()),
+				int(o.GetIndex()),
+				o.GetRallyPoint()),
+		}, nil
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "cannot import unknown action %v", pb)
+	}
+}
+
+// Recorder implements the executor.Recorder interface and builds a Replay
+// from the actions applied by the Executor.
+type Recorder struct {
+	// mux guards the pb property.
+	mux sync.Mutex
+	pb  *rdpb.Replay
+}
+
+// NewRecorder constructs a new Recorder instance. The input arguments must
+// match the arguments used to construct the recorded executorutils.Utils
+// instance.
+func NewRecorder(pb *mdpb.TileMap, rpb *edpb.Registry, d *gdpb.Coordinate, tickDuration time.Duration, minPathLength int) *Recorder {
+	return &Recorder{
+		pb: &rdpb.Replay{
+			TileMap:          pb,
+			Registry:         rpb,
+			ClusterDimension: d,
+			TickDuration:     durationpb.New(tickDuration),
+			MinPathLength:    int32(minPathLength),
+		},
+	}
+}
+
+// Record appends the list of actions applied at the input tick to the Replay.
+//
+// Actions which cannot be exported (e.g. actions restored from a snapshot
+// which were generated by a Visitor) are logged and skipped, and the Replay
+// may diverge from the recorded game after this tick. The chase action of an
+// attack action is reconstructed from the attack, and is skipped silently.
+func (r *Recorder) Record(tick id.Tick, actions []action.Action) error {
+	chases := map[*chaseaction.Action]bool{}
+	for _, a := range actions {
+		if i, ok := a.(*attackaction.Action); ok {
+			chases[i.Chase()] = true
+		}
+	}
+
+	var pbs []*rdpb.Action
+	for _, a := range actions {
+		if i, ok := a.(*chaseaction.Action); ok && chases[i] {
+			continue
+		}
+		pb, err := Export(tick, a)
+		if err != nil {
+			log.Printf("[%v] skipping action %v of type %v in replay: %v", tick, a.ID(), a.Type(), err)
+			continue
+		}
+		pbs = append(pbs, pb)
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.pb.Actions = append(r.pb.GetActions(), pbs...)
+	return nil
+}
+
+// Defer appends the list of actions which were deferred at the input tick to
+// the Replay.
+func (r *Recorder) Defer(tick id.Tick, fsmType fcpb.FSMType, aids []id.ActionID) error {
+	pb := &rdpb.Deferral{
+		Tick:    tick.Value(),
+		FsmType: fsmType,
+	}
+	for _, aid := range aids {
+		pb.ActionIds = append(pb.GetActionIds(), aid.Value())
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.pb.Deferrals = append(r.pb.GetDeferrals(), pb)
+	return nil
+}
+
+// Replay returns a copy of the game recorded so far.
+func (r *Recorder) Replay() *rdpb.Replay {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return proto.Clone(r.pb).(*rdpb.Replay)
+}
+
+// Player feeds a recorded game into a fresh Executor.
+type Player struct {
+	utils *executorutils.Utils
+
+	// actions is the list of recorded actions, indexed by the tick at
+	// which the action was applied.
+	actions map[id.Tick][]*rdpb.Action
+
+	// deferrals is the list of actions which were deferred by each Visitor
+	// pass, indexed by the tick at which the actions were deferred.
+	deferrals map[id.Tick]map[fcpb.FSMType][]id.ActionID
+
+	// end is the last tick at which a recorded action was applied.
+	end id.Tick
+}
+
+// NewPlayer constructs a new Player instance from a recorded game.
+func NewPlayer(pb *rdpb.Replay) (*Player, error) {
+	u, err := executorutils.New(
+		pb.GetTileMap(),
+		pb.GetRegistry(),
+		pb.GetClusterDimension(),
+		pb.GetTickDuration().AsDuration(),
+		int(pb.GetMinPathLength()))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Player{
+		utils:     u,
+		actions:   map[id.Tick][]*rdpb.Action{},
+		deferrals: map[id.Tick]map[fcpb.FSMType][]id.ActionID{},
+	}
+	for _, a := range pb.GetActions() {
+		t := id.Tick(a.GetTick())
+		p.actions[t] = append(p.actions[t], a)
+		if t > p.end {
+			p.end = t
+		}
+	}
+	for _, d := range pb.GetDeferrals() {
+		t := id.Tick(d.GetTick())
+		if p.deferrals[t] == nil {
+			p.deferrals[t] = map[fcpb.FSMType][]id.ActionID{}
+		}
+		for _, aid := range d.GetActionIds() {
+			p.deferrals[t][d.GetFsmType()] = append(p.deferrals[t][d.GetFsmType()], id.ActionID(aid))
+		}
+	}
+	return p, nil
+}
+
+// Utils returns the underlying game instance. Callers must not schedule
+// additional actions on this instance.
+func (p *Player) Utils() *executorutils.Utils { return p.utils }
+
+// Tick returns the last game tick which was played back.
+func (p *Player) Tick() id.Tick { return p.utils.Status().Tick() }
+
+// Done returns true if all recorded actions have been applied.
+func (p *Player) Done() bool { return p.Tick() >= p.end }
+
+// Step schedules all actions recorded for the next tick and advances the game
+// by a single tick. Actions which were deferred in the recorded tick are
+// deferred again.
+func (p *Player) Step() error {
+	for _, pb := range p.actions[p.Tick()+1] {
+		actions, err := Import(p.utils, pb)
+		if err != nil {
+			return err
+		}
+		if err := p.utils.Executor().Schedule(actions); err != nil {
+			return err
+		}
+	}
+	return p.utils.Executor().StepDeferred(p.deferrals[p.Tick()+1])
+}
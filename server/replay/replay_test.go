@@ -0,0 +1,249 @@
+package replay
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/server/executor/executor"
+	"github.com/downflux/game/server/entity/component/attackable"
+	"github.com/downflux/game/server/entity/component/moveable"
+	"github.com/downflux/game/server/entity/component/targetable"
+	"github.com/downflux/game/server/entity/registrytest"
+	"github.com/downflux/game/server/grpc/executorutils"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	apipb "github.com/downflux/game/api/api_go_proto"
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+	mcpb "github.com/downflux/game/map/api/constants_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	attackaction "github.com/downflux/game/server/fsm/attack/attack"
+	chaseaction "github.com/downflux/game/server/fsm/move/chase"
+	moveaction "github.com/downflux/game/server/fsm/move/move"
+	rdpb "github.com/downflux/game/server/replay/api/data_go_proto"
+)
+
+const (
+	minPathLength = 8
+	nTicks        = 50
+)
+
+var (
+	_ executor.Recorder = &Recorder{}
+
+	tickDuration     = 100 * time.Millisecond
+	clusterDimension = &gdpb.Coordinate{X: 2, Y: 2}
+
+	/**
+	 *       - - - -
+	 *       - - - -
+	 *       - - - -
+	 * Y = 0 - - - -
+	 *   X = 0
+	 */
+	simpleMap = func() *mdpb.TileMap {
+		pb := &mdpb.TileMap{
+			Dimension: &gdpb.Coordinate{X: 4, Y: 4},
+			TerrainCosts: []*mdpb.TerrainCost{
+				{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_BLOCKED, Cost: math.Inf(0)},
+				{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS, Cost: 1},
+			},
+		}
+		for x := int32(0); x < 4; x++ {
+			for y := int32(0); y < 4; y++ {
+				pb.Tiles = append(pb.GetTiles(), &mdpb.Tile{
+					Coordinate:  &gdpb.Coordinate{X: x, Y: y},
+					TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS,
+				})
+			}
+		}
+		return pb
+	}()
+
+	registryPB = registrytest.Registry()
+)
+
+func export(u *executorutils.Utils) *gdpb.GameState {
+	return u.GameState().Export(0, u.GameState().NoFilter())
+}
+
+func TestPlayback(t *testing.T) {
+	u, err := executorutils.New(simpleMap, registryPB, clusterDimension, tickDuration, minPathLength)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	r := NewRecorder(simpleMap, registryPB, clusterDimension, tickDuration, minPathLength)
+	u.Executor().SetRecorder(r)
+
+	cid := id.ClientID("client-id")
+	enemy := id.ClientID("enemy-id")
+	if err := u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid); err != nil {
+		t.Fatalf("ProduceFree() = %v, want = nil", err)
+	}
+	if err := u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 3, Y: 3}, enemy); err != nil {
+		t.Fatalf("ProduceFree() = %v, want = nil", err)
+	}
+
+	var want []*gdpb.GameState
+	if err := u.Executor().Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+	want = append(want, export(u))
+
+	tanks := map[id.ClientID]string{}
+	for _, e := range u.GameState().Entities().Iter() {
+		if e.Type() == gcpb.EntityType_ENTITY_TYPE_TANK {
+			tanks[e.ClientID(u.Status().Tick())] = e.ID().Value()
+		}
+	}
+	if len(tanks) != 2 {
+		t.Fatalf("len() = %v, want = %v", len(tanks), 2)
+	}
+
+	if _, err := u.Move(&apipb.MoveRequest{
+		ClientId:    cid.Value(),
+		EntityIds:   []string{tanks[cid]},
+		Destination: &gdpb.Position{X: 3, Y: 0},
+	}); err != nil {
+		t.Fatalf("Move() = %v, want = nil", err)
+	}
+	if _, err := u.Attack(&apipb.AttackRequest{
+		ClientId:       enemy.Value(),
+		EntityIds:      []string{tanks[enemy]},
+		TargetEntityId: tanks[cid],
+	}); err != nil {
+		t.Fatalf("Attack() = %v, want = nil", err)
+	}
+
+	for i := 1; i < nTicks; i++ {
+		if err := u.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+		want = append(want, export(u))
+	}
+
+	p, err := NewPlayer(r.Replay())
+	if err != nil {
+		t.Fatalf("NewPlayer() = _, %v, want = nil", err)
+	}
+
+	for i := 0; i < nTicks; i++ {
+		if err := p.Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+		if diff := cmp.Diff(
+			want[i],
+			export(p.Utils()),
+			protocmp.Transform(),
+			protocmp.SortRepeated(func(a, b *gdpb.Entity) bool { return a.GetEntityId() < b.GetEntityId() }),
+			protocmp.SortRepeated(func(a, b *gdpb.Curve) bool {
+				return a.GetEntityId() < b.GetEntityId() || a.GetEntityId() == b.GetEntityId() && a.GetProperty() < b.GetProperty()
+			}),
+		); diff != "" {
+			t.Fatalf("[%v] Export() mismatch (-want +got):\n%v", p.Tick(), diff)
+		}
+	}
+
+	if !p.Done() {
+		t.Errorf("Done() = %v, want = %v", p.Done(), true)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	u, err := executorutils.New(simpleMap, registryPB, clusterDimension, tickDuration, minPathLength)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+
+	cid := id.ClientID("client-id")
+	for _, p := range []*gdpb.Position{{X: 0, Y: 0}, {X: 3, Y: 3}} {
+		if err := u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_TANK, p, cid); err != nil {
+			t.Fatalf("ProduceFree() = %v, want = nil", err)
+		}
+	}
+	if err := u.Executor().Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	var tanks []entity.Entity
+	for _, e := range u.GameState().Entities().Iter() {
+		if e.Type() == gcpb.EntityType_ENTITY_TYPE_TANK {
+			tanks = append(tanks, e)
+		}
+	}
+	if len(tanks) != 2 {
+		t.Fatalf("len() = %v, want = %v", len(tanks), 2)
+	}
+
+	dest := &gdpb.Position{X: 3, Y: 0}
+	chaseAction := chaseaction.New(u.Status(), tanks[0].(moveable.Component), tanks[1].(targetable.Component))
+
+	// The cache mixes client commands with actions which may only be
+	// generated by a Visitor, e.g. when restored from a snapshot.
+	actions := []action.Action{
+		moveaction.New(tanks[0].(moveable.Component), u.Status(), dest, moveaction.Default),
+		moveaction.New(tanks[1].(moveable.Component), u.Status(), dest, moveaction.Direct),
+		chaseAction,
+		attackaction.New(u.Status(), tanks[0].(attackable.Component), tanks[1].(targetable.Component), chaseAction),
+		chaseaction.New(u.Status(), tanks[1].(moveable.Component), tanks[0].(targetable.Component)),
+	}
+
+	r := NewRecorder(simpleMap, registryPB, clusterDimension, tickDuration, minPathLength)
+	if err := r.Record(u.Status().Tick(), actions); err != nil {
+		t.Fatalf("Record() = %v, want = nil", err)
+	}
+
+	want := []*rdpb.Action{
+		{
+			Tick: u.Status().Tick().Value(),
+			Action: &rdpb.Action_Move{
+				Move: &rdpb.Move{EntityId: tanks[0].ID().Value(), Destination: dest},
+			},
+		},
+		{
+			Tick: u.Status().Tick().Value(),
+			Action: &rdpb.Action_Attack{
+				Attack: &rdpb.Attack{EntityId: tanks[0].ID().Value(), TargetEntityId: tanks[1].ID().Value()},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, r.Replay().GetActions(), protocmp.Transform()); diff != "" {
+		t.Errorf("GetActions() mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestDefer(t *testing.T) {
+	const tick = id.Tick(2)
+	aids := []id.ActionID{"action-a", "action-b"}
+
+	r := NewRecorder(simpleMap, registryPB, clusterDimension, tickDuration, minPathLength)
+	if err := r.Defer(tick, fcpb.FSMType_FSM_TYPE_MOVE, aids); err != nil {
+		t.Fatalf("Defer() = %v, want = nil", err)
+	}
+
+	p, err := NewPlayer(r.Replay())
+	if err != nil {
+		t.Fatalf("NewPlayer() = _, %v, want = nil", err)
+	}
+
+	want := map[id.Tick]map[fcpb.FSMType][]id.ActionID{
+		tick: {fcpb.FSMType_FSM_TYPE_MOVE: aids},
+	}
+	if diff := cmp.Diff(want, p.deferrals); diff != "" {
+		t.Errorf("deferrals mismatch (-want +got):\n%v", diff)
+	}
+
+	// Playback defers the recorded actions without failing, even if the
+	// actions are not scheduled.
+	for i := 0; i < int(tick); i++ {
+		if err := p.Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+}
@@ -0,0 +1,797 @@
+// Package snapshot serializes the full state of a game in progress, i.e. the
+// current tick, all entities along with their curves, and all pending actions.
+//
+// Unlike a replay, a snapshot does not require the game to be re-simulated
+// from the beginning, and is used to checkpoint a game to disk and resume it
+// after a server restart.
+package snapshot
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/downflux/game/engine/curve/common/linearmove"
+	"github.com/downflux/game/engine/curve/curve"
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/component/attackable"
+	"github.com/downflux/game/server/entity/component/carrier"
+	"github.com/downflux/game/server/entity/component/moveable"
+	"github.com/downflux/game/server/entity/component/producer"
+	"github.com/downflux/game/server/entity/component/targetable"
+	"github.com/downflux/game/server/entity/factory"
+	"github.com/downflux/game/server/entity/harvester"
+	"github.com/downflux/game/server/entity/player"
+	"github.com/downflux/game/server/entity/projectile"
+	"github.com/downflux/game/server/entity/refinery"
+	"github.com/downflux/game/server/entity/registry"
+	"github.com/downflux/game/server/entity/resource"
+	"github.com/downflux/game/server/entity/tank"
+	"github.com/downflux/game/server/fsm/commonstate"
+	"github.com/downflux/game/server/grpc/executorutils"
+	"github.com/downflux/game/server/replay/replay"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	entitylist "github.com/downflux/game/engine/entity/list"
+	attackaction "github.com/downflux/game/server/fsm/attack/attack"
+	projectileaction "github.com/downflux/game/server/fsm/attack/projectile"
+	deathaction "github.com/downflux/game/server/fsm/death"
+	harvestaction "github.com/downflux/game/server/fsm/harvest"
+	chaseaction "github.com/downflux/game/server/fsm/move/chase"
+	moveaction "github.com/downflux/game/server/fsm/move/move"
+	produceaction "github.com/downflux/game/server/fsm/produce"
+	orderaction "github.com/downflux/game/server/fsm/production/order"
+	queueaction "github.com/downflux/game/server/fsm/production/queue"
+	rdpb "github.com/downflux/game/server/replay/api/data_go_proto"
+	sdpb "github.com/downflux/game/server/snapshot/api/data_go_proto"
+)
+
+// Save exports the current state of the input game. The game loop is paused
+// while the snapshot is taken.
+func Save(u *executorutils.Utils) (*sdpb.Snapshot, error) {
+	var pb *sdpb.Snapshot
+	err := u.Executor().Pause(func() error {
+		var err error
+		pb, err = save(u)
+		return err
+	})
+	return pb, err
+}
+
+// Load restores the game state from a snapshot into the input game. The input
+// game must be freshly constructed and not yet running. Entities spawned when
+// constructing the game, e.g. resource fields, are replaced by the entities in
+// the snapshot. The clients of the game, their teams and their control grants
+// are also restored; client sessions are managed by the server and restored
+// separately.
+//
+// Load does not restore the tick at which pending actions were originally
+// issued; these actions are treated as if issued at the snapshot tick when
+// deciding if a newer command should override them.
+func Load(u *executorutils.Utils, pb *sdpb.Snapshot) error {
+	return u.Executor().Pause(func() error { return load(u, pb) })
+}
+
+// Checkpointer implements the executor.Checkpointer interface, which allows
+// the Executor to roll back the game. Actions are checkpointed in the same
+// serialized form as in a Snapshot.
+type Checkpointer struct {
+	utils *executorutils.Utils
+}
+
+// NewCheckpointer constructs a new Checkpointer instance for the input game.
+func NewCheckpointer(u *executorutils.Utils) *Checkpointer {
+	return &Checkpointer{utils: u}
+}
+
+// Checkpoint exports the input list of actions.
+//
+// Paths planned before the checkpoint tick are exported alongside their move
+// actions, as the Executor truncates all curves after the tick of the restored
+// checkpoint.
+func (c *Checkpointer) Checkpoint(actions []action.Action) (interface{}, error) {
+	pbs, err := exportActions(actions)
+	if err != nil {
+		return nil, err
+	}
+
+	t := c.utils.Status().Tick()
+	for _, pb := range pbs {
+		for _, m := range moves(pb) {
+			if m.GetDirect() || id.Tick(m.GetExecutionTick()) <= t {
+				continue
+			}
+			e, ok := c.utils.GameState().Entities().Get(id.EntityID(m.GetEntityId())).(moveable.Component)
+			if !ok {
+				return nil, status.Errorf(codes.FailedPrecondition, "entity %v is not moveable", m.GetEntityId())
+			}
+			m.Path = e.PositionCurve().Export(t)
+		}
+	}
+	return pbs, nil
+}
+
+// Restore reconstructs the list of actions from a checkpoint. Actions which
+// reference entities which no longer exist are skipped.
+//
+// Moves are restored with their saved execution tick, and any path planned
+// before the checkpoint is merged back into the position curve of the entity,
+// so that re-simulating the game does not re-plan paths at different ticks.
+//
+// The Executor restores checkpoints after rewinding the entity list, so the
+// map tiles blocked by structures are also resynchronized here, e.g. to reopen
+// the tiles of structures which were built after the rollback tick.
+func (c *Checkpointer) Restore(checkpoint interface{}) ([]action.Action, error) {
+	if err := c.utils.Footprints().Sync(c.utils.GameState().Entities().Iter(), c.utils.Status().Tick()); err != nil {
+		return nil, err
+	}
+
+	var actions []action.Action
+	for _, pb := range checkpoint.([]*sdpb.Action) {
+		for _, m := range moves(pb) {
+			if m.GetPath() == nil {
+				continue
+			}
+			e, ok := c.utils.GameState().Entities().Get(id.EntityID(m.GetEntityId())).(moveable.Component)
+			if !ok {
+				continue
+			}
+			if err := restorePath(e.PositionCurve(), c.utils.Status().Tick(), m.GetPath()); err != nil {
+				return nil, err
+			}
+		}
+
+		restored, err := importAction(c.utils, pb)
+		if status.Code(err) == codes.FailedPrecondition {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, restored...)
+	}
+	return actions, nil
+}
+
+func save(u *executorutils.Utils) (*sdpb.Snapshot, error) {
+	pb := &sdpb.Snapshot{
+		Tick: u.Status().Tick().Value(),
+	}
+
+	entities := u.GameState().Entities().Iter()
+	sort.Slice(entities, func(i, j int) bool { return entities[i].ID() < entities[j].ID() })
+	for _, e := range entities {
+		pb.Entities = append(pb.GetEntities(), exportEntity(e))
+	}
+
+	actions, err := exportActions(u.Executor().Pending())
+	if err != nil {
+		return nil, err
+	}
+	pb.Actions = actions
+
+	pb.Clients = u.Executor().Teams()
+	for _, r := range u.ACL().Export() {
+		pb.Grants = append(pb.GetGrants(), &sdpb.Grant{
+			OwnerClientId:   r.Owner.Value(),
+			GranteeClientId: r.Grantee.Value(),
+			Tick:            r.Tick.Value(),
+			Granted:         r.Granted,
+		})
+	}
+
+	return pb, nil
+}
+
+func load(u *executorutils.Utils, pb *sdpb.Snapshot) error {
+	entities := u.GameState().Entities()
+	if u.Status().IsStarted() || u.Status().Tick() != 0 {
+		return status.Error(codes.FailedPrecondition, "cannot load a snapshot into a game which is already in progress")
+	}
+	for _, e := range entities.Iter() {
+		if err := entities.Remove(e.ID()); err != nil {
+			return err
+		}
+	}
+
+	// Entities which are referenced by other entities (e.g. projectiles)
+	// need to be constructed first.
+	var deferred []*sdpb.Entity
+	for _, epb := range pb.GetEntities() {
+		if epb.GetProjectileEntityId() != "" {
+			deferred = append(deferred, epb)
+			continue
+		}
+		if err := importEntity(u.Registry(), entities, epb); err != nil {
+			return err
+		}
+	}
+	for _, epb := range deferred {
+		if err := importEntity(u.Registry(), entities, epb); err != nil {
+			return err
+		}
+	}
+
+	// Clients are restored before the game starts, as teams are fixed
+	// afterwards. The accounts of the clients are restored along with the
+	// other entities.
+	for _, c := range pb.GetClients() {
+		cid := id.ClientID(c.GetClientId())
+		if err := u.Executor().AddClientWithID(cid); err != nil {
+			return err
+		}
+		if err := u.Executor().SetClientTeam(cid, c.GetTeam()); err != nil {
+			return err
+		}
+	}
+	for _, g := range pb.GetGrants() {
+		owner := id.ClientID(g.GetOwnerClientId())
+		grantee := id.ClientID(g.GetGranteeClientId())
+		if g.GetGranted() {
+			u.ACL().Grant(owner, grantee, id.Tick(g.GetTick()))
+		} else {
+			u.ACL().Revoke(owner, grantee, id.Tick(g.GetTick()))
+		}
+	}
+
+	u.GameState().Status().SetTick(id.Tick(pb.GetTick()))
+
+	// Structures in the snapshot block the map tiles under their
+	// footprint.
+	if err := u.Footprints().Sync(entities.Iter(), u.Status().Tick()); err != nil {
+		return err
+	}
+
+	for _, apb := range pb.GetActions() {
+		actions, err := importAction(u, apb)
+		if err != nil {
+			return err
+		}
+		if err := u.Executor().Schedule(actions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportEntity(e entity.Entity) *sdpb.Entity {
+	pb := &sdpb.Entity{
+		EntityId:  e.ID().Value(),
+		Type:      e.Type(),
+		StartTick: e.Start().Value(),
+		EndTick:   e.End().Value(),
+	}
+	if a, ok := e.(attackable.Component); ok && a.AttackProjectile() != nil {
+		pb.ProjectileEntityId = a.AttackProjectile().ID().Value()
+	}
+	for _, property := range e.Curves().Properties() {
+		pb.Curves = append(pb.GetCurves(), e.Curves().Curve(property).Export(0))
+	}
+	return pb
+}
+
+func importEntity(r *registry.Registry, entities *entitylist.List, pb *sdpb.Entity) error {
+	eid := id.EntityID(pb.GetEntityId())
+	tick := id.Tick(pb.GetStartTick())
+
+	// The initial position and owner of the entity are overwritten when
+	// the curves are imported.
+	var e entity.Entity
+	var err error
+	t := pb.GetType()
+	if def := r.Projectile(t); def != nil {
+		e, err = projectile.New(def, eid, tick, &gdpb.Position{}, id.ClientID(""))
+	} else if def := r.Unit(t); def != nil {
+		p, ok := entities.Get(id.EntityID(pb.GetProjectileEntityId())).(*projectile.Entity)
+		if !ok {
+			return status.Errorf(codes.FailedPrecondition, "cannot find projectile %v for entity %v", pb.GetProjectileEntityId(), eid)
+		}
+		e, err = tank.New(def, eid, tick, &gdpb.Position{}, id.ClientID(""), p)
+	} else if def := r.Harvester(t); def != nil {
+		e, err = harvester.New(def, eid, tick, &gdpb.Position{}, id.ClientID(""))
+	} else if def := r.Refinery(t); def != nil {
+		e, err = refinery.New(def, eid, tick, &gdpb.Position{}, id.ClientID(""))
+	} else if def := r.Factory(t); def != nil {
+		e, err = factory.New(def, eid, tick, &gdpb.Position{}, id.ClientID(""))
+	} else if t == gcpb.EntityType_ENTITY_TYPE_RESOURCE_FIELD {
+		e, err = resource.New(eid, tick, &gdpb.Position{}, 0)
+	} else if t == gcpb.EntityType_ENTITY_TYPE_PLAYER {
+		e, err = player.New(eid, tick, id.ClientID(""), 0)
+	} else {
+		return status.Errorf(codes.Unimplemented, "cannot import a %v entity", t)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, cpb := range pb.GetCurves() {
+		c := e.Curves().Curve(cpb.GetProperty())
+		if c == nil {
+			return status.Errorf(codes.InvalidArgument, "entity %v does not have a %v curve", eid, cpb.GetProperty())
+		}
+		if err := importCurve(c, cpb); err != nil {
+			return err
+		}
+	}
+
+	if end := id.Tick(pb.GetEndTick()); end != 0 {
+		e.Delete(end)
+	}
+
+	return entities.Append(e)
+}
+
+// importCurve replaces all data in the input curve with the serialized data.
+func importCurve(c curve.Curve, pb *gdpb.Curve) error {
+	tick := id.Tick(pb.GetTick())
+
+	switch c := c.(type) {
+	case *linearmove.Curve:
+		// Position curves are updated via Merge, which also sets the
+		// curve tick.
+		o := linearmove.New(c.EntityID(), tick)
+		for _, dpb := range pb.GetData() {
+			v, err := importDatum(c.DatumType(), dpb)
+			if err != nil {
+				return err
+			}
+			o.Add(id.Tick(dpb.GetTick()), v)
+		}
+		if o.Data().Len() == 0 {
+			return status.Errorf(codes.InvalidArgument, "cannot import an empty %v curve", c.Property())
+		}
+		truncate(c)
+		return c.Merge(o)
+	default:
+		if c.Tick() != tick {
+			return status.Errorf(codes.FailedPrecondition, "cannot restore the tick of a %v curve", c.Property())
+		}
+		truncate(c)
+
+		// Data is set directly to bypass the Add semantics of e.g.
+		// delta and timer curves.
+		for _, dpb := range pb.GetData() {
+			v, err := importDatum(c.DatumType(), dpb)
+			if err != nil {
+				return err
+			}
+			c.Data().Set(id.Tick(dpb.GetTick()), v)
+		}
+		return nil
+	}
+}
+
+// moves returns the move actions embedded in the input serialized action.
+func moves(pb *sdpb.Action) []*sdpb.Move {
+	var ms []*sdpb.Move
+	for _, m := range []*sdpb.Move{
+		pb.GetMove(),
+		pb.GetChase().GetMove(),
+		pb.GetProjectileShoot().GetMove(),
+		pb.GetAttack().GetChase().GetMove(),
+		pb.GetAttack().GetProjectileShoot().GetMove(),
+		pb.GetHarvest().GetMove(),
+	} {
+		if m != nil {
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+// restorePath merges a path exported at the input tick back into the
+// truncated position curve of the entity. Unlike importCurve, the history of
+// the curve before the tick is preserved.
+func restorePath(c *linearmove.Curve, t id.Tick, pb *gdpb.Curve) error {
+	o := linearmove.New(c.EntityID(), t)
+	for _, dpb := range pb.GetData() {
+		v, err := importDatum(c.DatumType(), dpb)
+		if err != nil {
+			return err
+		}
+		o.Add(id.Tick(dpb.GetTick()), v)
+	}
+	return c.Merge(o)
+}
+
+// truncate removes all data from the input curve.
+func truncate(c curve.Curve) {
+	if c.Data().Len() > 0 {
+		c.Data().Truncate(c.Data().Tick(0))
+	}
+}
+
+func importDatum(t reflect.Type, pb *gdpb.CurveDatum) (interface{}, error) {
+	var v interface{}
+	switch d := pb.GetDatum().(type) {
+	case *gdpb.CurveDatum_BoolDatum:
+		v = d.BoolDatum
+	case *gdpb.CurveDatum_DoubleDatum:
+		v = d.DoubleDatum
+	case *gdpb.CurveDatum_PositionDatum:
+		v = proto.Clone(d.PositionDatum).(*gdpb.Position)
+	case *gdpb.CurveDatum_StringDatum:
+		// String-like types, e.g. id.ClientID, are exported as raw
+		// strings.
+		if t.Kind() == reflect.String {
+			v = reflect.ValueOf(d.StringDatum).Convert(t).Interface()
+		}
+	}
+
+	if reflect.TypeOf(v) != t {
+		return nil, status.Errorf(codes.InvalidArgument, "cannot import datum %v into a curve of type %v", pb, t)
+	}
+	return v, nil
+}
+
+// isLive checks if the action will still be processed in future ticks.
+func isLive(a action.Action) (bool, error) {
+	s, err := a.State()
+	if err != nil {
+		return false, err
+	}
+	return s != commonstate.Canceled && s != commonstate.Finished, nil
+}
+
+// exportActions converts the list of actions tracked by the Executor into their
+// serialized form. Actions which are linked to a parent action (e.g. the move
+// generated by a chase) are serialized as part of the parent.
+func exportActions(actions []action.Action) ([]*sdpb.Action, error) {
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Type() < actions[j].Type() || actions[i].Type() == actions[j].Type() && actions[i].ID() < actions[j].ID()
+	})
+
+	var live []action.Action
+	linked := map[action.Action]bool{}
+	for _, a := range actions {
+		ok, err := isLive(a)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		live = append(live, a)
+
+		switch i := a.(type) {
+		case *attackaction.Action:
+			linked[i.Chase()] = true
+			if m := i.Chase().Move(); m != nil {
+				linked[m] = true
+			}
+			if p := i.ProjectileMove(); p != nil {
+				linked[p] = true
+				linked[p.Move()] = true
+			}
+		case *chaseaction.Action:
+			if m := i.Move(); m != nil {
+				linked[m] = true
+			}
+		case *projectileaction.Action:
+			linked[i.Move()] = true
+		case *harvestaction.Action:
+			if m := i.Move(); m != nil {
+				linked[m] = true
+			}
+		}
+	}
+
+	var pbs []*sdpb.Action
+	for _, a := range live {
+		if linked[a] {
+			continue
+		}
+
+		pb := &sdpb.Action{}
+		switch i := a.(type) {
+		case *moveaction.Action:
+			pb.Action = &sdpb.Action_Move{Move: exportMove(i)}
+		case *chaseaction.Action:
+			m, err := exportChase(i)
+			if err != nil {
+				return nil, err
+			}
+			pb.Action = &sdpb.Action_Chase{Chase: m}
+		case *projectileaction.Action:
+			pb.Action = &sdpb.Action_ProjectileShoot{ProjectileShoot: exportProjectile(i)}
+		case *attackaction.Action:
+			m, err := exportAttack(i)
+			if err != nil {
+				return nil, err
+			}
+			pb.Action = &sdpb.Action_Attack{Attack: m}
+		case *produceaction.Action:
+			m, err := replay.Export(0, i)
+			if err != nil {
+				return nil, err
+			}
+			pb.Action = &sdpb.Action_Produce{Produce: m.GetProduce()}
+		case *harvestaction.Action:
+			m, err := exportHarvest(i)
+			if err != nil {
+				return nil, err
+			}
+			pb.Action = &sdpb.Action_Harvest{Harvest: m}
+		case *queueaction.Action:
+			pb.Action = &sdpb.Action_ProductionQueue{ProductionQueue: exportQueue(i)}
+		case *orderaction.Action:
+			m, err := replay.Export(0, i)
+			if err != nil {
+				return nil, err
+			}
+			pb.Action = &sdpb.Action_ProductionOrder{ProductionOrder: m.GetProductionOrder()}
+		case *deathaction.Action:
+			pb.Action = &sdpb.Action_Death{Death: &sdpb.Death{EntityId: i.Component().ID().Value()}}
+		default:
+			return nil, status.Errorf(codes.Unimplemented, "cannot export action of type %v", a.Type())
+		}
+		pbs = append(pbs, pb)
+	}
+	return pbs, nil
+}
+
+func exportMove(a *moveaction.Action) *sdpb.Move {
+	return &sdpb.Move{
+		EntityId:      a.Component().ID().Value(),
+		Destination:   a.Destination(),
+		Direct:        a.MoveType() == moveaction.Direct,
+		ExecutionTick: a.ExecutionTick().Value(),
+	}
+}
+
+func exportChase(a *chaseaction.Action) (*sdpb.Chase, error) {
+	pb := &sdpb.Chase{
+		EntityId:       a.Source().ID().Value(),
+		TargetEntityId: a.Destination().ID().Value(),
+	}
+	if m := a.Move(); m != nil {
+		ok, err := isLive(m)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			pb.Move = exportMove(m)
+		}
+	}
+	return pb, nil
+}
+
+func exportHarvest(a *harvestaction.Action) (*sdpb.Harvest, error) {
+	pb := &sdpb.Harvest{
+		EntityId: a.Source().ID().Value(),
+	}
+	if m := a.Move(); m != nil {
+		ok, err := isLive(m)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			pb.Move = exportMove(m)
+		}
+	}
+	return pb, nil
+}
+
+func exportQueue(a *queueaction.Action) *sdpb.ProductionQueue {
+	pb := &sdpb.ProductionQueue{
+		EntityId: a.Source().ID().Value(),
+	}
+	for _, i := range a.Items() {
+		pb.Items = append(pb.GetItems(), &sdpb.ProductionItem{
+			ProductionId: i.ID.Value(),
+			EntityType:   i.EntityType,
+			Cost:         i.Cost,
+			BuildTicks:   i.BuildTicks,
+			Progress:     i.Progress,
+		})
+	}
+	return pb
+}
+
+func exportProjectile(a *projectileaction.Action) *sdpb.ProjectileShoot {
+	return &sdpb.ProjectileShoot{
+		EntityId:       a.Source().ID().Value(),
+		TargetEntityId: a.Target().ID().Value(),
+		Move:           exportMove(a.Move()),
+	}
+}
+
+func exportAttack(a *attackaction.Action) (*sdpb.Attack, error) {
+	c, err := exportChase(a.Chase())
+	if err != nil {
+		return nil, err
+	}
+	pb := &sdpb.Attack{
+		EntityId:       a.Source().ID().Value(),
+		TargetEntityId: a.Target().ID().Value(),
+		Chase:          c,
+	}
+	if p := a.ProjectileMove(); p != nil {
+		ok, err := isLive(p)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			pb.ProjectileShoot = exportProjectile(p)
+		}
+	}
+	return pb, nil
+}
+
+// importAction reconstructs the list of actions represented by the serialized
+// action. Linked actions are reconnected to their parent action.
+func importAction(u *executorutils.Utils, pb *sdpb.Action) ([]action.Action, error) {
+	switch pb.GetAction().(type) {
+	case *sdpb.Action_Move:
+		m, err := importMove(u, pb.GetMove())
+		if err != nil {
+			return nil, err
+		}
+		return []action.Action{m}, nil
+	case *sdpb.Action_Chase:
+		_, actions, err := importChase(u, pb.GetChase())
+		return actions, err
+	case *sdpb.Action_ProjectileShoot:
+		_, actions, err := importProjectile(u, pb.GetProjectileShoot())
+		return actions, err
+	case *sdpb.Action_Attack:
+		return importAttack(u, pb.GetAttack())
+	case *sdpb.Action_Produce:
+		return replay.Import(u, &rdpb.Action{
+			Action: &rdpb.Action_Produce{Produce: pb.GetProduce()},
+		})
+	case *sdpb.Action_Harvest:
+		return importHarvest(u, pb.GetHarvest())
+	case *sdpb.Action_ProductionQueue:
+		return importQueue(u, pb.GetProductionQueue())
+	case *sdpb.Action_ProductionOrder:
+		return replay.Import(u, &rdpb.Action{
+			Action: &rdpb.Action_ProductionOrder{ProductionOrder: pb.GetProductionOrder()},
+		})
+	case *sdpb.Action_Death:
+		e, ok := u.GameState().Entities().Get(id.EntityID(pb.GetDeath().GetEntityId())).(deathaction.Component)
+		if !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "entity %v is not targetable", pb.GetDeath().GetEntityId())
+		}
+		return []action.Action{deathaction.New(u.Status(), e)}, nil
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "cannot import unknown action %v", pb)
+	}
+}
+
+func importMove(u *executorutils.Utils, pb *sdpb.Move) (*moveaction.Action, error) {
+	e, ok := u.GameState().Entities().Get(id.EntityID(pb.GetEntityId())).(moveable.Component)
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "entity %v is not moveable", pb.GetEntityId())
+	}
+
+	t := moveaction.Default
+	if pb.GetDirect() {
+		t = moveaction.Direct
+	}
+
+	m := moveaction.New(e, u.Status(), pb.GetDestination(), t)
+	if t == moveaction.Default {
+		if err := m.SchedulePartialMove(id.Tick(pb.GetExecutionTick())); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func importChase(u *executorutils.Utils, pb *sdpb.Chase) (*chaseaction.Action, []action.Action, error) {
+	s, ok := u.GameState().Entities().Get(id.EntityID(pb.GetEntityId())).(moveable.Component)
+	if !ok {
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "entity %v is not moveable", pb.GetEntityId())
+	}
+	t, ok := u.GameState().Entities().Get(id.EntityID(pb.GetTargetEntityId())).(targetable.Component)
+	if !ok {
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "entity %v is not targetable", pb.GetTargetEntityId())
+	}
+
+	c := chaseaction.New(u.Status(), s, t)
+	actions := []action.Action{c}
+	if pb.GetMove() != nil {
+		m, err := importMove(u, pb.GetMove())
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := c.SetMove(m); err != nil {
+			return nil, nil, err
+		}
+		actions = append(actions, m)
+	}
+	return c, actions, nil
+}
+
+func importHarvest(u *executorutils.Utils, pb *sdpb.Harvest) ([]action.Action, error) {
+	s, ok := u.GameState().Entities().Get(id.EntityID(pb.GetEntityId())).(carrier.Component)
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "entity %v is not a harvester", pb.GetEntityId())
+	}
+
+	h := harvestaction.New(u.Status(), s)
+	actions := []action.Action{h}
+	if pb.GetMove() != nil {
+		m, err := importMove(u, pb.GetMove())
+		if err != nil {
+			return nil, err
+		}
+		if err := h.SetMove(m); err != nil {
+			return nil, err
+		}
+		actions = append(actions, m)
+	}
+	return actions, nil
+}
+
+func importQueue(u *executorutils.Utils, pb *sdpb.ProductionQueue) ([]action.Action, error) {
+	f, ok := u.GameState().Entities().Get(id.EntityID(pb.GetEntityId())).(producer.Component)
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "entity %v cannot produce entities", pb.GetEntityId())
+	}
+
+	q := queueaction.New(u.Status(), f)
+	for _, i := range pb.GetItems() {
+		q.Enqueue(&queueaction.Item{
+			ID:         id.ActionID(i.GetProductionId()),
+			EntityType: i.GetEntityType(),
+			Cost:       i.GetCost(),
+			BuildTicks: i.GetBuildTicks(),
+			Progress:   i.GetProgress(),
+		})
+	}
+	return []action.Action{q}, nil
+}
+
+func importProjectile(u *executorutils.Utils, pb *sdpb.ProjectileShoot) (*projectileaction.Action, []action.Action, error) {
+	s, ok := u.GameState().Entities().Get(id.EntityID(pb.GetEntityId())).(attackable.Component)
+	if !ok {
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "entity %v is not attackable", pb.GetEntityId())
+	}
+	t, ok := u.GameState().Entities().Get(id.EntityID(pb.GetTargetEntityId())).(targetable.Component)
+	if !ok {
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "entity %v is not targetable", pb.GetTargetEntityId())
+	}
+
+	m, err := importMove(u, pb.GetMove())
+	if err != nil {
+		return nil, nil, err
+	}
+	p := projectileaction.New(s, t, m)
+	return p, []action.Action{m, p}, nil
+}
+
+func importAttack(u *executorutils.Utils, pb *sdpb.Attack) ([]action.Action, error) {
+	s, ok := u.GameState().Entities().Get(id.EntityID(pb.GetEntityId())).(attackable.Component)
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "entity %v is not attackable", pb.GetEntityId())
+	}
+	t, ok := u.GameState().Entities().Get(id.EntityID(pb.GetTargetEntityId())).(targetable.Component)
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "entity %v is not targetable", pb.GetTargetEntityId())
+	}
+
+	c, actions, err := importChase(u, pb.GetChase())
+	if err != nil {
+		return nil, err
+	}
+
+	a := attackaction.New(u.Status(), s, t, c)
+	actions = append(actions, a)
+
+	if pb.GetProjectileShoot() != nil {
+		p, projectileActions, err := importProjectile(u, pb.GetProjectileShoot())
+		if err != nil {
+			return nil, err
+		}
+		a.SetProjectileMove(p)
+		actions = append(actions, projectileActions...)
+	}
+	return actions, nil
+}
@@ -0,0 +1,512 @@
+package snapshot
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/component/moveable"
+	"github.com/downflux/game/server/entity/registrytest"
+	"github.com/downflux/game/server/grpc/executorutils"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	apipb "github.com/downflux/game/api/api_go_proto"
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	mcpb "github.com/downflux/game/map/api/constants_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+	sdpb "github.com/downflux/game/server/snapshot/api/data_go_proto"
+)
+
+const (
+	minPathLength = 8
+
+	// nTicks is the number of ticks the game is run before the snapshot
+	// is taken, and the number of ticks the game is run after the
+	// snapshot is restored.
+	nTicks = 25
+)
+
+var (
+	tickDuration     = 100 * time.Millisecond
+	clusterDimension = &gdpb.Coordinate{X: 2, Y: 2}
+
+	/**
+	 *       R - - -
+	 *       - - - -
+	 *       - - - -
+	 * Y = 0 - - - -
+	 *   X = 0
+	 *
+	 * R is a resource field.
+	 */
+	simpleMap = func() *mdpb.TileMap {
+		pb := &mdpb.TileMap{
+			Dimension: &gdpb.Coordinate{X: 4, Y: 4},
+			TerrainCosts: []*mdpb.TerrainCost{
+				{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_BLOCKED, Cost: math.Inf(0)},
+				{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS, Cost: 1},
+			},
+			ResourceFields: []*mdpb.ResourceField{
+				{Position: &gdpb.Position{X: 0, Y: 3}, Resources: 30},
+			},
+		}
+		for x := int32(0); x < 4; x++ {
+			for y := int32(0); y < 4; y++ {
+				pb.Tiles = append(pb.GetTiles(), &mdpb.Tile{
+					Coordinate:  &gdpb.Coordinate{X: x, Y: y},
+					TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS,
+				})
+			}
+		}
+		return pb
+	}()
+
+	sortEntities = protocmp.SortRepeated(func(a, b *gdpb.Entity) bool { return a.GetEntityId() < b.GetEntityId() })
+	sortCurves   = protocmp.SortRepeated(func(a, b *gdpb.Curve) bool {
+		return a.GetEntityId() < b.GetEntityId() || a.GetEntityId() == b.GetEntityId() && a.GetProperty() < b.GetProperty()
+	})
+
+	registryPB = func() *edpb.Registry {
+		pb := registrytest.Registry()
+		pb.GetUnits()[0].BuildTicks = 10
+		pb.Harvesters = []*edpb.HarvesterDefinition{
+			{
+				EntityType:   gcpb.EntityType_ENTITY_TYPE_HARVESTER,
+				MoveVelocity: 2,
+				Health:       100,
+				Capacity:     6,
+				HarvestRate:  2,
+			},
+		}
+		pb.Refineries = []*edpb.RefineryDefinition{
+			{EntityType: gcpb.EntityType_ENTITY_TYPE_REFINERY, Health: 100},
+		}
+		pb.Factories = []*edpb.FactoryDefinition{
+			{
+				EntityType: gcpb.EntityType_ENTITY_TYPE_FACTORY,
+				Health:     100,
+				Produces:   []gcpb.EntityType{gcpb.EntityType_ENTITY_TYPE_TANK},
+			},
+		}
+		return pb
+	}()
+)
+
+func newUtils(t *testing.T) *executorutils.Utils {
+	u, err := executorutils.New(simpleMap, registryPB, clusterDimension, tickDuration, minPathLength)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	return u
+}
+
+func export(u *executorutils.Utils) *gdpb.GameState {
+	return u.GameState().Export(0, u.GameState().NoFilter())
+}
+
+func TestSaveLoad(t *testing.T) {
+	u := newUtils(t)
+
+	// The tank of the enemy client chases and attacks the tank of the
+	// client.
+	cid := id.ClientID("client-id")
+	enemy := id.ClientID("enemy-id")
+
+	// The client shares control of its units with an ally, which should
+	// be preserved along with the teams of all clients.
+	ally := id.ClientID("ally-id")
+	for _, m := range []*gdpb.TeamMembership{
+		{ClientId: cid.Value(), Team: 1},
+		{ClientId: ally.Value(), Team: 1},
+		{ClientId: enemy.Value(), Team: 2},
+	} {
+		if err := u.Executor().AddClientWithID(id.ClientID(m.GetClientId())); err != nil {
+			t.Fatalf("AddClientWithID() = %v, want = nil", err)
+		}
+		if err := u.Executor().SetClientTeam(id.ClientID(m.GetClientId()), m.GetTeam()); err != nil {
+			t.Fatalf("SetClientTeam() = %v, want = nil", err)
+		}
+	}
+	u.ACL().Grant(cid, ally, 0)
+
+	if err := u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid); err != nil {
+		t.Fatalf("ProduceFree() = %v, want = nil", err)
+	}
+	if err := u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 3, Y: 3}, enemy); err != nil {
+		t.Fatalf("ProduceFree() = %v, want = nil", err)
+	}
+
+	// Add a harvester which shuttles between the resource field and a
+	// refinery for the duration of the test.
+	if err := u.AddAccount(cid); err != nil {
+		t.Fatalf("AddAccount() = %v, want = nil", err)
+	}
+	if err := u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_HARVESTER, &gdpb.Position{X: 1, Y: 2}, cid); err != nil {
+		t.Fatalf("ProduceFree() = %v, want = nil", err)
+	}
+	if err := u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_REFINERY, &gdpb.Position{X: 3, Y: 1}, cid); err != nil {
+		t.Fatalf("ProduceFree() = %v, want = nil", err)
+	}
+
+	// Add a factory which is still building units when the snapshot is
+	// taken.
+	if err := u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_FACTORY, &gdpb.Position{X: 2, Y: 2}, cid); err != nil {
+		t.Fatalf("ProduceFree() = %v, want = nil", err)
+	}
+
+	if err := u.Executor().Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	tanks := map[id.ClientID]string{}
+	var factory string
+	for _, e := range u.GameState().Entities().Iter() {
+		switch e.Type() {
+		case gcpb.EntityType_ENTITY_TYPE_TANK:
+			tanks[e.ClientID(u.Status().Tick())] = e.ID().Value()
+		case gcpb.EntityType_ENTITY_TYPE_FACTORY:
+			factory = e.ID().Value()
+		}
+	}
+	if len(tanks) != 2 {
+		t.Fatalf("len() = %v, want = %v", len(tanks), 2)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := u.Produce(&apipb.ProduceRequest{
+			Tick:       u.Status().Tick().Value(),
+			ClientId:   cid.Value(),
+			EntityId:   factory,
+			EntityType: gcpb.EntityType_ENTITY_TYPE_TANK,
+		}); err != nil {
+			t.Fatalf("Produce() = _, %v, want = nil", err)
+		}
+	}
+	if err := u.SetRallyPoint(&apipb.SetRallyPointRequest{
+		Tick:       u.Status().Tick().Value(),
+		ClientId:   cid.Value(),
+		EntityId:   factory,
+		RallyPoint: &gdpb.Position{X: 3, Y: 3},
+	}); err != nil {
+		t.Fatalf("SetRallyPoint() = %v, want = nil", err)
+	}
+
+	if _, err := u.Move(&apipb.MoveRequest{
+		ClientId:    cid.Value(),
+		EntityIds:   []string{tanks[cid]},
+		Destination: &gdpb.Position{X: 3, Y: 0},
+	}); err != nil {
+		t.Fatalf("Move() = %v, want = nil", err)
+	}
+	if _, err := u.Attack(&apipb.AttackRequest{
+		ClientId:       enemy.Value(),
+		EntityIds:      []string{tanks[enemy]},
+		TargetEntityId: tanks[cid],
+	}); err != nil {
+		t.Fatalf("Attack() = %v, want = nil", err)
+	}
+
+	for i := 0; i < nTicks; i++ {
+		if err := u.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+
+	want, err := Save(u)
+	if err != nil {
+		t.Fatalf("Save() = _, %v, want = nil", err)
+	}
+
+	v := newUtils(t)
+	if err := Load(v, want); err != nil {
+		t.Fatalf("Load() = %v, want = nil", err)
+	}
+
+	got, err := Save(v)
+	if err != nil {
+		t.Fatalf("Save() = _, %v, want = nil", err)
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Fatalf("Save() mismatch (-want +got):\n%v", diff)
+	}
+
+	if team, err := v.Executor().ClientTeam(ally); err != nil || team != 1 {
+		t.Errorf("ClientTeam() = %v, %v, want = %v, nil", team, err, 1)
+	}
+	if !v.ACL().Allowed(cid, ally, v.Status().Tick()) {
+		t.Errorf("Allowed() = false, want = true")
+	}
+
+	// Restored structures should block the tiles under their footprint.
+	if v.Footprints().Placeable(&gdpb.Position{X: 2, Y: 2}, &gdpb.Coordinate{X: 1, Y: 1}) {
+		t.Errorf("Placeable() = true, want = false")
+	}
+
+	// The restored game should evolve identically to the original game.
+	for i := 0; i < nTicks; i++ {
+		if err := u.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+		if err := v.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+
+		if diff := cmp.Diff(
+			export(u),
+			export(v),
+			protocmp.Transform(),
+			sortEntities,
+			sortCurves,
+		); diff != "" {
+			t.Fatalf("[%v] Export() mismatch (-want +got):\n%v", v.Status().Tick(), diff)
+		}
+	}
+}
+
+func TestLoadInProgress(t *testing.T) {
+	u := newUtils(t)
+	if err := u.Executor().Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	if err := Load(u, &sdpb.Snapshot{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Load() = %v, want = %v", err, codes.FailedPrecondition)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	const window = 10
+	const delay = 3
+
+	// u receives the move command on time, while v receives the same
+	// command several ticks late.
+	cid := id.ClientID("client-id")
+	u := newUtils(t)
+	v := newUtils(t)
+	for _, w := range []*executorutils.Utils{u, v} {
+		w.Executor().SetCheckpointer(NewCheckpointer(w), window)
+		if err := w.ProduceFree(gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid); err != nil {
+			t.Fatalf("ProduceFree() = %v, want = nil", err)
+		}
+		if err := w.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+
+	var tanks []string
+	for _, e := range u.GameState().Entities().Iter() {
+		if e.Type() == gcpb.EntityType_ENTITY_TYPE_TANK {
+			tanks = append(tanks, e.ID().Value())
+		}
+	}
+	if len(tanks) != 1 {
+		t.Fatalf("len() = %v, want = %v", len(tanks), 1)
+	}
+
+	tick := u.Status().Tick()
+	for i := 0; i < delay; i++ {
+		if err := v.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+
+	req := &apipb.MoveRequest{
+		Tick:        tick.Value(),
+		ClientId:    cid.Value(),
+		EntityIds:   []string{tanks[0]},
+		Destination: &gdpb.Position{X: 3, Y: 0},
+	}
+	if _, err := u.Move(req); err != nil {
+		t.Fatalf("Move() = %v, want = nil", err)
+	}
+	if _, err := v.Move(req); err != nil {
+		t.Fatalf("Move() = %v, want = nil", err)
+	}
+	if got := v.Status().Tick(); got != tick+delay {
+		t.Fatalf("Tick() = %v, want = %v", got, tick+delay)
+	}
+
+	for i := 0; i < delay; i++ {
+		if err := u.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+
+	// The rolled back game should match the game in which the move
+	// command was received on time.
+	for i := 0; i < nTicks; i++ {
+		if diff := cmp.Diff(
+			export(u),
+			export(v),
+			protocmp.Transform(),
+			sortEntities,
+			sortCurves,
+		); diff != "" {
+			t.Fatalf("[%v] Export() mismatch (-want +got):\n%v", v.Status().Tick(), diff)
+		}
+
+		if err := u.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+		if err := v.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+}
+
+func TestRollbackInFlight(t *testing.T) {
+	const window = 10
+	const delay = 3
+	const lead = 2
+
+	// u receives the second move command on time, while v receives it
+	// several ticks late, after the first tank has planned its path.
+	cid := id.ClientID("client-id")
+	u := newUtils(t)
+	v := newUtils(t)
+	for _, w := range []*executorutils.Utils{u, v} {
+		w.Executor().SetCheckpointer(NewCheckpointer(w), window)
+		for _, p := range []*gdpb.Position{{X: 0, Y: 0}, {X: 3, Y: 0}} {
+			if err := w.ProduceFree(gcpb.EntityType_ENTITY_TYPE_TANK, p, cid); err != nil {
+				t.Fatalf("ProduceFree() = %v, want = nil", err)
+			}
+		}
+		if err := w.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+
+	tanks := map[float64]string{}
+	for _, e := range u.GameState().Entities().Iter() {
+		if e.Type() == gcpb.EntityType_ENTITY_TYPE_TANK {
+			tanks[e.(moveable.Component).Position(u.Status().Tick()).GetX()] = e.ID().Value()
+		}
+	}
+	if len(tanks) != 2 {
+		t.Fatalf("len() = %v, want = %v", len(tanks), 2)
+	}
+
+	for _, w := range []*executorutils.Utils{u, v} {
+		if _, err := w.Move(&apipb.MoveRequest{
+			Tick:        w.Status().Tick().Value(),
+			ClientId:    cid.Value(),
+			EntityIds:   []string{tanks[0]},
+			Destination: &gdpb.Position{X: 3, Y: 2},
+		}); err != nil {
+			t.Fatalf("Move() = %v, want = nil", err)
+		}
+		for i := 0; i < lead; i++ {
+			if err := w.Executor().Step(); err != nil {
+				t.Fatalf("Step() = %v, want = nil", err)
+			}
+		}
+	}
+
+	tick := u.Status().Tick()
+	for i := 0; i < delay; i++ {
+		if err := v.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+
+	req := &apipb.MoveRequest{
+		Tick:        tick.Value(),
+		ClientId:    cid.Value(),
+		EntityIds:   []string{tanks[3]},
+		Destination: &gdpb.Position{X: 2, Y: 0},
+	}
+	if _, err := u.Move(req); err != nil {
+		t.Fatalf("Move() = %v, want = nil", err)
+	}
+	if _, err := v.Move(req); err != nil {
+		t.Fatalf("Move() = %v, want = nil", err)
+	}
+
+	for i := 0; i < delay; i++ {
+		if err := u.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+
+	// The path planned by the first tank before the rollback tick should
+	// be preserved, rather than re-planned from the rollback tick.
+	for i := 0; i < nTicks; i++ {
+		if diff := cmp.Diff(
+			export(u),
+			export(v),
+			protocmp.Transform(),
+			sortEntities,
+			sortCurves,
+		); diff != "" {
+			t.Fatalf("[%v] Export() mismatch (-want +got):\n%v", v.Status().Tick(), diff)
+		}
+
+		if err := u.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+		if err := v.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+}
+
+func TestRollbackFootprint(t *testing.T) {
+	const window = 10
+
+	cid := id.ClientID("client-id")
+	u := newUtils(t)
+	u.Executor().SetCheckpointer(NewCheckpointer(u), window)
+	if err := u.ProduceFree(gcpb.EntityType_ENTITY_TYPE_TANK, &gdpb.Position{X: 0, Y: 0}, cid); err != nil {
+		t.Fatalf("ProduceFree() = %v, want = nil", err)
+	}
+	if err := u.Executor().Step(); err != nil {
+		t.Fatalf("Step() = %v, want = nil", err)
+	}
+
+	var tank string
+	for _, e := range u.GameState().Entities().Iter() {
+		if e.Type() == gcpb.EntityType_ENTITY_TYPE_TANK {
+			tank = e.ID().Value()
+		}
+	}
+
+	// The refinery is built after the rollback tick, and therefore is
+	// removed and rebuilt when the game is rolled back.
+	tick := u.Status().Tick()
+	if err := u.ProduceDebug(gcpb.EntityType_ENTITY_TYPE_REFINERY, &gdpb.Position{X: 2, Y: 2}); err != nil {
+		t.Fatalf("ProduceDebug() = %v, want = nil", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := u.Executor().Step(); err != nil {
+			t.Fatalf("Step() = %v, want = nil", err)
+		}
+	}
+
+	if _, err := u.Move(&apipb.MoveRequest{
+		Tick:        tick.Value(),
+		ClientId:    cid.Value(),
+		EntityIds:   []string{tank},
+		Destination: &gdpb.Position{X: 3, Y: 0},
+	}); err != nil {
+		t.Fatalf("Move() = %v, want = nil", err)
+	}
+
+	var refineries int
+	for _, e := range u.GameState().Entities().Iter() {
+		if e.Type() == gcpb.EntityType_ENTITY_TYPE_REFINERY && e.End() == 0 {
+			refineries++
+		}
+	}
+	if refineries != 1 {
+		t.Errorf("len() = %v, want = %v", refineries, 1)
+	}
+	if u.Footprints().Placeable(&gdpb.Position{X: 2, Y: 2}, &gdpb.Coordinate{X: 1, Y: 1}) {
+		t.Errorf("Placeable() = true, want = false")
+	}
+}
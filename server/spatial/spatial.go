@@ -0,0 +1,357 @@
+// Package spatial implements a spatial hash over the positions of game
+// entities, which answers "who is near X" queries without scanning the full
+// entity list.
+//
+// An Index is a snapshot of entity positions at a single tick, and is not
+// updated as the underlying position curves change. A Tracker keeps an Index
+// up to date with the current tick.
+package spatial
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/entity/list"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/map/utils"
+	"github.com/downflux/game/server/entity/component/harvestable"
+	"github.com/downflux/game/server/entity/component/moveable"
+	"github.com/downflux/game/server/entity/component/positionable"
+	"github.com/downflux/game/server/entity/component/targetable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	serverstatus "github.com/downflux/game/engine/status/status"
+)
+
+// Filter selects the entities returned by a query. Queries only return
+// entities which pass all input filters.
+type Filter func(e entity.Entity) bool
+
+// Targetable selects entities which may be attacked.
+func Targetable(e entity.Entity) bool {
+	_, ok := e.(targetable.Component)
+	return ok
+}
+
+// Moveable selects entities which may move.
+func Moveable(e entity.Entity) bool {
+	_, ok := e.(moveable.Component)
+	return ok
+}
+
+// Harvestable selects resource fields.
+func Harvestable(e entity.Entity) bool {
+	_, ok := e.(harvestable.Component)
+	return ok
+}
+
+// Type selects entities of the input type.
+func Type(t gcpb.EntityType) Filter {
+	return func(e entity.Entity) bool { return e.Type() == t }
+}
+
+// Clients selects entities owned by any of the input clients at the input
+// tick.
+func Clients(tick id.Tick, cids ...id.ClientID) Filter {
+	return func(e entity.Entity) bool {
+		cid := e.ClientID(tick)
+		for _, c := range cids {
+			if cid == c {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Friendly selects entities owned by the input client or by an ally of the
+// input client at the input tick, as reported by the input allied function.
+// Entities which are not owned by any client are not friendly.
+func Friendly(tick id.Tick, cid id.ClientID, allied func(a, b id.ClientID) bool) Filter {
+	return func(e entity.Entity) bool {
+		c := e.ClientID(tick)
+		return c != "" && cid != "" && allied(c, cid)
+	}
+}
+
+// Hostile selects entities owned by clients which are not allied with the
+// input client at the input tick. Entities which are not owned by any client
+// are neutral, and are not hostile.
+func Hostile(tick id.Tick, cid id.ClientID, allied func(a, b id.ClientID) bool) Filter {
+	return func(e entity.Entity) bool {
+		c := e.ClientID(tick)
+		return c != "" && cid != "" && !allied(c, cid)
+	}
+}
+
+// cell is the coordinate of a bucket in the spatial hash.
+type cell struct {
+	x, y int64
+}
+
+// Index is a spatial hash of positionable entities. An Index is read-only
+// once built, and may be queried concurrently.
+type Index struct {
+	// size is the side length, in tiles, of each bucket.
+	size float64
+
+	cells     map[cell][]entity.Entity
+	positions map[id.EntityID]*gdpb.Position
+
+	// min and max are the (inclusive) bounds of all non-empty buckets.
+	min, max cell
+}
+
+// New constructs an Index over the positions of the input entities at the
+// input tick. Entities which are not positionable or which have been
+// destroyed are skipped.
+//
+// The bucket size should be on the order of the typical query radius.
+func New(entities []entity.Entity, tick id.Tick, size float64) (*Index, error) {
+	if size <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "bucket size %v must be positive", size)
+	}
+
+	ix := &Index{
+		size:      size,
+		cells:     map[cell][]entity.Entity{},
+		positions: map[id.EntityID]*gdpb.Position{},
+	}
+	for _, e := range entities {
+		p, ok := e.(positionable.Component)
+		if !ok || e.End() != 0 {
+			continue
+		}
+		pos := p.Position(tick)
+		ix.positions[e.ID()] = pos
+
+		c := ix.cell(pos)
+		if len(ix.cells) == 0 {
+			ix.min, ix.max = c, c
+		}
+		ix.min = cell{x: minInt64(ix.min.x, c.x), y: minInt64(ix.min.y, c.y)}
+		ix.max = cell{x: maxInt64(ix.max.x, c.x), y: maxInt64(ix.max.y, c.y)}
+		ix.cells[c] = append(ix.cells[c], e)
+	}
+	return ix, nil
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// clamp returns the intersection of the input (inclusive) range of buckets
+// with the bounds of all non-empty buckets.
+func (ix *Index) clamp(lo, hi cell) (cell, cell) {
+	return cell{x: maxInt64(lo.x, ix.min.x), y: maxInt64(lo.y, ix.min.y)},
+		cell{x: minInt64(hi.x, ix.max.x), y: minInt64(hi.y, ix.max.y)}
+}
+
+func (ix *Index) cell(p *gdpb.Position) cell {
+	return cell{
+		x: int64(math.Floor(p.GetX() / ix.size)),
+		y: int64(math.Floor(p.GetY() / ix.size)),
+	}
+}
+
+// Position returns the indexed position of the input entity, or nil if the
+// entity is not in the Index.
+func (ix *Index) Position(eid id.EntityID) *gdpb.Position {
+	return ix.positions[eid]
+}
+
+// match checks if the input entity passes all input filters.
+func match(e entity.Entity, filters []Filter) bool {
+	for _, f := range filters {
+		if !f(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// scan appends to the input list all entities in the bucket which pass the
+// input filters.
+func (ix *Index) scan(res []entity.Entity, c cell, filters []Filter) []entity.Entity {
+	for _, e := range ix.cells[c] {
+		if match(e, filters) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// sortByDistance sorts the input entities by distance from the input
+// position. Ties are broken by the entity ID to ensure replays are
+// deterministic.
+func (ix *Index) sortByDistance(entities []entity.Entity, p *gdpb.Position) {
+	d := func(e entity.Entity) float64 {
+		return utils.Euclidean(p, ix.positions[e.ID()])
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		if di, dj := d(entities[i]), d(entities[j]); di != dj {
+			return di < dj
+		}
+		return entities[i].ID() < entities[j].ID()
+	})
+}
+
+// Radius returns all entities within distance r (inclusive) of the input
+// position. Entities are sorted by distance from the input position, and ties
+// are broken by the entity ID.
+func (ix *Index) Radius(p *gdpb.Position, r float64, filters ...Filter) []entity.Entity {
+	min, max := ix.clamp(
+		ix.cell(&gdpb.Position{X: p.GetX() - r, Y: p.GetY() - r}),
+		ix.cell(&gdpb.Position{X: p.GetX() + r, Y: p.GetY() + r}),
+	)
+
+	var res []entity.Entity
+	for x := min.x; x <= max.x; x++ {
+		for y := min.y; y <= max.y; y++ {
+			for _, e := range ix.scan(nil, cell{x: x, y: y}, filters) {
+				if utils.Euclidean(p, ix.positions[e.ID()]) <= r {
+					res = append(res, e)
+				}
+			}
+		}
+	}
+	ix.sortByDistance(res, p)
+	return res
+}
+
+// Rectangle returns all entities within the (inclusive) bounding box spanned
+// by the input lower-left and upper-right corners. Entities are sorted by
+// entity ID.
+func (ix *Index) Rectangle(min, max *gdpb.Position, filters ...Filter) []entity.Entity {
+	lo, hi := ix.clamp(ix.cell(min), ix.cell(max))
+
+	var res []entity.Entity
+	for x := lo.x; x <= hi.x; x++ {
+		for y := lo.y; y <= hi.y; y++ {
+			for _, e := range ix.scan(nil, cell{x: x, y: y}, filters) {
+				q := ix.positions[e.ID()]
+				if min.GetX() <= q.GetX() && q.GetX() <= max.GetX() && min.GetY() <= q.GetY() && q.GetY() <= max.GetY() {
+					res = append(res, e)
+				}
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID() < res[j].ID() })
+	return res
+}
+
+// Nearest returns the (at most) k entities closest to the input position.
+// Entities are sorted by distance from the input position, and ties are
+// broken by the entity ID.
+//
+// Buckets are searched in rings of increasing distance around the input
+// position, so that the search stops early if the k closest entities are
+// nearby.
+func (ix *Index) Nearest(p *gdpb.Position, k int, filters ...Filter) []entity.Entity {
+	if k <= 0 || len(ix.cells) == 0 {
+		return nil
+	}
+
+	c := ix.cell(p)
+	var res []entity.Entity
+	for r := int64(0); ; r++ {
+		// Stop once the ring lies entirely outside of all non-empty
+		// buckets.
+		if c.x-r < ix.min.x && c.x+r > ix.max.x && c.y-r < ix.min.y && c.y+r > ix.max.y {
+			break
+		}
+
+		for x := c.x - r; x <= c.x+r; x++ {
+			for y := c.y - r; y <= c.y+r; y++ {
+				if x == c.x-r || x == c.x+r || y == c.y-r || y == c.y+r {
+					res = ix.scan(res, cell{x: x, y: y}, filters)
+				}
+			}
+		}
+
+		// All entities outside of the current ring are at least r
+		// buckets away from the input position.
+		if len(res) >= k {
+			ix.sortByDistance(res, p)
+			if utils.Euclidean(p, ix.positions[res[k-1].ID()]) <= float64(r)*ix.size {
+				break
+			}
+		}
+	}
+
+	ix.sortByDistance(res, p)
+	if len(res) > k {
+		res = res[:k]
+	}
+	return res
+}
+
+// Tracker keeps an Index of a list of entities up to date with the current
+// tick. The Index is rebuilt from the position curves of the entities
+// whenever it is requested at a different tick than the last build, or after
+// the Tracker is reset.
+//
+// Entities which are added or moved within the current tick are only
+// reflected after the Tracker is reset, e.g. at the start of each Visitor
+// pass.
+type Tracker struct {
+	entities *list.List
+	status   serverstatus.ReadOnlyStatus
+	size     float64
+
+	// mux guards the cached Index and the tick at which it was built.
+	mux   sync.Mutex
+	index *Index
+	tick  id.Tick
+}
+
+// NewTracker constructs a new Tracker instance. See New for details on the
+// bucket size.
+func NewTracker(entities *list.List, dfStatus serverstatus.ReadOnlyStatus, size float64) *Tracker {
+	return &Tracker{
+		entities: entities,
+		status:   dfStatus,
+		size:     size,
+	}
+}
+
+// Reset discards the cached Index.
+func (t *Tracker) Reset() {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.index = nil
+}
+
+// Index returns an Index of the entity positions at the current tick.
+func (t *Tracker) Index() (*Index, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	tick := t.status.Tick()
+	if t.index != nil && t.tick == tick {
+		return t.index, nil
+	}
+
+	ix, err := New(t.entities.Iter(), tick, t.size)
+	if err != nil {
+		return nil, err
+	}
+	t.index, t.tick = ix, tick
+	return ix, nil
+}
@@ -0,0 +1,224 @@
+package spatial
+
+import (
+	"testing"
+	"time"
+
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/entity/list"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/server/entity/registrytest"
+	"github.com/downflux/game/server/entity/resource"
+	"github.com/downflux/game/server/entity/tank"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	serverstatus "github.com/downflux/game/engine/status/status"
+)
+
+var (
+	tankPB = registrytest.Tank()
+)
+
+func newTank(t *testing.T, eid id.EntityID, p *gdpb.Position) *tank.Entity {
+	return newOwnedTank(t, eid, p, "")
+}
+
+func newOwnedTank(t *testing.T, eid id.EntityID, p *gdpb.Position, cid id.ClientID) *tank.Entity {
+	e, err := tank.New(tankPB, eid, 0, p, cid, nil)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = _, nil", err)
+	}
+	return e
+}
+
+func ids(entities []entity.Entity) []id.EntityID {
+	var res []id.EntityID
+	for _, e := range entities {
+		res = append(res, e.ID())
+	}
+	return res
+}
+
+func TestNewError(t *testing.T) {
+	if _, err := New(nil, 0, 0); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("New() = _, %v, want = _, %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestRadius(t *testing.T) {
+	dead := newTank(t, "dead", &gdpb.Position{X: 0, Y: 0})
+	dead.Delete(1)
+
+	entities := []entity.Entity{
+		newTank(t, "b", &gdpb.Position{X: 1, Y: 0}),
+		newTank(t, "a", &gdpb.Position{X: 0, Y: 1}),
+		newTank(t, "c", &gdpb.Position{X: 0.5, Y: 0}),
+		newTank(t, "d", &gdpb.Position{X: -2, Y: -2}),
+		newTank(t, "e", &gdpb.Position{X: 5, Y: 5}),
+		dead,
+	}
+
+	testConfigs := []struct {
+		name string
+		p    *gdpb.Position
+		r    float64
+		size float64
+		want []id.EntityID
+	}{
+		{name: "Empty", p: &gdpb.Position{X: 10, Y: 10}, r: 1, size: 1, want: nil},
+		{name: "Point", p: &gdpb.Position{X: 5, Y: 5}, r: 0, size: 1, want: []id.EntityID{"e"}},
+		{name: "SortedByDistance", p: &gdpb.Position{X: 0, Y: 0}, r: 1, size: 1, want: []id.EntityID{"c", "a", "b"}},
+		{name: "NegativeCoordinates", p: &gdpb.Position{X: 0, Y: 0}, r: 3, size: 1, want: []id.EntityID{"c", "a", "b", "d"}},
+		{name: "LargeBuckets", p: &gdpb.Position{X: 0, Y: 0}, r: 3, size: 10, want: []id.EntityID{"c", "a", "b", "d"}},
+		{name: "SmallBuckets", p: &gdpb.Position{X: 0, Y: 0}, r: 3, size: 0.1, want: []id.EntityID{"c", "a", "b", "d"}},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			ix, err := New(entities, 0, c.size)
+			if err != nil {
+				t.Fatalf("New() = _, %v, want = _, nil", err)
+			}
+			if diff := cmp.Diff(c.want, ids(ix.Radius(c.p, c.r))); diff != "" {
+				t.Errorf("Radius() mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestRectangle(t *testing.T) {
+	entities := []entity.Entity{
+		newTank(t, "b", &gdpb.Position{X: 1, Y: 1}),
+		newTank(t, "a", &gdpb.Position{X: 0, Y: 0}),
+		newTank(t, "c", &gdpb.Position{X: 2, Y: 0.5}),
+		newTank(t, "d", &gdpb.Position{X: -1, Y: 0}),
+		newTank(t, "e", &gdpb.Position{X: 100, Y: 100}),
+	}
+
+	testConfigs := []struct {
+		name     string
+		min, max *gdpb.Position
+		want     []id.EntityID
+	}{
+		{name: "Empty", min: &gdpb.Position{X: 10, Y: 10}, max: &gdpb.Position{X: 20, Y: 20}, want: nil},
+		{name: "Inclusive", min: &gdpb.Position{X: 0, Y: 0}, max: &gdpb.Position{X: 2, Y: 1}, want: []id.EntityID{"a", "b", "c"}},
+		{name: "Partial", min: &gdpb.Position{X: -1, Y: 0}, max: &gdpb.Position{X: 1.5, Y: 0.5}, want: []id.EntityID{"a", "d"}},
+		{name: "All", min: &gdpb.Position{X: -1e9, Y: -1e9}, max: &gdpb.Position{X: 1e9, Y: 1e9}, want: []id.EntityID{"a", "b", "c", "d", "e"}},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			ix, err := New(entities, 0, 1)
+			if err != nil {
+				t.Fatalf("New() = _, %v, want = _, nil", err)
+			}
+			if diff := cmp.Diff(c.want, ids(ix.Rectangle(c.min, c.max))); diff != "" {
+				t.Errorf("Rectangle() mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestNearest(t *testing.T) {
+	f, err := resource.New("field", 0, &gdpb.Position{X: 1, Y: 0}, 10)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = _, nil", err)
+	}
+
+	entities := []entity.Entity{
+		newOwnedTank(t, "a", &gdpb.Position{X: 0, Y: 2}, "client-a"),
+		newOwnedTank(t, "b", &gdpb.Position{X: 2, Y: 0}, "client-b"),
+		newOwnedTank(t, "c", &gdpb.Position{X: -2, Y: 0}, "client-c"),
+		newOwnedTank(t, "d", &gdpb.Position{X: 50, Y: 50}, "client-a"),
+		f,
+	}
+
+	// client-a and client-b are allies.
+	allied := func(a, b id.ClientID) bool {
+		team := map[id.ClientID]int{"client-a": 1, "client-b": 1, "client-c": 2}
+		return team[a] == team[b]
+	}
+
+	testConfigs := []struct {
+		name    string
+		p       *gdpb.Position
+		k       int
+		filters []Filter
+		want    []id.EntityID
+	}{
+		{name: "Zero", p: &gdpb.Position{}, k: 0, want: nil},
+		{name: "Closest", p: &gdpb.Position{}, k: 1, want: []id.EntityID{"field"}},
+		{name: "TiesByID", p: &gdpb.Position{}, k: 3, want: []id.EntityID{"field", "a", "b"}},
+		{name: "Far", p: &gdpb.Position{X: 40, Y: 40}, k: 1, want: []id.EntityID{"d"}},
+		{name: "TooFew", p: &gdpb.Position{}, k: 10, want: []id.EntityID{"field", "a", "b", "c", "d"}},
+		{name: "Targetable", p: &gdpb.Position{}, k: 1, filters: []Filter{Targetable}, want: []id.EntityID{"a"}},
+		{name: "Harvestable", p: &gdpb.Position{X: 50, Y: 50}, k: 1, filters: []Filter{Harvestable}, want: []id.EntityID{"field"}},
+		{name: "Type", p: &gdpb.Position{}, k: 5, filters: []Filter{Type(gcpb.EntityType_ENTITY_TYPE_TANK), Moveable}, want: []id.EntityID{"a", "b", "c", "d"}},
+		{name: "Clients", p: &gdpb.Position{}, k: 5, filters: []Filter{Clients(0, "client-a")}, want: []id.EntityID{"a", "d"}},
+		{name: "Friendly", p: &gdpb.Position{}, k: 5, filters: []Filter{Friendly(0, "client-b", allied)}, want: []id.EntityID{"a", "b", "d"}},
+		{name: "Hostile", p: &gdpb.Position{}, k: 5, filters: []Filter{Hostile(0, "client-b", allied)}, want: []id.EntityID{"c"}},
+		{name: "NoMatch", p: &gdpb.Position{}, k: 1, filters: []Filter{Clients(0, "client-d")}, want: nil},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			ix, err := New(entities, 0, 1)
+			if err != nil {
+				t.Fatalf("New() = _, %v, want = _, nil", err)
+			}
+			if diff := cmp.Diff(c.want, ids(ix.Nearest(c.p, c.k, c.filters...))); diff != "" {
+				t.Errorf("Nearest() mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestTracker(t *testing.T) {
+	s := serverstatus.New(time.Millisecond)
+	entities := list.New()
+	tr := NewTracker(entities, s, 1)
+
+	e := newTank(t, "a", &gdpb.Position{X: 0, Y: 0})
+	e.PositionCurve().Add(10, &gdpb.Position{X: 10, Y: 0})
+	if err := entities.Append(e); err != nil {
+		t.Fatalf("Append() = %v, want = nil", err)
+	}
+
+	ix, err := tr.Index()
+	if err != nil {
+		t.Fatalf("Index() = _, %v, want = _, nil", err)
+	}
+	if diff := cmp.Diff([]id.EntityID{"a"}, ids(ix.Radius(&gdpb.Position{}, 0))); diff != "" {
+		t.Errorf("Radius() mismatch (-want +got):\n%v", diff)
+	}
+
+	// The Index is cached within the same tick.
+	if err := entities.Append(newTank(t, "b", &gdpb.Position{X: 0, Y: 0})); err != nil {
+		t.Fatalf("Append() = %v, want = nil", err)
+	}
+	if got, err := tr.Index(); err != nil || got != ix {
+		t.Errorf("Index() = %v, %v, want = %v, nil", got, err, ix)
+	}
+
+	// The Index is rebuilt after a reset.
+	tr.Reset()
+	if ix, err = tr.Index(); err != nil {
+		t.Fatalf("Index() = _, %v, want = _, nil", err)
+	}
+	if diff := cmp.Diff([]id.EntityID{"a", "b"}, ids(ix.Radius(&gdpb.Position{}, 0))); diff != "" {
+		t.Errorf("Radius() mismatch (-want +got):\n%v", diff)
+	}
+
+	// The Index follows the position curves at the current tick.
+	s.SetTick(5)
+	if ix, err = tr.Index(); err != nil {
+		t.Fatalf("Index() = _, %v, want = _, nil", err)
+	}
+	if diff := cmp.Diff([]id.EntityID{"a"}, ids(ix.Radius(&gdpb.Position{X: 5, Y: 0}, 0))); diff != "" {
+		t.Errorf("Radius() mismatch (-want +got):\n%v", diff)
+	}
+}
@@ -1,6 +1,8 @@
 package attack
 
 import (
+	"context"
+
 	"github.com/downflux/game/engine/fsm/action"
 	"github.com/downflux/game/engine/fsm/schedule"
 	"github.com/downflux/game/engine/gamestate/dirty"
@@ -77,7 +79,7 @@ func (v *Visitor) visitFSM(node *attack.Action) error {
 	return nil
 }
 
-func (v *Visitor) Visit(a visitor.Agent) error {
+func (v *Visitor) Visit(ctx context.Context, a visitor.Agent) error {
 	if node, ok := a.(*attack.Action); ok {
 		return v.visitFSM(node)
 	}
@@ -1,11 +1,16 @@
 package projectile
 
 import (
+	"context"
+
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/schedule"
 	"github.com/downflux/game/engine/gamestate/dirty"
 	"github.com/downflux/game/engine/status/status"
 	"github.com/downflux/game/engine/visitor/visitor"
 	"github.com/downflux/game/server/fsm/attack/projectile"
 	"github.com/downflux/game/server/fsm/commonstate"
+	"github.com/downflux/game/server/fsm/death"
 
 	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
 )
@@ -18,17 +23,22 @@ type Visitor struct {
 	visitor.Base                       // Read-only.
 	status       status.ReadOnlyStatus // Read-only.
 	dirty        *dirty.List
+
+	// schedule is used to check if the target is destroyed once the
+	// target health has been depleted.
+	schedule *schedule.Schedule
 }
 
-func New(s status.ReadOnlyStatus, d *dirty.List) *Visitor {
+func New(s status.ReadOnlyStatus, d *dirty.List, fsmSchedule *schedule.Schedule) *Visitor {
 	return &Visitor{
-		Base:   *visitor.New(fsmType),
-		status: s,
-		dirty:  d,
+		Base:     *visitor.New(fsmType),
+		status:   s,
+		dirty:    d,
+		schedule: fsmSchedule,
 	}
 }
 
-func (v Visitor) Visit(i visitor.Agent) error {
+func (v Visitor) Visit(ctx context.Context, i visitor.Agent) error {
 	if node, ok := i.(*projectile.Action); ok {
 		return v.visitFSM(node)
 	}
@@ -63,6 +73,10 @@ func (v Visitor) visitFSM(i *projectile.Action) error {
 		if err := i.To(s, commonstate.Finished, false); err != nil {
 			return err
 		}
+
+		if e, ok := i.Target().(death.Component); ok && e.TargetHealth(tick) <= 0 {
+			return v.schedule.Extend([]action.Action{death.New(v.status, e)})
+		}
 	}
 	return nil
 }
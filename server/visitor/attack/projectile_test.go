@@ -1,14 +1,17 @@
 package projectile
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/downflux/game/engine/fsm/schedule"
 	"github.com/downflux/game/engine/gamestate/dirty"
 	"github.com/downflux/game/engine/id/id"
 	"github.com/downflux/game/engine/status/status"
 	"github.com/downflux/game/engine/visitor/visitor"
 	"github.com/downflux/game/server/entity/projectile"
+	"github.com/downflux/game/server/entity/registrytest"
 	"github.com/downflux/game/server/entity/tank"
 	"github.com/downflux/game/server/fsm/commonstate"
 	"github.com/downflux/game/server/fsm/move/move"
@@ -16,11 +19,15 @@ import (
 
 	gcpb "github.com/downflux/game/api/constants_go_proto"
 	gdpb "github.com/downflux/game/api/data_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
 	projectileaction "github.com/downflux/game/server/fsm/attack/projectile"
 )
 
 var (
 	_ visitor.Visitor = &Visitor{}
+
+	tankPB       = registrytest.Tank()
+	projectilePB = registrytest.TankProjectile()
 )
 
 func newTank(
@@ -30,7 +37,7 @@ func newTank(
 	pos *gdpb.Position,
 	cid id.ClientID,
 	proj *projectile.Entity) *tank.Entity {
-	e, err := tank.New(eid, tick, pos, cid, proj)
+	e, err := tank.New(tankPB, eid, tick, pos, cid, proj)
 	if err != nil {
 		t.Fatalf("New() = %v, want = nil", err)
 	}
@@ -46,10 +53,10 @@ func TestVisitPending(t *testing.T) {
 	s := status.New(time.Millisecond)
 	d := dirty.New()
 
-	projectileVisitor := New(s, d)
+	projectileVisitor := New(s, d, schedule.New([]fcpb.FSMType{fcpb.FSMType_FSM_TYPE_DEATH}))
 
 	shell, err := projectile.New(
-		id.EntityID("shell-entity"), t0, p0, cid)
+		projectilePB, id.EntityID("shell-entity"), t0, p0, cid)
 	if err != nil {
 		t.Fatalf("New() = %v, want = nil", err)
 	}
@@ -60,7 +67,7 @@ func TestVisitPending(t *testing.T) {
 	moveFSM := move.New(shell, s, target.Position(s.Tick()), move.Direct)
 	projectileFSM := projectileaction.New(source, target, moveFSM)
 
-	if err := projectileVisitor.Visit(projectileFSM); err != nil {
+	if err := projectileVisitor.Visit(context.Background(), projectileFSM); err != nil {
 		t.Fatalf("Visit() = %v, want = nil", err)
 	}
 
@@ -78,10 +85,10 @@ func TestVisitExecute(t *testing.T) {
 	s := status.New(time.Millisecond)
 	d := dirty.New()
 
-	projectileVisitor := New(s, d)
+	projectileVisitor := New(s, d, schedule.New([]fcpb.FSMType{fcpb.FSMType_FSM_TYPE_DEATH}))
 
 	shell, err := projectile.New(
-		id.EntityID("shell-entity"), t0, p1, cid)
+		projectilePB, id.EntityID("shell-entity"), t0, p1, cid)
 	if err != nil {
 		t.Fatalf("New() = %v, want = nil", err)
 	}
@@ -94,7 +101,7 @@ func TestVisitExecute(t *testing.T) {
 	moveFSM := move.New(shell, s, target.Position(s.Tick()), move.Direct)
 	projectileFSM := projectileaction.New(source, target, moveFSM)
 
-	if err := projectileVisitor.Visit(projectileFSM); err != nil {
+	if err := projectileVisitor.Visit(context.Background(), projectileFSM); err != nil {
 		t.Fatalf("Visit() = %v, want = nil", err)
 	}
 
@@ -116,3 +123,41 @@ func TestVisitExecute(t *testing.T) {
 		t.Fatalf("State() = %v, %v, want = %v, nil", got, err, commonstate.Finished)
 	}
 }
+
+func TestVisitLethal(t *testing.T) {
+	cid := id.ClientID("client-id")
+	p0 := &gdpb.Position{X: 0, Y: 0}
+	p1 := &gdpb.Position{X: 1, Y: 0}
+	t0 := id.Tick(0)
+
+	s := status.New(time.Millisecond)
+	d := dirty.New()
+	fsmSchedule := schedule.New([]fcpb.FSMType{fcpb.FSMType_FSM_TYPE_DEATH})
+
+	projectileVisitor := New(s, d, fsmSchedule)
+
+	shell, err := projectile.New(
+		projectilePB, id.EntityID("shell-entity"), t0, p1, cid)
+	if err != nil {
+		t.Fatalf("New() = %v, want = nil", err)
+	}
+
+	source := newTank(t, id.EntityID("source-entity"), t0, p0, cid, shell)
+	target := newTank(t, id.EntityID("target-entity"), t0, p1, cid, nil)
+
+	// Leave the target with less health than a single attack deals.
+	if err := target.TargetHealthCurve().Add(s.Tick(), source.AttackStrength()/2-target.TargetHealth(s.Tick())); err != nil {
+		t.Fatalf("Add() = %v, want = nil", err)
+	}
+
+	moveFSM := move.New(shell, s, target.Position(s.Tick()), move.Direct)
+	projectileFSM := projectileaction.New(source, target, moveFSM)
+
+	if err := projectileVisitor.Visit(context.Background(), projectileFSM); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+
+	if a := fsmSchedule.Get(fcpb.FSMType_FSM_TYPE_DEATH).Get(id.ActionID(target.ID())); a == nil {
+		t.Error("Get() = nil, want a non-nil value")
+	}
+}
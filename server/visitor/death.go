@@ -0,0 +1,165 @@
+// Package death implements the Visitor which destroys entities once their
+// health has been depleted.
+package death
+
+import (
+	"context"
+	"sync"
+
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/schedule"
+	"github.com/downflux/game/engine/gamestate/dirty"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/server/entity/component/structure"
+	"github.com/downflux/game/server/footprint/footprint"
+	"github.com/downflux/game/server/fsm/attack/attack"
+	"github.com/downflux/game/server/fsm/commonstate"
+	"github.com/downflux/game/server/fsm/death"
+	"github.com/downflux/game/server/fsm/move/chase"
+	"github.com/downflux/game/server/fsm/production/order"
+
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+	serverstatus "github.com/downflux/game/engine/status/status"
+)
+
+const (
+	// fsmType is the registered FSMType of the death visitor.
+	fsmType = fcpb.FSMType_FSM_TYPE_DEATH
+)
+
+var (
+	// commands is the list of FSMTypes of the actions which are issued
+	// directly to an entity, and which are canceled when the entity is
+	// destroyed.
+	commands = []fcpb.FSMType{
+		fcpb.FSMType_FSM_TYPE_MOVE,
+		fcpb.FSMType_FSM_TYPE_CHASE,
+		fcpb.FSMType_FSM_TYPE_ATTACK,
+		fcpb.FSMType_FSM_TYPE_HARVEST,
+		fcpb.FSMType_FSM_TYPE_PRODUCTION_QUEUE,
+	}
+)
+
+// Visitor marks entities with depleted health as destroyed. This struct
+// implements the visitor.Visitor interface.
+type Visitor struct {
+	visitor.Base
+
+	// status is reference to the global Executor status struct.
+	status serverstatus.ReadOnlyStatus
+
+	// dirty is a reference to the global cache of mutated Curve and
+	// Entity instances.
+	dirty *dirty.List
+
+	// mux guards the schedule, as the Visitor may be called concurrently,
+	// and multiple destroyed entities may reference the same action.
+	mux      sync.Mutex
+	schedule *schedule.Schedule
+
+	// footprints tracks the map tiles blocked by structures. The tiles
+	// of destroyed structures are reopened.
+	footprints *footprint.Map
+}
+
+// New creates a new instance of the Visitor struct.
+func New(dfStatus serverstatus.ReadOnlyStatus, dirtystate *dirty.List, fsmSchedule *schedule.Schedule, footprints *footprint.Map) *Visitor {
+	return &Visitor{
+		Base:       *visitor.New(fsmType),
+		status:     dfStatus,
+		dirty:      dirtystate,
+		schedule:   fsmSchedule,
+		footprints: footprints,
+	}
+}
+
+// cancel cancels the input action if the action has not already ended.
+func cancel(a action.Action) error {
+	s, err := a.State()
+	if err != nil {
+		return err
+	}
+	if s == commonstate.Finished || s == commonstate.Canceled {
+		return nil
+	}
+	return a.Cancel()
+}
+
+// cancelUnsafe cancels all actions issued to the specified entity, as well as
+// all actions which target the entity. Items remaining in the production queue
+// of a destroyed factory are not refunded. The caller must hold the Visitor
+// mutex.
+func (v *Visitor) cancelUnsafe(eid id.EntityID) error {
+	for _, t := range commands {
+		if a := v.schedule.Get(t).Get(id.ActionID(eid)); a != nil {
+			if err := cancel(a); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, a := range v.schedule.Get(fcpb.FSMType_FSM_TYPE_CHASE).Iter() {
+		if a.(*chase.Action).Destination().ID() == eid {
+			if err := cancel(a); err != nil {
+				return err
+			}
+		}
+	}
+	for _, a := range v.schedule.Get(fcpb.FSMType_FSM_TYPE_PRODUCTION_ORDER).Iter() {
+		if a.(*order.Action).Factory().ID() == eid {
+			if err := cancel(a); err != nil {
+				return err
+			}
+		}
+	}
+	for _, a := range v.schedule.Get(fcpb.FSMType_FSM_TYPE_ATTACK).Iter() {
+		if a.(*attack.Action).Target().ID() == eid {
+			if err := cancel(a.(*attack.Action).Chase()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *Visitor) visitFSM(node *death.Action) error {
+	s, err := node.State()
+	if err != nil {
+		return err
+	}
+
+	switch s {
+	case commonstate.Executing:
+		e := node.Component()
+		e.Delete(v.status.Tick())
+
+		if err := v.dirty.AddEntity(dirty.Entity{ID: e.ID()}); err != nil {
+			return err
+		}
+
+		v.mux.Lock()
+		err := v.cancelUnsafe(e.ID())
+		v.mux.Unlock()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := e.(structure.Component); ok {
+			if err := v.footprints.Unblock(e.ID()); err != nil {
+				return err
+			}
+		}
+
+		return node.Finish()
+	}
+	return nil
+}
+
+// Visit destroys the entity bound to a death Action.
+func (v *Visitor) Visit(ctx context.Context, a visitor.Agent) error {
+	if node, ok := a.(*death.Action); ok {
+		return v.visitFSM(node)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package death
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/schedule"
+	"github.com/downflux/game/engine/gamestate/dirty"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/map/utils"
+	"github.com/downflux/game/pathing/hpf/graph"
+	"github.com/downflux/game/server/entity/refinery"
+	"github.com/downflux/game/server/entity/registrytest"
+	"github.com/downflux/game/server/entity/tank"
+	"github.com/downflux/game/server/footprint/footprint"
+	"github.com/downflux/game/server/fsm/commonstate"
+	"github.com/downflux/game/server/fsm/death"
+	"github.com/downflux/game/server/fsm/move/move"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+	mcpb "github.com/downflux/game/map/api/constants_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	tile "github.com/downflux/game/map/map"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+var (
+	_ visitor.Visitor = &Visitor{}
+
+	tankPB     = registrytest.Tank()
+	refineryPB = &edpb.RefineryDefinition{
+		EntityType: gcpb.EntityType_ENTITY_TYPE_REFINERY,
+		Health:     100,
+	}
+)
+
+// newMap constructs an open 2x2 map.
+func newMap(t *testing.T) (*tile.Map, *graph.Graph) {
+	pb := &mdpb.TileMap{
+		Dimension: &gdpb.Coordinate{X: 2, Y: 2},
+		TerrainCosts: []*mdpb.TerrainCost{
+			{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS, Cost: 1},
+		},
+	}
+	for x := int32(0); x < 2; x++ {
+		for y := int32(0); y < 2; y++ {
+			pb.Tiles = append(pb.GetTiles(), &mdpb.Tile{
+				Coordinate:  &gdpb.Coordinate{X: x, Y: y},
+				TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS,
+			})
+		}
+	}
+
+	tm, err := tile.ImportMap(pb)
+	if err != nil {
+		t.Fatalf("ImportMap() = _, %v, want = nil", err)
+	}
+	g, err := graph.BuildGraph(tm, &gdpb.Coordinate{X: 1, Y: 1})
+	if err != nil {
+		t.Fatalf("BuildGraph() = _, %v, want = nil", err)
+	}
+	return tm, g
+}
+
+func TestVisit(t *testing.T) {
+	s := status.New(time.Millisecond)
+	s.IncrementTick()
+
+	d := dirty.New()
+	fsmSchedule := schedule.New([]fcpb.FSMType{
+		fcpb.FSMType_FSM_TYPE_MOVE,
+		fcpb.FSMType_FSM_TYPE_CHASE,
+		fcpb.FSMType_FSM_TYPE_ATTACK,
+		fcpb.FSMType_FSM_TYPE_DEATH,
+	})
+
+	e, err := tank.New(tankPB, id.EntityID("entity-id"), 0, &gdpb.Position{X: 0, Y: 0}, id.ClientID("client-id"), nil)
+	if err != nil {
+		t.Fatalf("New() = %v, want = nil", err)
+	}
+	if err := e.TargetHealthCurve().Add(s.Tick(), -e.TargetHealth(s.Tick())); err != nil {
+		t.Fatalf("Add() = %v, want = nil", err)
+	}
+
+	m := move.New(e, s, &gdpb.Position{X: 1, Y: 0}, move.Default)
+	a := death.New(s, e)
+	if err := fsmSchedule.Extend([]action.Action{m, a}); err != nil {
+		t.Fatalf("Extend() = %v, want = nil", err)
+	}
+
+	tm, g := newMap(t)
+	v := New(s, d, fsmSchedule, footprint.New(tm, g))
+	if err := v.Visit(context.Background(), a); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+
+	if got := e.End(); got != s.Tick() {
+		t.Errorf("End() = %v, want = %v", got, s.Tick())
+	}
+	if got, err := a.State(); err != nil || got != commonstate.Finished {
+		t.Errorf("State() = %v, %v, want = %v, nil", got, err, commonstate.Finished)
+	}
+	if got, err := m.State(); err != nil || got != commonstate.Canceled {
+		t.Errorf("State() = %v, %v, want = %v, nil", got, err, commonstate.Canceled)
+	}
+	if got := d.Pop().Entities(); len(got) != 1 || got[0].ID != e.ID() {
+		t.Errorf("Entities() = %v, want = [%v]", got, e.ID())
+	}
+}
+
+func TestVisitStructure(t *testing.T) {
+	s := status.New(time.Millisecond)
+	s.IncrementTick()
+
+	tm, g := newMap(t)
+	fm := footprint.New(tm, g)
+
+	r, err := refinery.New(refineryPB, id.EntityID("refinery"), 0, &gdpb.Position{X: 1, Y: 1}, id.ClientID("client-id"))
+	if err != nil {
+		t.Fatalf("New() = %v, want = nil", err)
+	}
+	if err := fm.Block(r, 0); err != nil {
+		t.Fatalf("Block() = %v, want = nil", err)
+	}
+	if err := r.TargetHealthCurve().Add(s.Tick(), -r.TargetHealth(s.Tick())); err != nil {
+		t.Fatalf("Add() = %v, want = nil", err)
+	}
+
+	a := death.New(s, r)
+	fsmSchedule := schedule.New([]fcpb.FSMType{
+		fcpb.FSMType_FSM_TYPE_MOVE,
+		fcpb.FSMType_FSM_TYPE_CHASE,
+		fcpb.FSMType_FSM_TYPE_ATTACK,
+		fcpb.FSMType_FSM_TYPE_DEATH,
+	})
+	if err := fsmSchedule.Extend([]action.Action{a}); err != nil {
+		t.Fatalf("Extend() = %v, want = nil", err)
+	}
+
+	v := New(s, dirty.New(), fsmSchedule, fm)
+	if err := v.Visit(context.Background(), a); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+
+	if !footprint.Open(tm, utils.MapCoordinate{X: 1, Y: 1}) {
+		t.Error("Open() = false, want = true")
+	}
+}
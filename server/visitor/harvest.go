@@ -0,0 +1,273 @@
+// Package harvest implements the Visitor which drives the harvesting loop of
+// harvesters. Idle harvesters collect resources from the nearest resource
+// field until full, then unload the collected resources at the nearest
+// refinery owned by the same client in exchange for credits.
+package harvest
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/entity/list"
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/schedule"
+	"github.com/downflux/game/engine/gamestate/dirty"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/map/utils"
+	"github.com/downflux/game/server/entity/component/harvestable"
+	"github.com/downflux/game/server/entity/component/structure"
+	"github.com/downflux/game/server/entity/player"
+	"github.com/downflux/game/server/fsm/commonstate"
+	"github.com/downflux/game/server/fsm/harvest"
+	"github.com/downflux/game/server/fsm/move/move"
+	"github.com/downflux/game/server/spatial/spatial"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+	serverstatus "github.com/downflux/game/engine/status/status"
+)
+
+const (
+	// fsmType is the registered FSMType of the harvest visitor.
+	fsmType = fcpb.FSMType_FSM_TYPE_HARVEST
+
+	// harvestRadius is the maximum distance, in units of tiles, between a
+	// harvester and a resource field or refinery for the harvester to
+	// collect or unload resources.
+	harvestRadius = 1
+
+	// bucketSize is the side length, in tiles, of the buckets of the
+	// spatial index used to look up resource fields and refineries.
+	bucketSize = 8
+)
+
+// Visitor advances the harvesting loop of each harvester. This struct
+// implements the visitor.Visitor and visitor.Planner interfaces.
+type Visitor struct {
+	visitor.Base
+
+	// status is reference to the global Executor status struct.
+	status serverstatus.ReadOnlyStatus
+
+	// dirty is a reference to the global cache of mutated Curve and
+	// Entity instances.
+	dirty *dirty.List
+
+	// mux guards the resource field, cargo, and credits curves, as well
+	// as the schedule, as the Visitor may be called concurrently, and
+	// multiple harvesters may share the same resource field or client.
+	mux      sync.Mutex
+	entities *list.List
+	schedule *schedule.Schedule
+
+	// index tracks the positions of all entities at the current tick.
+	index *spatial.Tracker
+
+	// planned is the set of harvest actions which were already advanced
+	// by Plan in the current tick.
+	planned map[id.ActionID]bool
+}
+
+// New creates a new instance of the Visitor struct.
+func New(dfStatus serverstatus.ReadOnlyStatus, entities *list.List, dirtystate *dirty.List, fsmSchedule *schedule.Schedule) *Visitor {
+	return &Visitor{
+		Base:     *visitor.New(fsmType),
+		status:   dfStatus,
+		entities: entities,
+		dirty:    dirtystate,
+		schedule: fsmSchedule,
+		index:    spatial.NewTracker(entities, dfStatus, bucketSize),
+	}
+}
+
+// Plan discards the spatial index built in the previous tick, which may be
+// stale after a rollback, and advances all harvest actions scheduled for the
+// current tick serially, in the order of the action ID. Harvesters may contend
+// for the resources of the same field, and the outcome must not depend on the
+// order in which the actions would otherwise be visited concurrently.
+//
+// Plan does not return early if the input context is done, as the contended
+// resources would otherwise be split differently on rollback or replay.
+func (v *Visitor) Plan(ctx context.Context, agents []visitor.Agent) error {
+	v.index.Reset()
+	v.planned = map[id.ActionID]bool{}
+
+	var nodes []*harvest.Action
+	for _, a := range agents {
+		if node, ok := a.(*harvest.Action); ok {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+
+	for _, node := range nodes {
+		if err := v.visitFSM(node); err != nil {
+			return err
+		}
+		v.planned[node.ID()] = true
+	}
+	return nil
+}
+
+// nearest returns the positionable entity closest to the input position which
+// satisfies the input filters. Ties are broken by the entity ID to ensure
+// replays are deterministic.
+func (v *Visitor) nearest(p *gdpb.Position, filters ...spatial.Filter) (entity.Entity, error) {
+	ix, err := v.index.Index()
+	if err != nil {
+		return nil, err
+	}
+	if res := ix.Nearest(p, 1, filters...); len(res) > 0 {
+		return res[0], nil
+	}
+	return nil, nil
+}
+
+// moveUnsafe directs the harvester to the input destination. The caller must
+// hold the Visitor mutex.
+func (v *Visitor) moveUnsafe(node *harvest.Action, destination *gdpb.Position) error {
+	m := move.New(node.Source(), v.status, destination, move.Default)
+	if err := v.schedule.Extend([]action.Action{m}); err != nil {
+		return err
+	}
+	return node.SetMove(m)
+}
+
+// collectUnsafe transfers resources from the resource field into the cargo of
+// the harvester. The caller must hold the Visitor mutex.
+func (v *Visitor) collectUnsafe(node *harvest.Action, f harvestable.Component) error {
+	tick := v.status.Tick()
+	h := node.Source()
+
+	amount := h.CarryRate()
+	if r := h.CarryCapacity() - h.Cargo(tick); r < amount {
+		amount = r
+	}
+	if r := f.Resources(tick); r < amount {
+		amount = r
+	}
+	if amount <= 0 {
+		return nil
+	}
+
+	if err := f.ResourcesCurve().Add(tick, -amount); err != nil {
+		return err
+	}
+	if err := h.CargoCurve().Add(tick, amount); err != nil {
+		return err
+	}
+
+	for _, c := range []dirty.Curve{
+		{EntityID: f.ID(), Property: gcpb.EntityProperty_ENTITY_PROPERTY_RESOURCES},
+		{EntityID: h.ID(), Property: gcpb.EntityProperty_ENTITY_PROPERTY_CARGO},
+	} {
+		if err := v.dirty.AddCurve(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unloadUnsafe transfers the cargo of the harvester into the credits of the
+// owning client. The caller must hold the Visitor mutex.
+func (v *Visitor) unloadUnsafe(node *harvest.Action, cid id.ClientID) error {
+	tick := v.status.Tick()
+	h := node.Source()
+
+	p, ok := v.entities.Get(player.ID(cid)).(*player.Entity)
+	if !ok {
+		return nil
+	}
+
+	cargo := h.Cargo(tick)
+	if err := p.CreditsCurve().Add(tick, cargo); err != nil {
+		return err
+	}
+	if err := h.CargoCurve().Add(tick, -cargo); err != nil {
+		return err
+	}
+
+	for _, c := range []dirty.Curve{
+		{EntityID: p.ID(), Property: gcpb.EntityProperty_ENTITY_PROPERTY_CREDITS},
+		{EntityID: h.ID(), Property: gcpb.EntityProperty_ENTITY_PROPERTY_CARGO},
+	} {
+		if err := v.dirty.AddCurve(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Visitor) visitFSM(node *harvest.Action) error {
+	s, err := node.State()
+	if err != nil {
+		return err
+	}
+
+	switch s {
+	case commonstate.Executing:
+		tick := v.status.Tick()
+		h := node.Source()
+		p := h.Position(tick)
+
+		v.mux.Lock()
+		defer v.mux.Unlock()
+
+		cargo := h.Cargo(tick)
+		if cargo < h.CarryCapacity() {
+			f, err := v.nearest(p, func(e entity.Entity) bool {
+				c, ok := e.(harvestable.Component)
+				return ok && c.Resources(tick) > 0
+			})
+			if err != nil {
+				return err
+			}
+			if f != nil {
+				c := f.(harvestable.Component)
+				if utils.Euclidean(p, c.Position(tick)) <= harvestRadius {
+					return v.collectUnsafe(node, c)
+				}
+				return v.moveUnsafe(node, c.Position(tick))
+			}
+			// Unload any partial cargo once all resource fields
+			// have been depleted.
+			if cargo == 0 {
+				return nil
+			}
+		}
+
+		cid := h.(entity.Entity).ClientID(tick)
+		r, err := v.nearest(
+			p,
+			spatial.Type(gcpb.EntityType_ENTITY_TYPE_REFINERY),
+			spatial.Clients(tick, cid),
+		)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			return nil
+		}
+		// Refineries block the tiles under their footprint, so the
+		// harvester unloads from any tile adjacent to the refinery.
+		s := r.(structure.Component)
+		if structure.Distance(s, tick, p) <= harvestRadius {
+			return v.unloadUnsafe(node, cid)
+		}
+		return v.moveUnsafe(node, s.Position(tick))
+	}
+	return nil
+}
+
+// Visit advances the harvesting loop of the harvester bound to a harvest
+// Action. Actions which were already advanced by Plan are skipped.
+func (v *Visitor) Visit(ctx context.Context, a visitor.Agent) error {
+	if node, ok := a.(*harvest.Action); ok && !v.planned[node.ID()] {
+		return v.visitFSM(node)
+	}
+	return nil
+}
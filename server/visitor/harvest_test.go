@@ -0,0 +1,193 @@
+package harvest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/entity/list"
+	"github.com/downflux/game/engine/fsm/schedule"
+	"github.com/downflux/game/engine/gamestate/dirty"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/server/entity/harvester"
+	"github.com/downflux/game/server/entity/player"
+	"github.com/downflux/game/server/entity/refinery"
+	"github.com/downflux/game/server/entity/resource"
+	"github.com/downflux/game/server/fsm/harvest"
+	"google.golang.org/protobuf/proto"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+const (
+	cid = id.ClientID("client-id")
+)
+
+var (
+	_ visitor.Visitor = &Visitor{}
+
+	harvesterPB = &edpb.HarvesterDefinition{
+		EntityType:   gcpb.EntityType_ENTITY_TYPE_HARVESTER,
+		MoveVelocity: 1,
+		Health:       100,
+		Capacity:     10,
+		HarvestRate:  4,
+	}
+	refineryPB = &edpb.RefineryDefinition{
+		EntityType: gcpb.EntityType_ENTITY_TYPE_REFINERY,
+		Health:     100,
+	}
+)
+
+type world struct {
+	status    *status.Status
+	schedule  *schedule.Schedule
+	harvester *harvester.Entity
+	field     *resource.Entity
+	player    *player.Entity
+	visitor   *Visitor
+}
+
+// newWorld constructs a game state with a single resource field at the origin
+// and a refinery at (5, 0).
+func newWorld(t *testing.T, p *gdpb.Position, cargo float64) *world {
+	s := status.New(time.Millisecond)
+	fsmSchedule := schedule.New([]fcpb.FSMType{
+		fcpb.FSMType_FSM_TYPE_MOVE,
+		fcpb.FSMType_FSM_TYPE_HARVEST,
+	})
+
+	h, err := harvester.New(harvesterPB, "harvester", 0, p, cid)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	if err := h.CargoCurve().Add(0, cargo); err != nil {
+		t.Fatalf("Add() = %v, want = nil", err)
+	}
+	f, err := resource.New("field", 0, &gdpb.Position{X: 0, Y: 0}, 5)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	r, err := refinery.New(refineryPB, "refinery", 0, &gdpb.Position{X: 5, Y: 0}, cid)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	pl, err := player.New(player.ID(cid), 0, cid, 0)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+
+	entities := list.New()
+	for _, e := range []entity.Entity{h, f, r, pl} {
+		if err := entities.Append(e); err != nil {
+			t.Fatalf("Append() = %v, want = nil", err)
+		}
+	}
+
+	return &world{
+		status:    s,
+		schedule:  fsmSchedule,
+		harvester: h,
+		field:     f,
+		player:    pl,
+		visitor:   New(s, entities, dirty.New(), fsmSchedule),
+	}
+}
+
+func TestVisitCollect(t *testing.T) {
+	w := newWorld(t, &gdpb.Position{X: 0, Y: 0}, 0)
+	a := harvest.New(w.status, w.harvester)
+
+	want := []float64{4, 5}
+	for i, cargo := range want {
+		if err := w.visitor.Visit(context.Background(), a); err != nil {
+			t.Fatalf("Visit() = %v, want = nil", err)
+		}
+		if got := w.harvester.Cargo(w.status.Tick()); got != cargo {
+			t.Errorf("[%v]: Cargo() = %v, want = %v", i, got, cargo)
+		}
+		w.status.IncrementTick()
+	}
+
+	if got := w.field.Resources(w.status.Tick()); got != 0 {
+		t.Errorf("Resources() = %v, want = 0", got)
+	}
+
+	// Once the field is depleted, the partial cargo is delivered to the
+	// refinery.
+	if err := w.visitor.Visit(context.Background(), a); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+	if a.Move() == nil || !proto.Equal(a.Move().Destination(), &gdpb.Position{X: 5, Y: 0}) {
+		t.Errorf("Move() = %v, want = a move to the refinery", a.Move())
+	}
+}
+
+func TestVisitMoveToField(t *testing.T) {
+	w := newWorld(t, &gdpb.Position{X: 3, Y: 0}, 0)
+	a := harvest.New(w.status, w.harvester)
+
+	if err := w.visitor.Visit(context.Background(), a); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+	if a.Move() == nil || !proto.Equal(a.Move().Destination(), &gdpb.Position{X: 0, Y: 0}) {
+		t.Errorf("Move() = %v, want = a move to the resource field", a.Move())
+	}
+	if got := w.schedule.Get(fcpb.FSMType_FSM_TYPE_MOVE).Get(a.ID()); got != a.Move() {
+		t.Errorf("Get() = %v, want = %v", got, a.Move())
+	}
+}
+
+func TestVisitUnload(t *testing.T) {
+	w := newWorld(t, &gdpb.Position{X: 5, Y: 0}, harvesterPB.GetCapacity())
+	a := harvest.New(w.status, w.harvester)
+
+	if err := w.visitor.Visit(context.Background(), a); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+	if got := w.harvester.Cargo(w.status.Tick()); got != 0 {
+		t.Errorf("Cargo() = %v, want = 0", got)
+	}
+	if got := w.player.Credits(w.status.Tick()); got != harvesterPB.GetCapacity() {
+		t.Errorf("Credits() = %v, want = %v", got, harvesterPB.GetCapacity())
+	}
+}
+
+func TestPlanContested(t *testing.T) {
+	w := newWorld(t, &gdpb.Position{X: 0, Y: 0}, 0)
+
+	h, err := harvester.New(harvesterPB, "a-harvester", 0, &gdpb.Position{X: 0, Y: 0}, cid)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	if err := w.visitor.entities.Append(h); err != nil {
+		t.Fatalf("Append() = %v, want = nil", err)
+	}
+
+	a := harvest.New(w.status, w.harvester)
+	b := harvest.New(w.status, h)
+
+	// The field only holds enough resources to fill one harvester; the
+	// harvester with the lower action ID collects first.
+	if err := w.visitor.Plan(context.Background(), []visitor.Agent{a, b}); err != nil {
+		t.Fatalf("Plan() = %v, want = nil", err)
+	}
+	for _, i := range []*harvest.Action{a, b} {
+		if err := w.visitor.Visit(context.Background(), i); err != nil {
+			t.Fatalf("Visit() = %v, want = nil", err)
+		}
+	}
+
+	if got := h.Cargo(w.status.Tick()); got != 4 {
+		t.Errorf("Cargo() = %v, want = %v", got, 4)
+	}
+	if got := w.harvester.Cargo(w.status.Tick()); got != 1 {
+		t.Errorf("Cargo() = %v, want = %v", got, 1)
+	}
+}
@@ -0,0 +1,200 @@
+// Package avoid implements the local collision avoidance Visitor, which steers
+// units apart so that they do not overlap.
+//
+// Units are treated as circles of a fixed radius. Once per tick, the position
+// of each unit in the next tick is predicted from its position curve, and units
+// whose predicted positions overlap are pushed apart, i.e. separation steering.
+// The push is written back into the position curve of the unit, which then
+// rejoins the originally planned path at the next waypoint.
+package avoid
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/downflux/game/engine/curve/common/linearmove"
+	"github.com/downflux/game/engine/entity/entity"
+	"github.com/downflux/game/engine/entity/list"
+	"github.com/downflux/game/engine/gamestate/dirty"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/map/utils"
+	"github.com/downflux/game/server/entity/component/moveable"
+	"github.com/downflux/game/server/spatial/spatial"
+
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+	serverstatus "github.com/downflux/game/engine/status/status"
+	tile "github.com/downflux/game/map/map"
+)
+
+const (
+	// fsmType is the registered FSMType of the avoid visitor.
+	fsmType = fcpb.FSMType_FSM_TYPE_AVOID
+
+	// radius is the radius, in tiles, of the circle occupied by a unit.
+	radius = 0.45
+
+	// epsilon is the length, in tiles, below which a push is ignored.
+	epsilon = 1e-6
+)
+
+// Visitor pushes overlapping units apart. This struct implements the
+// visitor.Visitor and visitor.Planner interfaces.
+//
+// No actions are scheduled for the Visitor; all units are instead steered
+// once per tick in Plan.
+type Visitor struct {
+	visitor.Base
+
+	// tileMap is the underlying Map object used for the game. Units are
+	// never pushed into a blocked tile.
+	tileMap *tile.Map
+
+	// status is reference to the global Executor status struct.
+	status serverstatus.ReadOnlyStatus
+
+	// entities is the list of all game entities.
+	entities *list.List
+
+	// dirty is a reference to the global cache of mutated Curve and
+	// Entity instances.
+	dirty *dirty.List
+}
+
+// New creates a new instance of the Visitor struct.
+func New(tileMap *tile.Map, dfStatus serverstatus.ReadOnlyStatus, entities *list.List, dirtystate *dirty.List) *Visitor {
+	return &Visitor{
+		Base:     *visitor.New(fsmType),
+		tileMap:  tileMap,
+		status:   dfStatus,
+		entities: entities,
+		dirty:    dirtystate,
+	}
+}
+
+// unit checks if the input entity takes part in collision avoidance.
+// Projectiles are not targetable and pass freely over units, and structures
+// are not moveable and instead block the map tiles under their footprint.
+func unit(e entity.Entity) bool {
+	return spatial.Moveable(e) && spatial.Targetable(e) && e.End() == 0
+}
+
+// push returns the displacement which separates the input unit from all
+// overlapping neighbors. Each unit of an overlapping pair is displaced by
+// half of the overlap; units at the exact same position are split along the
+// X-axis by entity ID.
+func push(ix *spatial.Index, e entity.Entity) *gdpb.Position {
+	p := ix.Position(e.ID())
+
+	res := &gdpb.Position{}
+	for _, n := range ix.Radius(p, 2*radius) {
+		if n.ID() == e.ID() {
+			continue
+		}
+		q := ix.Position(n.ID())
+		d := utils.Euclidean(p, q)
+
+		ux, uy := 1.0, 0.0
+		if d > 0 {
+			ux, uy = (p.GetX()-q.GetX())/d, (p.GetY()-q.GetY())/d
+		} else if e.ID() < n.ID() {
+			ux = -1
+		}
+
+		overlap := (2*radius - d) / 2
+		res.X += ux * overlap
+		res.Y += uy * overlap
+	}
+	return res
+}
+
+// steer replaces the next tick of the position curve of the input unit with
+// the input position. The rest of the planned curve is kept as-is.
+func (v *Visitor) steer(e moveable.Component, p *gdpb.Position) error {
+	tick := v.status.Tick()
+	c := e.PositionCurve()
+
+	cv := linearmove.New(e.ID(), tick)
+	cv.Add(tick, e.Position(tick))
+	cv.Add(tick+1, p)
+
+	d := c.Data()
+	i := d.Search(tick + 1)
+	if i < d.Len() && d.Tick(i) == tick+1 {
+		i++
+	}
+	for ; i < d.Len(); i++ {
+		cv.Add(d.Tick(i), d.Get(d.Tick(i)))
+	}
+
+	if err := v.dirty.AddCurve(dirty.Curve{
+		EntityID: e.ID(),
+		Property: c.Property(),
+	}); err != nil {
+		return err
+	}
+	return c.Merge(cv)
+}
+
+// Plan pushes apart all units which are predicted to overlap in the next
+// tick. Pushes are computed from the predicted positions of all units before
+// any curve is adjusted, so that the result does not depend on the order in
+// which units are steered, and replays are deterministic.
+//
+// A unit may be pushed at most as far as it could move in a single tick, and
+// is never pushed into a blocked tile.
+func (v *Visitor) Plan(ctx context.Context, agents []visitor.Agent) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	tick := v.status.Tick()
+	ticksPerSecond := float64(time.Second / v.status.TickDuration())
+
+	var units []entity.Entity
+	for _, e := range v.entities.Iter() {
+		if unit(e) {
+			units = append(units, e)
+		}
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].ID() < units[j].ID() })
+
+	ix, err := spatial.New(units, tick+1, 2*radius)
+	if err != nil {
+		return err
+	}
+
+	targets := map[id.EntityID]*gdpb.Position{}
+	for _, e := range units {
+		d := push(ix, e)
+		l := utils.Euclidean(d, &gdpb.Position{})
+		if l < epsilon {
+			continue
+		}
+		if max := e.(moveable.Component).MoveVelocity() / ticksPerSecond; l > max {
+			d.X, d.Y = d.GetX()*max/l, d.GetY()*max/l
+		}
+
+		p := ix.Position(e.ID())
+		q := &gdpb.Position{X: p.GetX() + d.GetX(), Y: p.GetY() + d.GetY()}
+		if !v.tileMap.Open(int32(math.Round(q.GetX())), int32(math.Round(q.GetY()))) {
+			continue
+		}
+		targets[e.ID()] = q
+	}
+
+	for _, e := range units {
+		if q, found := targets[e.ID()]; found {
+			if err := v.steer(e.(moveable.Component), q); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Visit is a no-op, as no actions are scheduled for the Visitor.
+func (v *Visitor) Visit(ctx context.Context, a visitor.Agent) error { return nil }
@@ -0,0 +1,206 @@
+package avoid
+
+import (
+	"context"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/downflux/game/engine/entity/list"
+	"github.com/downflux/game/engine/gamestate/dirty"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/server/entity/registrytest"
+	"github.com/downflux/game/server/entity/tank"
+	"github.com/google/go-cmp/cmp"
+
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	mcpb "github.com/downflux/game/map/api/constants_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	tile "github.com/downflux/game/map/map"
+)
+
+var (
+	_ visitor.Visitor = &Visitor{}
+	_ visitor.Planner = &Visitor{}
+)
+
+// newMap returns an open 3x3 map, with the input tiles blocked.
+func newMap(t *testing.T, blocked []*gdpb.Coordinate) *tile.Map {
+	pb := &mdpb.TileMap{
+		Dimension: &gdpb.Coordinate{X: 3, Y: 3},
+		TerrainCosts: []*mdpb.TerrainCost{
+			{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_BLOCKED, Cost: math.Inf(0)},
+			{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS, Cost: 1},
+		},
+	}
+	for x := int32(0); x < 3; x++ {
+		for y := int32(0); y < 3; y++ {
+			pb.Tiles = append(pb.GetTiles(), &mdpb.Tile{
+				Coordinate:  &gdpb.Coordinate{X: x, Y: y},
+				TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS,
+			})
+		}
+	}
+	for _, c := range blocked {
+		pb.Tiles[c.GetX()*3+c.GetY()].TerrainType = mcpb.TerrainType_TERRAIN_TYPE_BLOCKED
+	}
+
+	tm, err := tile.ImportMap(pb)
+	if err != nil {
+		t.Fatalf("ImportMap() = _, %v, want = _, nil", err)
+	}
+	return tm
+}
+
+func newTank(t *testing.T, eid id.EntityID, v float64, p *gdpb.Position) *tank.Entity {
+	pb := registrytest.Tank()
+	pb.MoveVelocity = v
+	e, err := tank.New(pb, eid, 0, p, "", nil)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = _, nil", err)
+	}
+	return e
+}
+
+// near checks if two positions are equal, up to floating point error.
+func near(a, b *gdpb.Position) bool {
+	return math.Abs(a.GetX()-b.GetX()) < 1e-9 && math.Abs(a.GetY()-b.GetY()) < 1e-9
+}
+
+func TestPlan(t *testing.T) {
+	type unit struct {
+		eid id.EntityID
+		v   float64
+
+		// path is the list of waypoints of the unit, one every 10
+		// ticks, starting at tick 0.
+		path []*gdpb.Position
+	}
+
+	testConfigs := []struct {
+		name    string
+		blocked []*gdpb.Coordinate
+		units   []unit
+
+		// want is the expected position of each unit at tick 1.
+		want map[id.EntityID]*gdpb.Position
+
+		// end is the expected position of each unit at the end of its
+		// path.
+		end map[id.EntityID]*gdpb.Position
+
+		steered []id.EntityID
+	}{
+		{
+			name: "Apart",
+			units: []unit{
+				{eid: "a", v: 10, path: []*gdpb.Position{{X: 0, Y: 0}}},
+				{eid: "b", v: 10, path: []*gdpb.Position{{X: 2, Y: 2}}},
+			},
+			want: map[id.EntityID]*gdpb.Position{
+				"a": {X: 0, Y: 0},
+				"b": {X: 2, Y: 2},
+			},
+		},
+		{
+			name: "Coincident",
+			units: []unit{
+				{eid: "a", v: 10, path: []*gdpb.Position{{X: 1, Y: 1}}},
+				{eid: "b", v: 10, path: []*gdpb.Position{{X: 1, Y: 1}}},
+			},
+			want: map[id.EntityID]*gdpb.Position{
+				"a": {X: 1 - radius, Y: 1},
+				"b": {X: 1 + radius, Y: 1},
+			},
+			steered: []id.EntityID{"a", "b"},
+		},
+		{
+			name: "Capped",
+			units: []unit{
+				{eid: "a", v: 1, path: []*gdpb.Position{{X: 1, Y: 1}}},
+				{eid: "b", v: 1, path: []*gdpb.Position{{X: 1, Y: 1}}},
+			},
+			want: map[id.EntityID]*gdpb.Position{
+				"a": {X: 0.9, Y: 1},
+				"b": {X: 1.1, Y: 1},
+			},
+			steered: []id.EntityID{"a", "b"},
+		},
+		{
+			name:    "Wall",
+			blocked: []*gdpb.Coordinate{{X: 0, Y: 1}},
+			units: []unit{
+				{eid: "a", v: 10, path: []*gdpb.Position{{X: 0.6, Y: 1}}},
+				{eid: "b", v: 10, path: []*gdpb.Position{{X: 1, Y: 1}}},
+			},
+			want: map[id.EntityID]*gdpb.Position{
+				"a": {X: 0.6, Y: 1},
+				"b": {X: 1 + (2*radius-0.4)/2, Y: 1},
+			},
+			steered: []id.EntityID{"b"},
+		},
+		{
+			name: "Moving",
+			units: []unit{
+				{eid: "a", v: 1, path: []*gdpb.Position{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}}},
+				{eid: "b", v: 1, path: []*gdpb.Position{{X: 0.1, Y: 0.5}}},
+			},
+			want: map[id.EntityID]*gdpb.Position{
+				"a": {X: 0.1, Y: -0.1},
+				"b": {X: 0.1, Y: 0.6},
+			},
+			end: map[id.EntityID]*gdpb.Position{
+				"a": {X: 2, Y: 0},
+			},
+			steered: []id.EntityID{"a", "b"},
+		},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			s := status.New(100 * time.Millisecond)
+			d := dirty.New()
+			entities := list.New()
+
+			tanks := map[id.EntityID]*tank.Entity{}
+			for _, u := range c.units {
+				e := newTank(t, u.eid, u.v, u.path[0])
+				for i, p := range u.path {
+					e.PositionCurve().Add(id.Tick(10*i), p)
+				}
+				if err := entities.Append(e); err != nil {
+					t.Fatalf("Append() = %v, want = nil", err)
+				}
+				tanks[u.eid] = e
+			}
+
+			v := New(newMap(t, c.blocked), s, entities, d)
+			if err := v.Plan(context.Background(), nil); err != nil {
+				t.Fatalf("Plan() = %v, want = nil", err)
+			}
+
+			for eid, want := range c.want {
+				if got := tanks[eid].Position(1); !near(got, want) {
+					t.Errorf("Position(%v) = %v, want = %v", eid, got, want)
+				}
+			}
+			for eid, want := range c.end {
+				if got := tanks[eid].Position(math.MaxInt32); !near(got, want) {
+					t.Errorf("Position(%v) = %v, want = %v", eid, got, want)
+				}
+			}
+
+			var got []id.EntityID
+			for _, curve := range d.Curves() {
+				got = append(got, curve.EntityID)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			if diff := cmp.Diff(c.steered, got); diff != "" {
+				t.Errorf("Curves() mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
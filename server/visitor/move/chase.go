@@ -1,6 +1,8 @@
 package chase
 
 import (
+	"context"
+
 	"github.com/downflux/game/engine/fsm/action"
 	"github.com/downflux/game/engine/fsm/schedule"
 	"github.com/downflux/game/engine/status/status"
@@ -49,7 +51,7 @@ func (v *Visitor) visitFSM(node *chase.Action) error {
 	return nil
 }
 
-func (v *Visitor) Visit(a visitor.Agent) error {
+func (v *Visitor) Visit(ctx context.Context, a visitor.Agent) error {
 	if node, ok := a.(*chase.Action); ok {
 		return v.visitFSM(node)
 	}
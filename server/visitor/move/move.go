@@ -1,6 +1,7 @@
 package move
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -9,8 +10,10 @@ import (
 	"github.com/downflux/game/engine/id/id"
 	"github.com/downflux/game/engine/visitor/visitor"
 	"github.com/downflux/game/map/utils"
+	"github.com/downflux/game/pathing/flowfield/flowfield"
 	"github.com/downflux/game/pathing/hpf/astar"
 	"github.com/downflux/game/pathing/hpf/graph"
+	"github.com/downflux/game/server/footprint/footprint"
 	"github.com/downflux/game/server/fsm/commonstate"
 	"github.com/downflux/game/server/fsm/move/move"
 	"google.golang.org/grpc/codes"
@@ -19,12 +22,18 @@ import (
 	gdpb "github.com/downflux/game/api/data_go_proto"
 	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
 	serverstatus "github.com/downflux/game/engine/status/status"
+	tileastar "github.com/downflux/game/map/astar"
 	tile "github.com/downflux/game/map/map"
 )
 
 const (
 	// fsmType is the registered FSMType of the move visitor.
 	fsmType = fcpb.FSMType_FSM_TYPE_MOVE
+
+	// minGroupSize is the minimum number of entities moving to the same
+	// destination in a single tick for the Visitor to plan their paths
+	// with a shared flow field instead of separate A* searches.
+	minGroupSize = 8
 )
 
 // coordinate transforms a gdpb.Position instance into a gdpb.Coordinate
@@ -49,10 +58,6 @@ func position(c *gdpb.Coordinate) *gdpb.Position {
 type Visitor struct {
 	visitor.Base
 
-	// mux guarantees we're running only one tile.Map astar at a time.
-	// TODO(minkezhang): Make this concurrent.
-	mux sync.Mutex
-
 	// tileMap is the underlying Map object used for the game.
 	tileMap *tile.Map
 
@@ -74,6 +79,26 @@ type Visitor struct {
 	// outdated once a new move command is issued for the Entity, which
 	// may happen frequently in an RTS game.
 	minPathLength int
+
+	// minGroupSize is the minimum number of entities moving to the same
+	// destination for which a flow field is used.
+	minGroupSize int
+
+	// fields holds the flow fields of all destinations shared by at
+	// least minGroupSize entities moving in the current tick. Fields are
+	// computed lazily by the first entity in the group to be visited.
+	//
+	// The map itself is only written in Plan, which is never called
+	// concurrently with Visit.
+	fields map[utils.MapCoordinate]*group
+}
+
+// group is a lazily computed flow field shared by all entities moving to the
+// same destination.
+type group struct {
+	once sync.Once
+	f    *flowfield.Field
+	err  error
 }
 
 // New constructs a new move Visitor instance.
@@ -90,22 +115,84 @@ func New(
 		status:        dfStatus,
 		dirty:         dirtystate,
 		minPathLength: minPathLength,
+		minGroupSize:  minGroupSize,
+	}
+}
+
+// destination returns the tile to which the path of the input move is
+// planned. Entities may be sent to a blocked tile, e.g. when sent to a
+// structure; the path is instead planned to the nearest open tile.
+func (v *Visitor) destination(node *move.Action) utils.MapCoordinate {
+	dest, _ := footprint.Nearest(v.tileMap, utils.MC(coordinate(node.Destination())))
+	return dest
+}
+
+// Plan groups the moves which will be executed in the current tick by
+// destination, so that large groups share a single flow field.
+func (v *Visitor) Plan(ctx context.Context, agents []visitor.Agent) error {
+	v.fields = map[utils.MapCoordinate]*group{}
+
+	counts := map[utils.MapCoordinate]int{}
+	for _, a := range agents {
+		node, ok := a.(*move.Action)
+		if !ok || node.MoveType() != move.Default {
+			continue
+		}
+		s, err := node.State()
+		if err != nil {
+			return err
+		}
+		if s == commonstate.Executing {
+			counts[v.destination(node)]++
+		}
+	}
+
+	for dest, n := range counts {
+		if n >= v.minGroupSize {
+			v.fields[dest] = &group{}
+		}
 	}
+	return nil
 }
 
-func (v *Visitor) generatePath(node *move.Action) ([]*tile.Tile, error) {
+func (v *Visitor) generatePath(ctx context.Context, node *move.Action) ([]*tile.Tile, error) {
 	t := node.MoveType()
 	switch t {
 	case move.Default:
-		p, _, err := astar.Path(
-			v.tileMap,
-			v.abstractGraph,
-			utils.MC(coordinate(node.Component().Position(v.status.Tick()))),
-			utils.MC(coordinate(node.Destination())),
-			v.minPathLength,
-		)
-		// TODO(minkezhang): Handle error by logging and continuing.
-		return p, err
+		// Entities may start inside of a blocked tile, e.g. when
+		// spawned by a factory. The path is instead planned from the
+		// nearest open tile.
+		src, _ := footprint.Nearest(v.tileMap, utils.MC(coordinate(node.Component().Position(v.status.Tick()))))
+		dest := v.destination(node)
+
+		var p []*tile.Tile
+		var err error
+		if g, found := v.fields[dest]; found {
+			g.once.Do(func() {
+				g.f, g.err = flowfield.New(ctx, v.tileMap, dest)
+			})
+			if g.err != nil {
+				return nil, g.err
+			}
+			p, _, err = g.f.Path(src, v.minPathLength)
+		} else {
+			p, _, err = astar.Path(
+				ctx,
+				v.tileMap,
+				v.abstractGraph,
+				src,
+				dest,
+				v.minPathLength,
+			)
+		}
+		if err != nil {
+			// TODO(minkezhang): Handle error by logging and continuing.
+			return nil, err
+		}
+		if v.tileMap.AnyAngle() {
+			p = tileastar.Smooth(v.tileMap, p)
+		}
+		return p, nil
 	case move.Direct:
 		if node.Destination().GetX() >= float64(v.tileMap.D.X) ||
 			node.Destination().GetY() >= float64(v.tileMap.D.Y) {
@@ -117,7 +204,9 @@ func (v *Visitor) generatePath(node *move.Action) ([]*tile.Tile, error) {
 			)
 		}
 
-		// TODO(minkezhang): Check for collisions, e.g. walls.
+		// Direct moves are only used by projectiles, which fly over
+		// walls and units alike. Collisions between units are instead
+		// resolved by the avoid Visitor.
 		return []*tile.Tile{v.tileMap.TileFromCoordinate(coordinate(node.Destination()))}, nil
 	default:
 		return nil, status.Errorf(
@@ -126,7 +215,7 @@ func (v *Visitor) generatePath(node *move.Action) ([]*tile.Tile, error) {
 	}
 }
 
-func (v *Visitor) visitFSM(node *move.Action) error {
+func (v *Visitor) visitFSM(ctx context.Context, node *move.Action) error {
 	s, err := node.State()
 	if err != nil {
 		return err
@@ -145,7 +234,10 @@ func (v *Visitor) visitFSM(node *move.Action) error {
 		ticksPerSecond := float64(time.Second / v.status.TickDuration())
 		ticksPerTile := id.Tick(ticksPerSecond / e.MoveVelocity())
 
-		p, err := v.generatePath(node)
+		// Path generation is expensive -- if we run out of time
+		// during the search, the context error is returned here, and
+		// the move is deferred to the next tick.
+		p, err := v.generatePath(ctx, node)
 		if err != nil {
 			return err
 		}
@@ -197,12 +289,14 @@ func (v *Visitor) visitFSM(node *move.Action) error {
 }
 
 // Visit mutates the specified entity's position curve.
-func (v *Visitor) Visit(a visitor.Agent) error {
-	v.mux.Lock()
-	defer v.mux.Unlock()
-
+//
+// Visit may be called concurrently for different entities. The tile.Map and
+// graph.Graph are only read here; both are mutated (e.g. when a building is
+// placed or destroyed) by other Visitor instances, which never run
+// concurrently with this one.
+func (v *Visitor) Visit(ctx context.Context, a visitor.Agent) error {
 	if node, ok := a.(*move.Action); ok {
-		return v.visitFSM(node)
+		return v.visitFSM(ctx, node)
 	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package move
 
 import (
+	"context"
 	"math"
 	"testing"
 	"time"
@@ -10,10 +11,14 @@ import (
 	"github.com/downflux/game/engine/id/id"
 	"github.com/downflux/game/engine/status/status"
 	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/map/utils"
 	"github.com/downflux/game/pathing/hpf/graph"
+	"github.com/downflux/game/server/entity/registrytest"
 	"github.com/downflux/game/server/entity/tank"
 	"github.com/downflux/game/server/fsm/move/move"
 	"github.com/google/go-cmp/cmp"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/testing/protocmp"
 
 	gcpb "github.com/downflux/game/api/constants_go_proto"
 	gdpb "github.com/downflux/game/api/data_go_proto"
@@ -49,6 +54,8 @@ var (
 	}
 
 	_ visitor.Visitor = &Visitor{}
+
+	tankPB = registrytest.Tank()
 )
 
 func newVisitor(t *testing.T) *Visitor {
@@ -70,7 +77,7 @@ func newVisitor(t *testing.T) *Visitor {
 
 func newTank(t *testing.T, eid id.EntityID, tick id.Tick, p *gdpb.Position) *tank.Entity {
 	cid := id.ClientID("client-id")
-	tankEntity, err := tank.New(eid, tick, p, cid, nil)
+	tankEntity, err := tank.New(tankPB, eid, tick, p, cid, nil)
 	if err != nil {
 		t.Fatalf("New() = %v, want = nil", err)
 	}
@@ -98,7 +105,7 @@ func TestVisitError(t *testing.T) {
 				v.status, &gdpb.Position{X: 0, Y: float64(simpleMap.GetDimension().GetY())},
 				c.moveType)
 
-			if err := v.Visit(i); err == nil {
+			if err := v.Visit(context.Background(), i); err == nil {
 				t.Error("Visit() = nil, want a non-nil error")
 			}
 		})
@@ -156,7 +163,7 @@ func TestVisit(t *testing.T) {
 
 	for _, c := range testConfigs {
 		t.Run(c.name, func(t *testing.T) {
-			if err := c.v.Visit(c.i); err != nil {
+			if err := c.v.Visit(context.Background(), c.i); err != nil {
 				t.Fatalf("Visit() = %v, want = nil", err)
 			}
 			got := c.v.dirty.Pop().Curves()
@@ -166,3 +173,170 @@ func TestVisit(t *testing.T) {
 		})
 	}
 }
+
+func TestVisitDeadlineExceeded(t *testing.T) {
+	const eid = "entity-id"
+	const t0 = 0
+	p0 := &gdpb.Position{X: 0, Y: 0}
+	p1 := &gdpb.Position{X: 0, Y: 1}
+
+	v := newVisitor(t)
+	i := move.New(newTank(t, eid, t0, p0), v.status, p1, move.Default)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := v.Visit(ctx, i); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+	if got := v.dirty.Pop().Curves(); got != nil {
+		t.Errorf("Pop() = %v, want = nil", got)
+	}
+
+	// The deferred move should be processed in a later pass.
+	if err := v.Visit(context.Background(), i); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+	want := []dirty.Curve{
+		{EntityID: eid, Property: gcpb.EntityProperty_ENTITY_PROPERTY_POSITION},
+	}
+	if diff := cmp.Diff(want, v.dirty.Pop().Curves()); diff != "" {
+		t.Errorf("Pop() mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestVisitBlockedDestination(t *testing.T) {
+	const eid = "entity-id"
+	const t0 = 0
+	p0 := &gdpb.Position{X: 0, Y: 0}
+
+	tm, err := tile.ImportMap(simpleMap)
+	if err != nil {
+		t.Fatalf("Import() = _, %v, want = nil", err)
+	}
+	tm.Tile(2, 2).SetTerrainType(mcpb.TerrainType_TERRAIN_TYPE_BLOCKED)
+
+	g, err := graph.BuildGraph(tm, &gdpb.Coordinate{X: 1, Y: 1})
+	if err != nil {
+		t.Fatalf("BuildGraph() = _, %v, want = nil", err)
+	}
+
+	v := New(tm, g, status.New(time.Millisecond), dirty.New(), 0)
+	e := newTank(t, eid, t0, p0)
+	i := move.New(e, v.status, &gdpb.Position{X: 2, Y: 2}, move.Default)
+
+	if err := v.Visit(context.Background(), i); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+
+	// The tank should stop at the open tile nearest to the blocked
+	// destination.
+	want := &gdpb.Position{X: 1, Y: 2}
+	if diff := cmp.Diff(want, e.Position(math.MaxInt32), protocmp.Transform()); diff != "" {
+		t.Errorf("Position() mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestVisitAnyAngle(t *testing.T) {
+	const eid = "entity-id"
+	const t0 = 0
+	p0 := &gdpb.Position{X: 0, Y: 0}
+
+	tm, err := tile.ImportMap(simpleMap)
+	if err != nil {
+		t.Fatalf("Import() = _, %v, want = nil", err)
+	}
+	tm.MovementType = mcpb.MovementType_MOVEMENT_TYPE_ANY_ANGLE
+
+	g, err := graph.BuildGraph(tm, &gdpb.Coordinate{X: 1, Y: 1})
+	if err != nil {
+		t.Fatalf("BuildGraph() = _, %v, want = nil", err)
+	}
+
+	v := New(tm, g, status.New(time.Millisecond), dirty.New(), 0)
+	e := newTank(t, eid, t0, p0)
+	i := move.New(e, v.status, &gdpb.Position{X: 2, Y: 1}, move.Default)
+
+	if err := v.Visit(context.Background(), i); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+
+	// The tank should move in a straight line to the destination, taking
+	// ticksPerTile * sqrt(5) ticks to arrive.
+	end := id.Tick(500 * math.Sqrt(5))
+	testConfigs := []struct {
+		name string
+		tick id.Tick
+		want *gdpb.Position
+	}{
+		{name: "Midpoint", tick: end / 2, want: &gdpb.Position{X: 1, Y: 0.5}},
+		{name: "Destination", tick: end, want: &gdpb.Position{X: 2, Y: 1}},
+	}
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if diff := cmp.Diff(c.want, e.Position(c.tick), protocmp.Transform()); diff != "" {
+				t.Errorf("Position() mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	tm, err := tile.ImportMap(simpleMap)
+	if err != nil {
+		t.Fatalf("Import() = _, %v, want = nil", err)
+	}
+	g, err := graph.BuildGraph(tm, &gdpb.Coordinate{X: 1, Y: 1})
+	if err != nil {
+		t.Fatalf("BuildGraph() = _, %v, want = nil", err)
+	}
+
+	v := New(tm, g, status.New(time.Millisecond), dirty.New(), 0)
+	v.minGroupSize = 2
+
+	group := &gdpb.Position{X: 2, Y: 2}
+	single := &gdpb.Position{X: 2, Y: 0}
+
+	var tanks []*tank.Entity
+	var agents []visitor.Agent
+	for i, c := range []struct {
+		p    *gdpb.Position
+		dest *gdpb.Position
+	}{
+		{p: &gdpb.Position{X: 0, Y: 0}, dest: group},
+		{p: &gdpb.Position{X: 0, Y: 1}, dest: group},
+		{p: &gdpb.Position{X: 1, Y: 0}, dest: single},
+	} {
+		e := newTank(t, id.EntityID(string(rune('a'+i))), 0, c.p)
+		tanks = append(tanks, e)
+		agents = append(agents, move.New(e, v.status, c.dest, move.Default))
+	}
+
+	if err := v.Plan(context.Background(), agents); err != nil {
+		t.Fatalf("Plan() = %v, want = nil", err)
+	}
+	if g, found := v.fields[utils.MapCoordinate{X: 2, Y: 2}]; !found || g.f != nil {
+		t.Errorf("fields[(2, 2)] = %v, %v, want = {nil}, true", g, found)
+	}
+	if _, found := v.fields[utils.MapCoordinate{X: 2, Y: 0}]; found {
+		t.Errorf("fields[(2, 0)] = _, %v, want = _, false", found)
+	}
+
+	var eg errgroup.Group
+	for _, a := range agents {
+		a := a
+		eg.Go(func() error { return v.Visit(context.Background(), a) })
+	}
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+	if g := v.fields[utils.MapCoordinate{X: 2, Y: 2}]; g.f == nil {
+		t.Error("fields[(2, 2)] = nil, want a non-nil flow field")
+	}
+
+	for i, want := range []*gdpb.Position{group, group, single} {
+		if diff := cmp.Diff(want, tanks[i].Position(math.MaxInt32), protocmp.Transform()); diff != "" {
+			t.Errorf("Position() mismatch (-want +got):\n%v", diff)
+		}
+	}
+}
@@ -1,15 +1,32 @@
 package produce
 
 import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"sync"
+
 	"github.com/downflux/game/engine/entity/entity"
 	"github.com/downflux/game/engine/entity/list"
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/schedule"
 	"github.com/downflux/game/engine/gamestate/dirty"
 	"github.com/downflux/game/engine/id/id"
 	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/server/entity/component/structure"
+	"github.com/downflux/game/server/entity/factory"
+	"github.com/downflux/game/server/entity/harvester"
+	"github.com/downflux/game/server/entity/player"
 	"github.com/downflux/game/server/entity/projectile"
+	"github.com/downflux/game/server/entity/refinery"
+	"github.com/downflux/game/server/entity/registry"
 	"github.com/downflux/game/server/entity/tank"
+	"github.com/downflux/game/server/footprint/footprint"
 	"github.com/downflux/game/server/fsm/commonstate"
+	"github.com/downflux/game/server/fsm/harvest"
+	"github.com/downflux/game/server/fsm/move/move"
 	"github.com/downflux/game/server/fsm/produce"
+	"github.com/downflux/game/server/fsm/production/queue"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -34,11 +51,15 @@ func unsupportedEntityType(t gcpb.EntityType) error {
 	return status.Errorf(codes.Unimplemented, "creating a new %v entity is not supported", t)
 }
 
-// Visitor adds a new Entity to the global state. This struct implements the
-// visitor.Visitor interface.
+// Visitor adds a new Entity to the global state, and charges the spawning
+// client for the cost of the Entity. This struct implements the
+// visitor.Visitor and visitor.Planner interfaces.
 type Visitor struct {
 	visitor.Base
 
+	// mux guards the entities list and the credits of each client, as the
+	// Visitor may be called concurrently.
+	mux      sync.Mutex
 	entities *list.List
 
 	// dirty is a reference to the global cache of mutated Curve and
@@ -47,62 +68,216 @@ type Visitor struct {
 
 	// status is reference to the global Executor status struct.
 	status serverstatus.ReadOnlyStatus
+
+	// registry is the list of unit archetypes which may be produced.
+	registry *registry.Registry // Read-only.
+
+	// schedule is a reference to the global FSM schedule, and is used to
+	// start the harvesting loop of newly produced harvesters, the
+	// production queue of newly produced factories, and the move of newly
+	// produced units to the rally point of the factory.
+	schedule *schedule.Schedule
+
+	// footprints tracks the map tiles blocked by structures. Newly
+	// produced structures block the tiles under their footprint.
+	footprints *footprint.Map
 }
 
 // New creates a new instance of the Visitor struct.
-func New(dfStatus serverstatus.ReadOnlyStatus, entities *list.List, dirtystate *dirty.List) *Visitor {
+func New(dfStatus serverstatus.ReadOnlyStatus, entities *list.List, dirtystate *dirty.List, r *registry.Registry, fsmSchedule *schedule.Schedule, footprints *footprint.Map) *Visitor {
 	return &Visitor{
-		Base:     *visitor.New(fsmType),
-		entities: entities,
-		dirty:    dirtystate,
-		status:   dfStatus,
+		Base:       *visitor.New(fsmType),
+		entities:   entities,
+		dirty:      dirtystate,
+		status:     dfStatus,
+		registry:   r,
+		schedule:   fsmSchedule,
+		footprints: footprints,
 	}
 }
 
 // TODO(minkezhang): Delete this function.
 func (v *Visitor) Schedule(args interface{}) error { return nil }
 
-func (v *Visitor) generateEID(l int) id.EntityID {
-	eid := id.EntityID(id.RandomString(entityIDLen))
+// seed deterministically transforms the produce action UUID into a random
+// number generator seed. This ensures replaying the same produce action will
+// create entities with the same UUIDs.
+func seed(aid id.ActionID) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(aid.Value()))
+	return int64(h.Sum64())
+}
+
+func (v *Visitor) generateEID(g *id.Generator, l int) id.EntityID {
+	eid := id.EntityID(g.RandomString(l))
 	for v.entities.Get(eid) != nil {
-		eid = id.EntityID(id.RandomString(entityIDLen))
+		eid = id.EntityID(g.RandomString(l))
 	}
 	return eid
 }
 
+// placeable checks if the entity spawned by the input produce action may be
+// placed at the spawn position. Only structures are restricted, and may not
+// overlap blocked tiles, e.g. walls or other structures.
+func (v *Visitor) placeable(node *produce.Action) bool {
+	var d *gdpb.Coordinate
+	switch t := node.EntityType(); {
+	case v.registry.Refinery(t) != nil:
+		d = v.registry.Refinery(t).GetFootprint()
+	case v.registry.Factory(t) != nil:
+		d = v.registry.Factory(t).GetFootprint()
+	default:
+		return true
+	}
+	return v.footprints.Placeable(node.SpawnPosition(), structure.New(d).Footprint())
+}
+
+// chargeUnsafe deducts the cost of the produced entity from the credits of the
+// spawning client. chargeUnsafe returns false if the client cannot afford the
+// entity. The caller must hold the Visitor mutex.
+func (v *Visitor) chargeUnsafe(node *produce.Action) (bool, error) {
+	if node.PaymentType() == produce.Free {
+		return true, nil
+	}
+
+	cost, ok := v.registry.Cost(node.EntityType())
+	if !ok {
+		return false, unsupportedEntityType(node.EntityType())
+	}
+	if cost == 0 {
+		return true, nil
+	}
+
+	tick := v.status.Tick()
+
+	p, ok := v.entities.Get(player.ID(node.SpawnClientID())).(*player.Entity)
+	if !ok || p.Credits(tick) < cost {
+		return false, nil
+	}
+	if err := p.CreditsCurve().Add(tick, -cost); err != nil {
+		return false, err
+	}
+	return true, v.dirty.AddCurve(dirty.Curve{EntityID: p.ID(), Property: gcpb.EntityProperty_ENTITY_PROPERTY_CREDITS})
+}
+
+// buildUnsafe constructs the entities spawned by the input produce action.
+// The caller must hold the Visitor mutex.
+func (v *Visitor) buildUnsafe(node *produce.Action) ([]entity.Entity, error) {
+	tick := v.status.Tick()
+	g := id.NewGenerator(seed(node.ID()))
+
+	entityType := node.EntityType()
+	switch {
+	case entityType == gcpb.EntityType_ENTITY_TYPE_PLAYER:
+		// Each client has a single player entity, which is created
+		// when the client joins the game.
+		eid := player.ID(node.SpawnClientID())
+		if v.entities.Get(eid) != nil {
+			return nil, nil
+		}
+		p, err := player.New(eid, tick, node.SpawnClientID(), v.registry.StartingCredits())
+		if err != nil {
+			return nil, err
+		}
+		return []entity.Entity{p}, nil
+	case v.registry.Unit(entityType) != nil:
+		u := v.registry.Unit(entityType)
+
+		shellEID := v.generateEID(g, entityIDLen)
+		unitEID := v.generateEID(g, entityIDLen)
+
+		shell, err := projectile.New(
+			v.registry.Projectile(u.GetAttack().GetProjectileType()),
+			shellEID, tick, &gdpb.Position{X: 0, Y: 0}, node.SpawnClientID())
+		if err != nil {
+			return nil, err
+		}
+		t, err := tank.New(
+			u, unitEID, tick, node.SpawnPosition(), node.SpawnClientID(), shell)
+		if err != nil {
+			return nil, err
+		}
+
+		if p := node.RallyPoint(); p != nil {
+			if err := v.schedule.Extend([]action.Action{
+				move.New(t, v.status, p, move.Default),
+			}); err != nil {
+				return nil, err
+			}
+		}
+		return []entity.Entity{t, shell}, nil
+	case v.registry.Harvester(entityType) != nil:
+		h, err := harvester.New(
+			v.registry.Harvester(entityType),
+			v.generateEID(g, entityIDLen), tick, node.SpawnPosition(), node.SpawnClientID())
+		if err != nil {
+			return nil, err
+		}
+
+		// Harvesters start harvesting immediately after being
+		// produced.
+		if err := v.schedule.Extend([]action.Action{harvest.New(v.status, h)}); err != nil {
+			return nil, err
+		}
+		return []entity.Entity{h}, nil
+	case v.registry.Refinery(entityType) != nil:
+		r, err := refinery.New(
+			v.registry.Refinery(entityType),
+			v.generateEID(g, entityIDLen), tick, node.SpawnPosition(), node.SpawnClientID())
+		if err != nil {
+			return nil, err
+		}
+		return []entity.Entity{r}, nil
+	case v.registry.Factory(entityType) != nil:
+		f, err := factory.New(
+			v.registry.Factory(entityType),
+			v.generateEID(g, entityIDLen), tick, node.SpawnPosition(), node.SpawnClientID())
+		if err != nil {
+			return nil, err
+		}
+
+		// Each factory owns a single production queue for its
+		// lifetime.
+		if err := v.schedule.Extend([]action.Action{queue.New(v.status, f)}); err != nil {
+			return nil, err
+		}
+		return []entity.Entity{f}, nil
+	default:
+		return nil, unsupportedEntityType(entityType)
+	}
+}
+
 func (v *Visitor) visitFSM(node *produce.Action) error {
 	s, err := node.State()
 	if err != nil {
 		return err
 	}
 
-	tick := v.status.Tick()
-
 	switch s {
 	case commonstate.Executing:
-		defer node.Finish()
+		v.mux.Lock()
+		defer v.mux.Unlock()
 
-		var es []entity.Entity
+		if !v.placeable(node) {
+			return node.Cancel()
+		}
 
-		switch entityType := node.EntityType(); entityType {
-		case gcpb.EntityType_ENTITY_TYPE_TANK:
-			shellEID := v.generateEID(entityIDLen)
-			tankEID := v.generateEID(entityIDLen)
+		ok, err := v.chargeUnsafe(node)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return node.Cancel()
+		}
 
-			shell, err := projectile.New(
-				shellEID, tick, &gdpb.Position{X: 0, Y: 0}, node.SpawnClientID())
-			t, err := tank.New(
-				tankEID, tick, node.SpawnPosition(), node.SpawnClientID(), shell)
-			if err != nil {
-				return err
-			}
+		defer node.Finish()
 
-			es = append(es, t, shell)
-		default:
-			return unsupportedEntityType(entityType)
+		entities, err := v.buildUnsafe(node)
+		if err != nil {
+			return err
 		}
 
-		for _, e := range es {
+		for _, e := range entities {
 			if err := v.dirty.AddEntity(dirty.Entity{ID: e.ID()}); err != nil {
 				return err
 			}
@@ -115,6 +290,12 @@ func (v *Visitor) visitFSM(node *produce.Action) error {
 					return err
 				}
 			}
+
+			if s, ok := e.(structure.Component); ok {
+				if err := v.footprints.Block(s, v.status.Tick()); err != nil {
+					return err
+				}
+			}
 		}
 	default:
 		return nil
@@ -123,8 +304,33 @@ func (v *Visitor) visitFSM(node *produce.Action) error {
 	return nil
 }
 
+// Plan applies all produce actions scheduled for the current tick serially, in
+// the order of the action ID. Actions may contend for the credits of the same
+// client or for the same map tiles, and the outcome must not depend on the
+// order in which the actions would otherwise be visited concurrently.
+//
+// Plan does not return early if the input context is done, as the contended
+// actions would otherwise be settled differently on rollback or replay. The
+// actions settled here are no longer executing, and are skipped by Visit.
+func (v *Visitor) Plan(ctx context.Context, agents []visitor.Agent) error {
+	var nodes []*produce.Action
+	for _, a := range agents {
+		if node, ok := a.(*produce.Action); ok {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+
+	for _, node := range nodes {
+		if err := v.visitFSM(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Visit mutates an entity.List with a new Entity.
-func (v *Visitor) Visit(a visitor.Agent) error {
+func (v *Visitor) Visit(ctx context.Context, a visitor.Agent) error {
 	if node, ok := a.(*produce.Action); ok {
 		return v.visitFSM(node)
 	}
@@ -1,9 +1,230 @@
 package produce
 
 import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/downflux/game/engine/entity/list"
+	"github.com/downflux/game/engine/fsm/fsm"
+	"github.com/downflux/game/engine/fsm/schedule"
+	"github.com/downflux/game/engine/gamestate/dirty"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
 	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/pathing/hpf/graph"
+	"github.com/downflux/game/server/entity/player"
+	"github.com/downflux/game/server/entity/registry"
+	"github.com/downflux/game/server/entity/registrytest"
+	"github.com/downflux/game/server/footprint/footprint"
+	"github.com/downflux/game/server/fsm/commonstate"
+	"github.com/downflux/game/server/fsm/produce"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+	mcpb "github.com/downflux/game/map/api/constants_go_proto"
+	mdpb "github.com/downflux/game/map/api/data_go_proto"
+	tile "github.com/downflux/game/map/map"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
 )
 
 var (
 	_ visitor.Visitor = &Visitor{}
+
+	registryPB = func() *edpb.Registry {
+		pb := registrytest.Registry()
+		pb.GetUnits()[0].Cost = 10
+		pb.Refineries = []*edpb.RefineryDefinition{
+			{
+				EntityType: gcpb.EntityType_ENTITY_TYPE_REFINERY,
+				Health:     100,
+				Footprint:  &gdpb.Coordinate{X: 2, Y: 1},
+			},
+		}
+		pb.StartingCredits = 15
+		return pb
+	}()
 )
+
+// newFootprints constructs a footprint.Map over an open 3x3 map.
+func newFootprints(t *testing.T) *footprint.Map {
+	pb := &mdpb.TileMap{
+		Dimension: &gdpb.Coordinate{X: 3, Y: 3},
+		TerrainCosts: []*mdpb.TerrainCost{
+			{TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS, Cost: 1},
+		},
+	}
+	for x := int32(0); x < 3; x++ {
+		for y := int32(0); y < 3; y++ {
+			pb.Tiles = append(pb.GetTiles(), &mdpb.Tile{
+				Coordinate:  &gdpb.Coordinate{X: x, Y: y},
+				TerrainType: mcpb.TerrainType_TERRAIN_TYPE_PLAINS,
+			})
+		}
+	}
+
+	tm, err := tile.ImportMap(pb)
+	if err != nil {
+		t.Fatalf("ImportMap() = _, %v, want = nil", err)
+	}
+	g, err := graph.BuildGraph(tm, &gdpb.Coordinate{X: 2, Y: 2})
+	if err != nil {
+		t.Fatalf("BuildGraph() = _, %v, want = nil", err)
+	}
+	return footprint.New(tm, g)
+}
+
+func TestVisitCharge(t *testing.T) {
+	s := status.New(time.Millisecond)
+	cid := id.ClientID("client-id")
+	p := &gdpb.Position{X: 0, Y: 0}
+
+	r, err := registry.New(registryPB)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	entities := list.New()
+	v := New(s, entities, dirty.New(), r, schedule.New([]fcpb.FSMType{fcpb.FSMType_FSM_TYPE_HARVEST}), newFootprints(t))
+
+	account := produce.New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_PLAYER, nil, cid, produce.Free)
+	if err := v.Visit(context.Background(), account); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+	pl, ok := entities.Get(player.ID(cid)).(*player.Entity)
+	if !ok {
+		t.Fatalf("Get() = %v, want a player entity", entities.Get(player.ID(cid)))
+	}
+
+	testConfigs := []struct {
+		name    string
+		a       *produce.Action
+		want    fsm.State
+		credits float64
+	}{
+		{
+			name:    "Affordable",
+			a:       produce.New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_TANK, p, cid, produce.Charged),
+			want:    commonstate.Finished,
+			credits: 5,
+		},
+		{
+			name:    "Unaffordable",
+			a:       produce.New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_TANK, p, cid, produce.Charged),
+			want:    commonstate.Canceled,
+			credits: 5,
+		},
+		{
+			name:    "Free",
+			a:       produce.New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_TANK, p, cid, produce.Free),
+			want:    commonstate.Finished,
+			credits: 5,
+		},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if err := v.Visit(context.Background(), c.a); err != nil {
+				t.Fatalf("Visit() = %v, want = nil", err)
+			}
+			if got, err := c.a.State(); err != nil || got != c.want {
+				t.Errorf("State() = %v, %v, want = %v, nil", got, err, c.want)
+			}
+			if got := pl.Credits(s.Tick()); got != c.credits {
+				t.Errorf("Credits() = %v, want = %v", got, c.credits)
+			}
+		})
+	}
+}
+
+func TestVisitPlacement(t *testing.T) {
+	s := status.New(time.Millisecond)
+	cid := id.ClientID("client-id")
+
+	r, err := registry.New(registryPB)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	fm := newFootprints(t)
+	v := New(s, list.New(), dirty.New(), r, schedule.New([]fcpb.FSMType{fcpb.FSMType_FSM_TYPE_HARVEST}), fm)
+
+	testConfigs := []struct {
+		name string
+		a    *produce.Action
+		want fsm.State
+	}{
+		{
+			name: "Open",
+			a:    produce.New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_REFINERY, &gdpb.Position{X: 0, Y: 0}, cid, produce.Free),
+			want: commonstate.Finished,
+		},
+		{
+			name: "Overlapping",
+			a:    produce.New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_REFINERY, &gdpb.Position{X: 1, Y: 0}, cid, produce.Free),
+			want: commonstate.Canceled,
+		},
+		{
+			name: "OutOfBounds",
+			a:    produce.New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_REFINERY, &gdpb.Position{X: 2, Y: 1}, cid, produce.Free),
+			want: commonstate.Canceled,
+		},
+		{
+			name: "Adjacent",
+			a:    produce.New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_REFINERY, &gdpb.Position{X: 0, Y: 1}, cid, produce.Free),
+			want: commonstate.Finished,
+		},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			if err := v.Visit(context.Background(), c.a); err != nil {
+				t.Fatalf("Visit() = %v, want = nil", err)
+			}
+			if got, err := c.a.State(); err != nil || got != c.want {
+				t.Errorf("State() = %v, %v, want = %v, nil", got, err, c.want)
+			}
+		})
+	}
+
+	if fm.Placeable(&gdpb.Position{X: 1, Y: 1}, &gdpb.Coordinate{X: 1, Y: 1}) {
+		t.Errorf("Placeable() = true, want = false")
+	}
+}
+
+func TestPlanContested(t *testing.T) {
+	s := status.New(time.Millisecond)
+	cid := id.ClientID("client-id")
+	p := &gdpb.Position{X: 0, Y: 0}
+
+	r, err := registry.New(registryPB)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	entities := list.New()
+	v := New(s, entities, dirty.New(), r, schedule.New([]fcpb.FSMType{fcpb.FSMType_FSM_TYPE_HARVEST}), newFootprints(t))
+
+	account := produce.New(s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_PLAYER, nil, cid, produce.Free)
+	if err := v.Visit(context.Background(), account); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+
+	// The client may only afford one of the two tanks; the action with the
+	// lower ID is charged first.
+	a := produce.NewWithID("action-a", s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_TANK, p, cid, produce.Charged)
+	b := produce.NewWithID("action-b", s, s.Tick(), gcpb.EntityType_ENTITY_TYPE_TANK, p, cid, produce.Charged)
+	if err := v.Plan(context.Background(), []visitor.Agent{b, a}); err != nil {
+		t.Fatalf("Plan() = %v, want = nil", err)
+	}
+
+	for _, c := range []struct {
+		a    *produce.Action
+		want fsm.State
+	}{
+		{a: a, want: commonstate.Finished},
+		{a: b, want: commonstate.Canceled},
+	} {
+		if got, err := c.a.State(); err != nil || got != c.want {
+			t.Errorf("State() = %v, %v, want = %v, nil", got, err, c.want)
+		}
+	}
+}
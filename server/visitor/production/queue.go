@@ -0,0 +1,233 @@
+// Package queue implements the Visitor which drives the production queues of
+// factories. Pending production orders are applied to the queue of the
+// targeted factory, and the item at the head of each queue is built one tick
+// at a time. Completed items are handed off to the produce visitor.
+package queue
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/downflux/game/engine/entity/list"
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/schedule"
+	"github.com/downflux/game/engine/gamestate/dirty"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/server/entity/player"
+	"github.com/downflux/game/server/entity/registry"
+	"github.com/downflux/game/server/fsm/commonstate"
+	"github.com/downflux/game/server/fsm/produce"
+	"github.com/downflux/game/server/fsm/production/order"
+	"github.com/downflux/game/server/fsm/production/queue"
+	"google.golang.org/protobuf/proto"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+	serverstatus "github.com/downflux/game/engine/status/status"
+)
+
+const (
+	// fsmType is the registered FSMType of the production queue visitor.
+	fsmType = fcpb.FSMType_FSM_TYPE_PRODUCTION_QUEUE
+)
+
+// Visitor applies production orders and advances the build progress of each
+// production queue. This struct implements the visitor.Visitor interface.
+type Visitor struct {
+	visitor.Base
+
+	// status is reference to the global Executor status struct.
+	status serverstatus.ReadOnlyStatus
+
+	// dirty is a reference to the global cache of mutated Curve and
+	// Entity instances.
+	dirty *dirty.List
+
+	// registry is the list of entity archetypes which may be produced.
+	registry *registry.Registry // Read-only.
+
+	// mux guards the credits curves and the schedule, as the Visitor may
+	// be called concurrently, and multiple factories may be owned by the
+	// same client.
+	mux      sync.Mutex
+	entities *list.List
+	schedule *schedule.Schedule
+}
+
+// New creates a new instance of the Visitor struct.
+func New(dfStatus serverstatus.ReadOnlyStatus, entities *list.List, dirtystate *dirty.List, r *registry.Registry, fsmSchedule *schedule.Schedule) *Visitor {
+	return &Visitor{
+		Base:     *visitor.New(fsmType),
+		status:   dfStatus,
+		entities: entities,
+		dirty:    dirtystate,
+		registry: r,
+		schedule: fsmSchedule,
+	}
+}
+
+// ordersUnsafe returns the list of pending orders issued to the input queue.
+// Orders are sorted by type and then by ID to ensure replays are
+// deterministic. The caller must hold the Visitor mutex.
+func (v *Visitor) ordersUnsafe(node *queue.Action) ([]*order.Action, error) {
+	var orders []*order.Action
+	for _, a := range v.schedule.Get(fcpb.FSMType_FSM_TYPE_PRODUCTION_ORDER).Iter() {
+		o := a.(*order.Action)
+		if id.ActionID(o.Factory().ID()) != node.ID() {
+			continue
+		}
+		s, err := o.State()
+		if err != nil {
+			return nil, err
+		}
+		if s == commonstate.Pending {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].OrderType() < orders[j].OrderType() || orders[i].OrderType() == orders[j].OrderType() && orders[i].ID() < orders[j].ID()
+	})
+	return orders, nil
+}
+
+// creditUnsafe adds the input amount to the credits of the owner of the input
+// queue. creditUnsafe returns false if the owner does not have enough credits
+// to cover a negative amount. The caller must hold the Visitor mutex.
+func (v *Visitor) creditUnsafe(node *queue.Action, amount float64) (bool, error) {
+	if amount == 0 {
+		return true, nil
+	}
+
+	tick := v.status.Tick()
+
+	cid := v.entities.Get(node.Source().ID()).ClientID(tick)
+	p, ok := v.entities.Get(player.ID(cid)).(*player.Entity)
+	if !ok || p.Credits(tick)+amount < 0 {
+		return false, nil
+	}
+	if err := p.CreditsCurve().Add(tick, amount); err != nil {
+		return false, err
+	}
+	return true, v.dirty.AddCurve(dirty.Curve{EntityID: p.ID(), Property: gcpb.EntityProperty_ENTITY_PROPERTY_CREDITS})
+}
+
+// applyUnsafe mutates the input queue as directed by the input order. Orders
+// which cannot be applied are canceled. The caller must hold the Visitor mutex.
+func (v *Visitor) applyUnsafe(node *queue.Action, o *order.Action) error {
+	tick := v.status.Tick()
+	f := node.Source()
+
+	switch o.OrderType() {
+	case order.Enqueue:
+		cost, ok := v.registry.Cost(o.EntityType())
+		buildTicks, _ := v.registry.BuildTicks(o.EntityType())
+		if !ok || !f.CanProduce(o.EntityType()) {
+			return o.Cancel()
+		}
+		ok, err := v.creditUnsafe(node, -cost)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return o.Cancel()
+		}
+		node.Enqueue(&queue.Item{
+			ID:         o.ID(),
+			EntityType: o.EntityType(),
+			Cost:       cost,
+			BuildTicks: buildTicks,
+		})
+	case order.Dequeue:
+		i := node.Dequeue(o.ItemID())
+		if i == nil {
+			return o.Cancel()
+		}
+		if _, err := v.creditUnsafe(node, i.Cost); err != nil {
+			return err
+		}
+	case order.Reorder:
+		if !node.Reorder(o.ItemID(), o.Index()) {
+			return o.Cancel()
+		}
+	case order.Rally:
+		if err := f.RallyPointCurve().Add(tick, o.RallyPoint()); err != nil {
+			return err
+		}
+		if err := v.dirty.AddCurve(dirty.Curve{EntityID: f.ID(), Property: gcpb.EntityProperty_ENTITY_PROPERTY_RALLY_POINT}); err != nil {
+			return err
+		}
+	}
+	return o.Finish()
+}
+
+// buildUnsafe advances the build progress of the head of the queue, and
+// schedules the produce action for the item once the item is complete. The
+// caller must hold the Visitor mutex.
+func (v *Visitor) buildUnsafe(node *queue.Action) error {
+	tick := v.status.Tick()
+	f := node.Source()
+
+	i := node.Head()
+	i.Progress++
+	if i.Progress < i.BuildTicks {
+		return nil
+	}
+	node.Pop()
+
+	// The item was charged when it was added to the queue.
+	p := produce.NewWithID(
+		i.ID,
+		v.status,
+		tick,
+		i.EntityType,
+		f.Position(tick),
+		v.entities.Get(f.ID()).ClientID(tick),
+		produce.Free)
+	if r := f.RallyPoint(tick); r != nil && !proto.Equal(r, f.Position(tick)) {
+		p.SetRallyPoint(r)
+	}
+	return v.schedule.Extend([]action.Action{p})
+}
+
+func (v *Visitor) visitFSM(node *queue.Action) error {
+	s, err := node.State()
+	if err != nil {
+		return err
+	}
+	if s == commonstate.Canceled {
+		return nil
+	}
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	orders, err := v.ordersUnsafe(node)
+	if err != nil {
+		return err
+	}
+	for _, o := range orders {
+		if err := v.applyUnsafe(node, o); err != nil {
+			return err
+		}
+	}
+
+	s, err = node.State()
+	if err != nil {
+		return err
+	}
+	if s == commonstate.Executing {
+		return v.buildUnsafe(node)
+	}
+	return nil
+}
+
+// Visit applies pending production orders to a production queue Action and
+// advances the build progress of the queue.
+func (v *Visitor) Visit(ctx context.Context, a visitor.Agent) error {
+	if node, ok := a.(*queue.Action); ok {
+		return v.visitFSM(node)
+	}
+	return nil
+}
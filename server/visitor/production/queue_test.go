@@ -0,0 +1,194 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/downflux/game/engine/entity/list"
+	"github.com/downflux/game/engine/fsm/action"
+	"github.com/downflux/game/engine/fsm/fsm"
+	"github.com/downflux/game/engine/fsm/schedule"
+	"github.com/downflux/game/engine/gamestate/dirty"
+	"github.com/downflux/game/engine/id/id"
+	"github.com/downflux/game/engine/status/status"
+	"github.com/downflux/game/engine/visitor/visitor"
+	"github.com/downflux/game/server/entity/factory"
+	"github.com/downflux/game/server/entity/player"
+	"github.com/downflux/game/server/entity/registry"
+	"github.com/downflux/game/server/entity/registrytest"
+	"github.com/downflux/game/server/fsm/commonstate"
+	"github.com/downflux/game/server/fsm/produce"
+	"github.com/downflux/game/server/fsm/production/order"
+	"github.com/downflux/game/server/fsm/production/queue"
+	"google.golang.org/protobuf/proto"
+
+	gcpb "github.com/downflux/game/api/constants_go_proto"
+	gdpb "github.com/downflux/game/api/data_go_proto"
+	fcpb "github.com/downflux/game/engine/fsm/api/constants_go_proto"
+	edpb "github.com/downflux/game/server/entity/api/data_go_proto"
+)
+
+const (
+	cid = id.ClientID("client-id")
+)
+
+var (
+	_ visitor.Visitor = &Visitor{}
+
+	registryPB = func() *edpb.Registry {
+		pb := registrytest.Registry()
+		pb.GetUnits()[0].Cost = 10
+		pb.GetUnits()[0].BuildTicks = 2
+		pb.Factories = []*edpb.FactoryDefinition{
+			{
+				EntityType: gcpb.EntityType_ENTITY_TYPE_FACTORY,
+				Health:     100,
+				Produces:   []gcpb.EntityType{gcpb.EntityType_ENTITY_TYPE_TANK},
+			},
+		}
+		return pb
+	}()
+)
+
+type world struct {
+	status   *status.Status
+	schedule *schedule.Schedule
+	factory  *factory.Entity
+	player   *player.Entity
+	queue    *queue.Action
+	visitor  *Visitor
+}
+
+// newWorld constructs a game state with a single factory at the origin, owned
+// by a client with the input number of credits.
+func newWorld(t *testing.T, credits float64) *world {
+	s := status.New(time.Millisecond)
+	fsmSchedule := schedule.New([]fcpb.FSMType{
+		fcpb.FSMType_FSM_TYPE_PRODUCE,
+		fcpb.FSMType_FSM_TYPE_PRODUCTION_QUEUE,
+		fcpb.FSMType_FSM_TYPE_PRODUCTION_ORDER,
+	})
+
+	r, err := registry.New(registryPB)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	f, err := factory.New(r.Factory(gcpb.EntityType_ENTITY_TYPE_FACTORY), "factory", 0, &gdpb.Position{X: 0, Y: 0}, cid)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+	p, err := player.New(player.ID(cid), 0, cid, credits)
+	if err != nil {
+		t.Fatalf("New() = _, %v, want = nil", err)
+	}
+
+	entities := list.New()
+	if err := entities.Append(f); err != nil {
+		t.Fatalf("Append() = %v, want = nil", err)
+	}
+	if err := entities.Append(p); err != nil {
+		t.Fatalf("Append() = %v, want = nil", err)
+	}
+
+	q := queue.New(s, f)
+	if err := fsmSchedule.Extend([]action.Action{q}); err != nil {
+		t.Fatalf("Extend() = %v, want = nil", err)
+	}
+
+	return &world{
+		status:   s,
+		schedule: fsmSchedule,
+		factory:  f,
+		player:   p,
+		queue:    q,
+		visitor:  New(s, entities, dirty.New(), r, fsmSchedule),
+	}
+}
+
+// issue schedules the input orders and runs the visitor over the queue.
+func (w *world) issue(t *testing.T, orders ...*order.Action) {
+	var actions []action.Action
+	for _, o := range orders {
+		actions = append(actions, o)
+	}
+	if err := w.schedule.Extend(actions); err != nil {
+		t.Fatalf("Extend() = %v, want = nil", err)
+	}
+	if err := w.visitor.Visit(context.Background(), w.queue); err != nil {
+		t.Fatalf("Visit() = %v, want = nil", err)
+	}
+}
+
+func TestVisitEnqueue(t *testing.T) {
+	testConfigs := []struct {
+		name    string
+		credits float64
+		t       gcpb.EntityType
+		want    fsm.State
+		items   int
+	}{
+		{name: "Affordable", credits: 15, t: gcpb.EntityType_ENTITY_TYPE_TANK, want: commonstate.Finished, items: 1},
+		{name: "Unaffordable", credits: 5, t: gcpb.EntityType_ENTITY_TYPE_TANK, want: commonstate.Canceled, items: 0},
+		{name: "NotProduced", credits: 15, t: gcpb.EntityType_ENTITY_TYPE_FACTORY, want: commonstate.Canceled, items: 0},
+	}
+
+	for _, c := range testConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			w := newWorld(t, c.credits)
+			o := order.NewEnqueue(w.status, w.factory, c.t)
+			w.issue(t, o)
+
+			if got, err := o.State(); err != nil || got != c.want {
+				t.Errorf("State() = %v, %v, want = %v, nil", got, err, c.want)
+			}
+			if got := len(w.queue.Items()); got != c.items {
+				t.Errorf("len(Items()) = %v, want = %v", got, c.items)
+			}
+		})
+	}
+}
+
+func TestVisitDequeue(t *testing.T) {
+	w := newWorld(t, 15)
+	o := order.NewEnqueue(w.status, w.factory, gcpb.EntityType_ENTITY_TYPE_TANK)
+	w.issue(t, o)
+
+	w.status.IncrementTick()
+	w.issue(t, order.NewDequeue(w.status, w.factory, o.ID()))
+
+	if got := len(w.queue.Items()); got != 0 {
+		t.Errorf("len(Items()) = %v, want = %v", got, 0)
+	}
+	if got := w.player.Credits(w.status.Tick()); got != 15 {
+		t.Errorf("Credits() = %v, want = %v", got, 15)
+	}
+}
+
+func TestVisitBuild(t *testing.T) {
+	w := newWorld(t, 15)
+	rallyPoint := &gdpb.Position{X: 3, Y: 4}
+	o := order.NewEnqueue(w.status, w.factory, gcpb.EntityType_ENTITY_TYPE_TANK)
+	w.issue(t, o, order.NewRally(w.status, w.factory, rallyPoint))
+
+	if a := w.schedule.Get(fcpb.FSMType_FSM_TYPE_PRODUCE).Get(o.ID()); a != nil {
+		t.Fatalf("Get() = %v, want = nil", a)
+	}
+
+	w.status.IncrementTick()
+	w.issue(t)
+
+	a, ok := w.schedule.Get(fcpb.FSMType_FSM_TYPE_PRODUCE).Get(o.ID()).(*produce.Action)
+	if !ok {
+		t.Fatalf("Get() = %v, want a produce action", a)
+	}
+	if got := a.EntityType(); got != gcpb.EntityType_ENTITY_TYPE_TANK {
+		t.Errorf("EntityType() = %v, want = %v", got, gcpb.EntityType_ENTITY_TYPE_TANK)
+	}
+	if got := a.RallyPoint(); !proto.Equal(got, rallyPoint) {
+		t.Errorf("RallyPoint() = %v, want = %v", got, rallyPoint)
+	}
+	if got := len(w.queue.Items()); got != 0 {
+		t.Errorf("len(Items()) = %v, want = %v", got, 0)
+	}
+}